@@ -0,0 +1,129 @@
+// Package api เป็น typed client สำหรับ Loyverse API v1.0
+// แต่ละ resource มี method List* ที่คืนค่าเป็นหน้า (page) แบบ strongly typed
+// และ method ที่คืนค่า Iterator สำหรับเดินผ่านทุกหน้าด้วย cursor
+package api
+
+import (
+	"backend/external/loyverse/utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL คือ URL หลักของ Loyverse API
+const DefaultBaseURL = "https://api.loyverse.com/v1.0"
+
+// MaxLimit คือจำนวนรายการสูงสุดต่อหน้าที่ Loyverse API อนุญาต
+const MaxLimit = 250
+
+// TimeFormat รูปแบบเวลา ISO 8601 ที่ Loyverse API ใช้ใน query parameter
+const TimeFormat = "2006-01-02T15:04:05.000Z"
+
+// Client เรียก Loyverse API ผ่าน utils.Client ซึ่งจัดการ retry และ rate limit ให้แล้ว
+type Client struct {
+	BaseURL string
+	HTTP    *utils.Client
+}
+
+// NewClient สร้าง Client ที่ชี้ไปยัง DefaultBaseURL
+func NewClient(token string) *Client {
+	return &Client{
+		BaseURL: DefaultBaseURL,
+		HTTP:    utils.NewClient(token),
+	}
+}
+
+// ListOptions คือ query parameter ที่ใช้ร่วมกันในทุก endpoint แบบ list
+// ฟิลด์ที่เป็นค่า zero จะไม่ถูกส่งไปกับ request
+type ListOptions struct {
+	Limit        int
+	Cursor       string
+	ShowDeleted  bool
+	StoreID      string
+	VariantIDs   []string
+	UpdatedAtMin time.Time
+	UpdatedAtMax time.Time
+	CreatedAtMin time.Time
+	CreatedAtMax time.Time
+}
+
+func (o ListOptions) values() url.Values {
+	q := url.Values{}
+	limit := o.Limit
+	if limit <= 0 || limit > MaxLimit {
+		limit = MaxLimit
+	}
+	q.Set("limit", strconv.Itoa(limit))
+	if o.Cursor != "" {
+		q.Set("cursor", o.Cursor)
+	}
+	if o.ShowDeleted {
+		q.Set("show_deleted", "true")
+	}
+	if o.StoreID != "" {
+		q.Set("store_id", o.StoreID)
+	}
+	if len(o.VariantIDs) > 0 {
+		q.Set("variant_ids", strings.Join(o.VariantIDs, ","))
+	}
+	setTime(q, "updated_at_min", o.UpdatedAtMin)
+	setTime(q, "updated_at_max", o.UpdatedAtMax)
+	setTime(q, "created_at_min", o.CreatedAtMin)
+	setTime(q, "created_at_max", o.CreatedAtMax)
+	return q
+}
+
+func setTime(q url.Values, key string, t time.Time) {
+	if !t.IsZero() {
+		q.Set(key, t.UTC().Format(TimeFormat))
+	}
+}
+
+// list ส่ง GET ไปยัง resource แล้ว decode ผลลัพธ์ลงใน out
+func (c *Client) list(ctx context.Context, resource string, opts ListOptions, out interface{}) error {
+	endpoint := c.endpoint(resource) + "?" + opts.values().Encode()
+	body, err := c.HTTP.Get(ctx, endpoint)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("loyverse api: decoding %s response: %w", resource, err)
+	}
+	return nil
+}
+
+// get ส่ง GET ไปยัง resource เดียว (เช่น "items/<id>") แล้ว decode ผลลัพธ์ลงใน out
+func (c *Client) get(ctx context.Context, resource string, out interface{}) error {
+	body, err := c.HTTP.Get(ctx, c.endpoint(resource))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("loyverse api: decoding %s response: %w", resource, err)
+	}
+	return nil
+}
+
+// post ส่ง in แบบ JSON ไปยัง resource แล้ว decode ผลลัพธ์ลงใน out
+func (c *Client) post(ctx context.Context, resource string, in, out interface{}) error {
+	payload, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	body, err := c.HTTP.Post(ctx, c.endpoint(resource), payload)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("loyverse api: decoding %s response: %w", resource, err)
+	}
+	return nil
+}
+
+func (c *Client) endpoint(resource string) string {
+	return strings.TrimRight(c.BaseURL, "/") + "/" + resource
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"backend/external/loyverse/models"
+	"backend/external/loyverse/utils"
+	"context"
+)
+
+// fetchPageFunc ดึงข้อมูลหนึ่งหน้าและคืนค่ารายการพร้อม cursor ของหน้าถัดไป
+type fetchPageFunc[T any] func(ctx context.Context, opts ListOptions) ([]T, string, error)
+
+// Iterator เดินผ่านทุกหน้าของ resource โดยใช้ cursor ที่ Loyverse ส่งกลับมา
+//
+//	it := client.Items(api.ListOptions{})
+//	for it.Next(ctx) {
+//		items := it.Page()
+//	}
+//	if err := it.Err(); err != nil { ... }
+type Iterator[T any] struct {
+	resource string
+	fetch    fetchPageFunc[T]
+	opts     ListOptions
+	page     []T
+	pages    int
+	done     bool
+	err      error
+}
+
+func newIterator[T any](resource string, opts ListOptions, fetch fetchPageFunc[T]) *Iterator[T] {
+	return &Iterator[T]{resource: resource, fetch: fetch, opts: opts}
+}
+
+// Next ดึงหน้าถัดไป คืนค่า false เมื่อไม่มีหน้าเหลือหรือเกิด error (ตรวจสอบด้วย Err)
+// ทุกหน้าที่ดึงได้ถูกแจ้งเป็น event page ผ่าน utils.ReportProgress ของ ctx
+func (it *Iterator[T]) Next(ctx context.Context) bool {
+	if it.done || it.err != nil {
+		return false
+	}
+
+	page, cursor, err := it.fetch(ctx, it.opts)
+	if err != nil {
+		it.err = err
+		it.page = nil
+		return false
+	}
+
+	it.page = page
+	it.pages++
+	utils.ReportProgress(ctx, models.SyncJobEvent{
+		Type:     models.SyncJobEventPage,
+		Resource: it.resource,
+		Page:     it.pages,
+		Rows:     int64(len(page)),
+	})
+	it.opts.Cursor = cursor
+	if cursor == "" {
+		it.done = true
+	}
+	return true
+}
+
+// Page คืนค่ารายการของหน้าปัจจุบัน
+func (it *Iterator[T]) Page() []T {
+	return it.page
+}
+
+// Pages คืนค่าจำนวนหน้าที่ดึงมาแล้ว
+func (it *Iterator[T]) Pages() int {
+	return it.pages
+}
+
+// Cursor คืนค่า cursor ของหน้าถัดไป (ว่างเมื่อถึงหน้าสุดท้ายแล้ว)
+func (it *Iterator[T]) Cursor() string {
+	return it.opts.Cursor
+}
+
+// Err คืนค่า error ที่ทำให้การวนหยุดลง
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// All ดึงทุกหน้าที่เหลือและรวมรายการทั้งหมดไว้ใน slice เดียว
+func (it *Iterator[T]) All(ctx context.Context) ([]T, error) {
+	var all []T
+	for it.Next(ctx) {
+		all = append(all, it.page...)
+	}
+	return all, it.err
+}
@@ -0,0 +1,307 @@
+package api
+
+import (
+	"backend/external/loyverse/models"
+	"context"
+)
+
+// ListCategories ดึงหมวดหมู่สินค้าหนึ่งหน้าจาก GET /categories
+func (c *Client) ListCategories(ctx context.Context, opts ListOptions) (*models.LoyCategoriesResponse, error) {
+	var page models.LoyCategoriesResponse
+	if err := c.list(ctx, "categories", opts, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Categories คืนค่า Iterator ที่ไล่ดึงหมวดหมู่สินค้าจนครบทุกหน้า
+func (c *Client) Categories(opts ListOptions) *Iterator[models.LoyCategory] {
+	return newIterator("categories", opts, func(ctx context.Context, opts ListOptions) ([]models.LoyCategory, string, error) {
+		page, err := c.ListCategories(ctx, opts)
+		if err != nil {
+			return nil, "", err
+		}
+		return page.Categories, page.Cursor, nil
+	})
+}
+
+// ListItems ดึงสินค้าหนึ่งหน้าจาก GET /items (variant ของแต่ละสินค้ามาพร้อมกันใน field variants)
+func (c *Client) ListItems(ctx context.Context, opts ListOptions) (*models.LoyItemsResponse, error) {
+	var page models.LoyItemsResponse
+	if err := c.list(ctx, "items", opts, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Items คืนค่า Iterator ที่ไล่ดึงสินค้าจนครบทุกหน้า
+func (c *Client) Items(opts ListOptions) *Iterator[models.LoyItem] {
+	return newIterator("items", opts, func(ctx context.Context, opts ListOptions) ([]models.LoyItem, string, error) {
+		page, err := c.ListItems(ctx, opts)
+		if err != nil {
+			return nil, "", err
+		}
+		return page.Items, page.Cursor, nil
+	})
+}
+
+// ListVariants ดึง variant ของสินค้าหนึ่งหน้าจาก GET /variants
+func (c *Client) ListVariants(ctx context.Context, opts ListOptions) (*models.LoyVariantsResponse, error) {
+	var page models.LoyVariantsResponse
+	if err := c.list(ctx, "variants", opts, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Variants คืนค่า Iterator ที่ไล่ดึง variant จนครบทุกหน้า รวมราคาแยกตามสาขาของแต่ละ variant
+func (c *Client) Variants(opts ListOptions) *Iterator[models.Variant] {
+	return newIterator("variants", opts, func(ctx context.Context, opts ListOptions) ([]models.Variant, string, error) {
+		page, err := c.ListVariants(ctx, opts)
+		if err != nil {
+			return nil, "", err
+		}
+		return page.Variants, page.Cursor, nil
+	})
+}
+
+// ListStores ดึงรายการสาขาหนึ่งหน้าจาก GET /stores
+func (c *Client) ListStores(ctx context.Context, opts ListOptions) (*models.LoyStoresResponse, error) {
+	var page models.LoyStoresResponse
+	if err := c.list(ctx, "stores", opts, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Stores คืนค่า Iterator ที่ไล่ดึงสาขาจนครบทุกหน้า
+func (c *Client) Stores(opts ListOptions) *Iterator[models.LoyStore] {
+	return newIterator("stores", opts, func(ctx context.Context, opts ListOptions) ([]models.LoyStore, string, error) {
+		page, err := c.ListStores(ctx, opts)
+		if err != nil {
+			return nil, "", err
+		}
+		return page.Stores, page.Cursor, nil
+	})
+}
+
+// ListSuppliers ดึงรายชื่อซัพพลายเออร์หนึ่งหน้าจาก GET /suppliers
+func (c *Client) ListSuppliers(ctx context.Context, opts ListOptions) (*models.LoySuppliersResponse, error) {
+	var page models.LoySuppliersResponse
+	if err := c.list(ctx, "suppliers", opts, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Suppliers คืนค่า Iterator ที่ไล่ดึงซัพพลายเออร์จนครบทุกหน้า
+func (c *Client) Suppliers(opts ListOptions) *Iterator[models.LoySupplier] {
+	return newIterator("suppliers", opts, func(ctx context.Context, opts ListOptions) ([]models.LoySupplier, string, error) {
+		page, err := c.ListSuppliers(ctx, opts)
+		if err != nil {
+			return nil, "", err
+		}
+		return page.Suppliers, page.Cursor, nil
+	})
+}
+
+// ListPaymentTypes ดึงประเภทการชำระเงินหนึ่งหน้าจาก GET /payment_types
+func (c *Client) ListPaymentTypes(ctx context.Context, opts ListOptions) (*models.LoyPaymentTypesResponse, error) {
+	var page models.LoyPaymentTypesResponse
+	if err := c.list(ctx, "payment_types", opts, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// PaymentTypes คืนค่า Iterator ที่ไล่ดึงประเภทการชำระเงินจนครบทุกหน้า
+func (c *Client) PaymentTypes(opts ListOptions) *Iterator[models.LoyPaymentType] {
+	return newIterator("payment_types", opts, func(ctx context.Context, opts ListOptions) ([]models.LoyPaymentType, string, error) {
+		page, err := c.ListPaymentTypes(ctx, opts)
+		if err != nil {
+			return nil, "", err
+		}
+		return page.PaymentTypes, page.Cursor, nil
+	})
+}
+
+// ListCustomers ดึงข้อมูลลูกค้าหนึ่งหน้าจาก GET /customers
+func (c *Client) ListCustomers(ctx context.Context, opts ListOptions) (*models.LoyCustomersResponse, error) {
+	var page models.LoyCustomersResponse
+	if err := c.list(ctx, "customers", opts, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Customers คืนค่า Iterator ที่ไล่ดึงลูกค้าจนครบทุกหน้า
+func (c *Client) Customers(opts ListOptions) *Iterator[models.LoyCustomer] {
+	return newIterator("customers", opts, func(ctx context.Context, opts ListOptions) ([]models.LoyCustomer, string, error) {
+		page, err := c.ListCustomers(ctx, opts)
+		if err != nil {
+			return nil, "", err
+		}
+		return page.Customers, page.Cursor, nil
+	})
+}
+
+// ListTaxes ดึงรายการภาษีหนึ่งหน้าจาก GET /taxes
+func (c *Client) ListTaxes(ctx context.Context, opts ListOptions) (*models.LoyTaxesResponse, error) {
+	var page models.LoyTaxesResponse
+	if err := c.list(ctx, "taxes", opts, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Taxes คืนค่า Iterator ที่ไล่ดึงรายการภาษีจนครบทุกหน้า
+func (c *Client) Taxes(opts ListOptions) *Iterator[models.LoyTax] {
+	return newIterator("taxes", opts, func(ctx context.Context, opts ListOptions) ([]models.LoyTax, string, error) {
+		page, err := c.ListTaxes(ctx, opts)
+		if err != nil {
+			return nil, "", err
+		}
+		return page.Taxes, page.Cursor, nil
+	})
+}
+
+// ListDiscounts ดึงรายการส่วนลดหนึ่งหน้าจาก GET /discounts
+func (c *Client) ListDiscounts(ctx context.Context, opts ListOptions) (*models.LoyDiscountsResponse, error) {
+	var page models.LoyDiscountsResponse
+	if err := c.list(ctx, "discounts", opts, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Discounts คืนค่า Iterator ที่ไล่ดึงส่วนลดจนครบทุกหน้า
+func (c *Client) Discounts(opts ListOptions) *Iterator[models.LoyDiscount] {
+	return newIterator("discounts", opts, func(ctx context.Context, opts ListOptions) ([]models.LoyDiscount, string, error) {
+		page, err := c.ListDiscounts(ctx, opts)
+		if err != nil {
+			return nil, "", err
+		}
+		return page.Discounts, page.Cursor, nil
+	})
+}
+
+// ListModifiers ดึง modifier พร้อมตัวเลือกของมันหนึ่งหน้าจาก GET /modifiers
+func (c *Client) ListModifiers(ctx context.Context, opts ListOptions) (*models.LoyModifiersResponse, error) {
+	var page models.LoyModifiersResponse
+	if err := c.list(ctx, "modifiers", opts, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Modifiers คืนค่า Iterator ที่ไล่ดึง modifier จนครบทุกหน้า
+func (c *Client) Modifiers(opts ListOptions) *Iterator[models.LoyModifier] {
+	return newIterator("modifiers", opts, func(ctx context.Context, opts ListOptions) ([]models.LoyModifier, string, error) {
+		page, err := c.ListModifiers(ctx, opts)
+		if err != nil {
+			return nil, "", err
+		}
+		return page.Modifiers, page.Cursor, nil
+	})
+}
+
+// ListEmployees ดึงรายชื่อพนักงานหนึ่งหน้าจาก GET /employees
+func (c *Client) ListEmployees(ctx context.Context, opts ListOptions) (*models.LoyEmployeesResponse, error) {
+	var page models.LoyEmployeesResponse
+	if err := c.list(ctx, "employees", opts, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Employees คืนค่า Iterator ที่ไล่ดึงพนักงานจนครบทุกหน้า
+func (c *Client) Employees(opts ListOptions) *Iterator[models.LoyEmployee] {
+	return newIterator("employees", opts, func(ctx context.Context, opts ListOptions) ([]models.LoyEmployee, string, error) {
+		page, err := c.ListEmployees(ctx, opts)
+		if err != nil {
+			return nil, "", err
+		}
+		return page.Employees, page.Cursor, nil
+	})
+}
+
+// ListPosDevices ดึงรายการเครื่อง POS หนึ่งหน้าจาก GET /pos_devices
+func (c *Client) ListPosDevices(ctx context.Context, opts ListOptions) (*models.LoyPosDevicesResponse, error) {
+	var page models.LoyPosDevicesResponse
+	if err := c.list(ctx, "pos_devices", opts, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// PosDevices คืนค่า Iterator ที่ไล่ดึงเครื่อง POS จนครบทุกหน้า
+func (c *Client) PosDevices(opts ListOptions) *Iterator[models.LoyPosDevice] {
+	return newIterator("pos_devices", opts, func(ctx context.Context, opts ListOptions) ([]models.LoyPosDevice, string, error) {
+		page, err := c.ListPosDevices(ctx, opts)
+		if err != nil {
+			return nil, "", err
+		}
+		return page.PosDevices, page.Cursor, nil
+	})
+}
+
+// ListInventory ดึงยอดสต็อกคงเหลือ (inventory levels) ของแต่ละ variant ต่อสาขาหนึ่งหน้าจาก GET /inventory
+func (c *Client) ListInventory(ctx context.Context, opts ListOptions) (*models.LoyInventoryLevelsResponse, error) {
+	var page models.LoyInventoryLevelsResponse
+	if err := c.list(ctx, "inventory", opts, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Inventory คืนค่า Iterator ที่ไล่ดึงยอดสต็อกคงเหลือจนครบทุกหน้า
+func (c *Client) Inventory(opts ListOptions) *Iterator[models.LoyInventoryLevel] {
+	return newIterator("inventory", opts, func(ctx context.Context, opts ListOptions) ([]models.LoyInventoryLevel, string, error) {
+		page, err := c.ListInventory(ctx, opts)
+		if err != nil {
+			return nil, "", err
+		}
+		return page.InventoryLevels, page.Cursor, nil
+	})
+}
+
+// ListReceipts ดึงใบเสร็จหนึ่งหน้าจาก GET /receipts
+// ใช้ UpdatedAtMin/CreatedAtMin ใน opts เพื่อดึงเฉพาะใบเสร็จที่เปลี่ยนไปหลัง sync ครั้งก่อน
+func (c *Client) ListReceipts(ctx context.Context, opts ListOptions) (*models.LoyReceiptsResponse, error) {
+	var page models.LoyReceiptsResponse
+	if err := c.list(ctx, "receipts", opts, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Receipts คืนค่า Iterator ที่ไล่ดึงใบเสร็จจนครบทุกหน้าตามช่วงเวลาใน opts
+func (c *Client) Receipts(opts ListOptions) *Iterator[models.LoyReceipt] {
+	return newIterator("receipts", opts, func(ctx context.Context, opts ListOptions) ([]models.LoyReceipt, string, error) {
+		page, err := c.ListReceipts(ctx, opts)
+		if err != nil {
+			return nil, "", err
+		}
+		return page.Receipts, page.Cursor, nil
+	})
+}
+
+// ListShifts ดึงกะการขาย (รวมยอดเงินสดที่คาดไว้และที่นับได้จริง) หนึ่งหน้าจาก GET /shifts
+func (c *Client) ListShifts(ctx context.Context, opts ListOptions) (*models.LoyShiftsResponse, error) {
+	var page models.LoyShiftsResponse
+	if err := c.list(ctx, "shifts", opts, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Shifts คืนค่า Iterator ที่ไล่ดึงกะการขายจนครบทุกหน้า
+func (c *Client) Shifts(opts ListOptions) *Iterator[models.LoyShift] {
+	return newIterator("shifts", opts, func(ctx context.Context, opts ListOptions) ([]models.LoyShift, string, error) {
+		page, err := c.ListShifts(ctx, opts)
+		if err != nil {
+			return nil, "", err
+		}
+		return page.Shifts, page.Cursor, nil
+	})
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"backend/external/loyverse/models"
+	"context"
+	"net/url"
+)
+
+// Object คือ resource หนึ่งตัวในรูป JSON object ตามที่ Loyverse ส่งมา
+// ใช้กับการอัปเดต item และ supplier เพื่อให้ฟิลด์ที่ connector ไม่ได้ model ไว้ถูกส่งกลับไปครบ
+// เพราะ POST ของ Loyverse แทนที่ resource ทั้งตัว ไม่ใช่ patch
+type Object map[string]interface{}
+
+// UpdateInventory ตั้งค่าจำนวนสต็อก (stock_after) ของแต่ละ variant/store ผ่าน POST /inventory
+// คืนค่า inventory levels หลังอัปเดต
+func (c *Client) UpdateInventory(ctx context.Context, updates []models.LoyInventoryUpdate) ([]models.LoyInventoryLevel, error) {
+	request := struct {
+		InventoryLevels []models.LoyInventoryUpdate `json:"inventory_levels"`
+	}{updates}
+	var response models.LoyInventoryLevelsResponse
+	if err := c.post(ctx, "inventory", request, &response); err != nil {
+		return nil, err
+	}
+	return response.InventoryLevels, nil
+}
+
+// GetItem ดึงสินค้าหนึ่งรายการจาก /items/{id}
+func (c *Client) GetItem(ctx context.Context, itemID string) (Object, error) {
+	var item Object
+	if err := c.get(ctx, "items/"+url.PathEscape(itemID), &item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// SaveItem สร้างหรืออัปเดตสินค้าผ่าน POST /items (อัปเดตเมื่อ item มี "id")
+func (c *Client) SaveItem(ctx context.Context, item Object) (Object, error) {
+	var saved Object
+	if err := c.post(ctx, "items", item, &saved); err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+// GetSupplier ดึงซัพพลายเออร์หนึ่งรายจาก /suppliers/{id}
+func (c *Client) GetSupplier(ctx context.Context, supplierID string) (Object, error) {
+	var supplier Object
+	if err := c.get(ctx, "suppliers/"+url.PathEscape(supplierID), &supplier); err != nil {
+		return nil, err
+	}
+	return supplier, nil
+}
+
+// SaveSupplier สร้างหรืออัปเดตซัพพลายเออร์ผ่าน POST /suppliers (อัปเดตเมื่อ supplier มี "id")
+func (c *Client) SaveSupplier(ctx context.Context, supplier Object) (Object, error) {
+	var saved Object
+	if err := c.post(ctx, "suppliers", supplier, &saved); err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
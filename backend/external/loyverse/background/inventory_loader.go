@@ -3,15 +3,17 @@ package background
 
 import (
 	"backend/external/loyverse/handlers"
+	"backend/external/loyverse/models"
 
+	"context"
 	"database/sql"
 	"log"
 )
 
-// InventoryLoader syncs inventory levels.
-func InventoryLoader(dbConn *sql.DB) {
-	log.Println("Starting inventory sync...")
-	if err := handlers.SyncInventoryLevels(dbConn); err != nil {
+// InventoryLoader syncs inventory levels of one connection.
+func InventoryLoader(dbConn *sql.DB, conn models.Connection) {
+	log.Printf("Starting inventory sync for %s...", conn.MerchantID)
+	if err := handlers.SyncInventoryLevels(context.Background(), dbConn, conn, models.SyncTriggerCron); err != nil {
 		log.Printf("Error syncing inventory levels: %v", err)
 	} else {
 		log.Println("Inventory sync completed successfully.")
@@ -0,0 +1,102 @@
+// background/outbound_worker.go
+package background
+
+import (
+	"backend/external/loyverse/api"
+	"backend/external/loyverse/models"
+	"backend/external/loyverse/repository"
+	"backend/external/loyverse/services"
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+)
+
+// ค่าควบคุมการทำงานของ outbound worker
+const (
+	OutboundPollInterval   = 5 * time.Second
+	OutboundBatchSize      = 20
+	OutboundLease          = 5 * time.Minute // เวลาที่จองรายการไว้ก่อนให้ worker อื่นหยิบไปทำต่อได้
+	OutboundMaxAttempts    = 8
+	OutboundBaseRetryDelay = 1 * time.Minute
+	OutboundMaxRetryDelay  = 1 * time.Hour
+)
+
+// RunOutboundWorker ส่งการเปลี่ยนแปลงในคิว outbound_changes ไปยัง Loyverse จนกว่า ctx จะถูกยกเลิก
+// แต่ละรายการถูกส่งด้วย client ของ connection ที่เป็นเจ้าของรายการนั้น (newClient เช่น services.NewLoyverseClient)
+func RunOutboundWorker(ctx context.Context, dbConn *sql.DB, newClient func(models.Connection) *api.Client) {
+	log.Println("Starting outbound worker...")
+	ticker := time.NewTicker(OutboundPollInterval)
+	defer ticker.Stop()
+
+	for {
+		processDueOutboundChanges(ctx, dbConn, newClient)
+
+		select {
+		case <-ctx.Done():
+			log.Println("Outbound worker stopped.")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// processDueOutboundChanges จองและส่งรายการที่ถึงเวลาจนกว่าคิวจะว่าง
+func processDueOutboundChanges(ctx context.Context, dbConn *sql.DB, newClient func(models.Connection) *api.Client) {
+	for ctx.Err() == nil {
+		changes, err := repository.ClaimDueOutboundChanges(dbConn, OutboundBatchSize, OutboundLease)
+		if err != nil || len(changes) == 0 {
+			return
+		}
+		clients := map[string]*api.Client{}
+		for _, change := range changes {
+			client, ok := clients[change.MerchantID]
+			if !ok {
+				conn, err := repository.GetConnection(dbConn, change.MerchantID)
+				if err != nil {
+					log.Printf("Outbound change %d: could not load connection %s: %v", change.ID, change.MerchantID, err)
+					repository.MarkOutboundChangeFailed(dbConn, change.ID, err, nil, outboundRetryDelay(change.Attempts), change.Attempts >= OutboundMaxAttempts)
+					continue
+				}
+				client = newClient(conn)
+				clients[change.MerchantID] = client
+			}
+			processOutboundChange(ctx, dbConn, client, change)
+		}
+	}
+}
+
+func processOutboundChange(ctx context.Context, dbConn *sql.DB, client *api.Client, change models.OutboundChange) {
+	request, response, err := services.PushOutboundChange(ctx, dbConn, client, change)
+	if err == nil {
+		repository.MarkOutboundChangeSent(dbConn, change.ID, request, response)
+		return
+	}
+	if errors.Is(err, repository.ErrOutboundClaimLost) {
+		// worker ที่จองรายการนี้ต่อไปเป็นผู้บันทึกผลเอง
+		log.Printf("Outbound change %d (%s %s) skipped: %v", change.ID, change.ChangeType, change.EntityID, err)
+		return
+	}
+
+	dead := services.IsPermanentOutboundError(err) || change.Attempts >= OutboundMaxAttempts
+	delay := outboundRetryDelay(change.Attempts)
+	if dead {
+		log.Printf("Outbound change %d (%s %s) moved to dead letter after %d attempts: %v", change.ID, change.ChangeType, change.EntityID, change.Attempts, err)
+	} else {
+		log.Printf("Outbound change %d (%s %s) failed on attempt %d, retrying in %s: %v", change.ID, change.ChangeType, change.EntityID, change.Attempts, delay, err)
+	}
+	repository.MarkOutboundChangeFailed(dbConn, change.ID, err, request, delay, dead)
+}
+
+// outboundRetryDelay คำนวณเวลารอแบบ exponential backoff ตามจำนวนครั้งที่ลองไปแล้ว
+func outboundRetryDelay(attempts int) time.Duration {
+	delay := OutboundBaseRetryDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= OutboundMaxRetryDelay {
+			return OutboundMaxRetryDelay
+		}
+	}
+	return delay
+}
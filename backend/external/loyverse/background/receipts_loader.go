@@ -3,17 +3,26 @@ package background
 
 import (
 	"backend/external/loyverse/handlers"
+	"backend/external/loyverse/models"
 
+	"context"
 	"database/sql"
 	"log"
 )
 
-// ReceiptsLoader syncs receipts.
-func ReceiptsLoader(dbConn *sql.DB) {
-	log.Println("Starting receipts sync...")
-	if err := handlers.SyncReceipts(dbConn); err != nil {
+// ReceiptsLoader syncs receipts of one connection changed since its last watermark,
+// followed by the cash shifts the receipts were rung up in.
+func ReceiptsLoader(dbConn *sql.DB, conn models.Connection) {
+	log.Printf("Starting receipts sync for %s...", conn.MerchantID)
+	if err := handlers.SyncReceipts(context.Background(), dbConn, conn, models.SyncTriggerCron, false); err != nil {
 		log.Printf("Error syncing receipts: %v", err)
 	} else {
 		log.Println("Receipts sync completed successfully.")
 	}
+
+	if err := handlers.SyncShifts(context.Background(), dbConn, conn, models.SyncTriggerCron); err != nil {
+		log.Printf("Error syncing shifts: %v", err)
+	} else {
+		log.Println("Shifts sync completed successfully.")
+	}
 }
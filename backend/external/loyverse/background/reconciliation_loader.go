@@ -0,0 +1,23 @@
+// background/reconciliation_loader.go
+package background
+
+import (
+	"backend/external/loyverse/handlers"
+	"backend/external/loyverse/models"
+
+	"context"
+	"database/sql"
+	"log"
+)
+
+// ReconciliationLoader compares the last handlers.ReconciliationDays days of receipts,
+// the items and the inventory levels of one connection with Loyverse and stores the mismatches as findings.
+func ReconciliationLoader(dbConn *sql.DB, conn models.Connection) {
+	log.Printf("Starting reconciliation for %s...", conn.MerchantID)
+	result, err := handlers.Reconcile(context.Background(), dbConn, conn, handlers.ReconciliationDays, models.SyncTriggerCron)
+	if err != nil {
+		log.Printf("Error reconciling: %v", err)
+		return
+	}
+	log.Printf("Reconciliation completed with %d open findings.", len(result.Findings))
+}
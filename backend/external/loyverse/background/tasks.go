@@ -1,74 +1,260 @@
 package background
 
 import (
+	"backend/external/loyverse/models"
 	"backend/external/loyverse/repository"
+	"backend/external/loyverse/utils"
 	"database/sql"
 	"log"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/robfig/cron/v3"
 )
 
-// StartBackgroundTasks ใช้ robfig/cron ในการตั้งเวลา jobs
-func StartBackgroundTasks(dbConn *sql.DB) {
-	loc, err := time.LoadLocation("Asia/Bangkok")
+// SchedulerTimezone คือ timezone ที่ใช้ตีความเวลาใน settings
+const SchedulerTimezone = utils.BusinessTimezone
+
+// SettingsPollInterval คือความถี่ที่ scheduler ตรวจสอบตาราง settings เผื่อมีการแก้ไขนอก API
+const SettingsPollInterval = time.Minute
+
+// nextRunsShown จำนวนเวลาที่จะรันครั้งถัดไปที่แสดงใน /api/schedule
+const nextRunsShown = 5
+
+// jobDefinition คือ job ที่ตารางเวลามาจาก key ในตาราง settings ของแต่ละ merchant
+type jobDefinition struct {
+	name         string
+	settingKey   string
+	defaultValue string
+	run          func(dbConn *sql.DB, conn models.Connection)
+}
+
+// jobDefinitions คือ jobs ที่ถูกตั้งเวลาให้ทุก connection ที่เปิดใช้งาน
+var jobDefinitions = []jobDefinition{
+	{name: "InventoryLoader", settingKey: "inventory_sync_time", defaultValue: "03:00", run: InventoryLoader},
+	{name: "ReceiptsLoader", settingKey: "receipts_sync_time", defaultValue: "04:30", run: ReceiptsLoader},
+	{name: "ReconciliationLoader", settingKey: "reconciliation_sync_time", defaultValue: "05:30", run: ReconciliationLoader},
+}
+
+// scheduledJob คือ job หนึ่งตัวของ connection หนึ่ง
+type scheduledJob struct {
+	jobDefinition
+	conn models.Connection
+
+	running  sync.Mutex // กันไม่ให้ job เดียวกันรันซ้อนกัน
+	value    string
+	specs    []string
+	entryIDs []cron.EntryID
+	err      error
+}
+
+func (job *scheduledJob) key() string {
+	return job.conn.MerchantID + "/" + job.name
+}
+
+// Scheduler ตั้งเวลา background jobs ของทุก connection ตามค่าในตาราง settings
+// และตั้งเวลาใหม่ทุกครั้งที่ค่าใน settings หรือรายการ connection เปลี่ยน
+type Scheduler struct {
+	db   *sql.DB
+	loc  *time.Location
+	cron *cron.Cron
+	jobs []*scheduledJob
+	stop chan struct{}
+
+	mu sync.Mutex
+}
+
+// NewScheduler สร้าง Scheduler สำหรับ inventory และ receipts sync
+func NewScheduler(dbConn *sql.DB) (*Scheduler, error) {
+	loc, err := time.LoadLocation(SchedulerTimezone)
 	if err != nil {
-		log.Fatalf("Failed to load timezone: %v", err)
+		return nil, err
 	}
 
-	c := cron.New(cron.WithLocation(loc))
+	return &Scheduler{
+		db:   dbConn,
+		loc:  loc,
+		cron: cron.New(cron.WithLocation(loc)),
+		stop: make(chan struct{}),
+	}, nil
+}
 
-	// Schedule InventoryLoader
-	inventoryTime, err := getSyncTime(dbConn, "inventory_sync_time", "03:00")
-	if err != nil {
-		log.Printf("Error getting inventory sync time: %v, using default 03:00", err)
-		inventoryTime = "03:00"
+// Start ตั้งเวลาทุก job ตาม settings ปัจจุบันและเริ่ม cron
+// scheduler จะทำงานไปจนกว่าจะเรียก Stop
+func (s *Scheduler) Start() {
+	s.Reload()
+	s.cron.Start()
+	go s.pollSettings()
+	log.Printf("Scheduler started (%s)", SchedulerTimezone)
+}
+
+// Stop หยุด cron และรอ job ที่กำลังรันอยู่ให้จบ
+func (s *Scheduler) Stop() {
+	close(s.stop)
+	<-s.cron.Stop().Done()
+	log.Println("Scheduler stopped.")
+}
+
+// Reload อ่านรายการ connection และ settings ใหม่ แล้วตั้งเวลาใหม่เฉพาะ job ที่ค่าเปลี่ยน
+// connection ที่ถูกปิดหรือลบจะถูกยกเลิกตารางเวลา
+// ถ้าค่าใหม่ไม่ถูกต้อง job จะคงตารางเวลาเดิมไว้และ error จะแสดงใน Entries
+func (s *Scheduler) Reload() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.syncConnections()
+
+	for _, job := range s.jobs {
+		value, err := repository.GetSetting(s.db, job.conn.MerchantID, job.settingKey)
+		if err != nil || strings.TrimSpace(value) == "" {
+			value = job.defaultValue
+		}
+		if value == job.value && job.err == nil {
+			continue
+		}
+
+		specs, err := utils.ParseSchedule(value)
+		if err != nil {
+			log.Printf("Invalid schedule %q for %s, keeping previous schedule: %v", value, job.key(), err)
+			job.err = err
+			continue
+		}
+
+		s.reschedule(job, value, specs)
 	}
-	cronSpec := convertToCronFormat(inventoryTime)
-	// log.Printf("Scheduling InventoryLoader with cron format: %s", cronSpec)
-	c.AddFunc(cronSpec, func() {
-		log.Println("Cron job: Running InventoryLoader...")
-		InventoryLoader(dbConn)
-	})
-
-	// Schedule ReceiptsLoader
-	receiptsTime, err := getSyncTime(dbConn, "receipts_sync_time", "04:30")
+}
+
+// syncConnections สร้าง jobs ให้ connection ที่เปิดใช้งานใหม่และลบ jobs ของ connection ที่ไม่อยู่แล้ว
+// ถ้าอ่านรายการ connection ไม่ได้จะคง jobs เดิมไว้
+func (s *Scheduler) syncConnections() {
+	connections, err := repository.ListConnections(s.db, true)
 	if err != nil {
-		log.Printf("Error getting receipts sync time: %v, using default 04:30", err)
-		receiptsTime = "04:30"
+		log.Printf("Could not load connections, keeping previous schedule: %v", err)
+		return
+	}
+
+	existing := make(map[string]*scheduledJob, len(s.jobs))
+	for _, job := range s.jobs {
+		existing[job.key()] = job
+	}
+
+	jobs := make([]*scheduledJob, 0, len(connections)*len(jobDefinitions))
+	for _, conn := range connections {
+		for _, definition := range jobDefinitions {
+			job := &scheduledJob{jobDefinition: definition, conn: conn}
+			if previous, ok := existing[job.key()]; ok {
+				// ใช้ job เดิมเพื่อคง entry ใน cron ไว้ แต่รับค่า connection ใหม่ (เช่น token ที่เปลี่ยน)
+				previous.conn = conn
+				job = previous
+				delete(existing, job.key())
+			}
+			jobs = append(jobs, job)
+		}
+	}
+
+	for _, job := range existing {
+		for _, id := range job.entryIDs {
+			s.cron.Remove(id)
+		}
+		log.Printf("Unscheduled %s", job.key())
+	}
+	s.jobs = jobs
+}
+
+// reschedule ลบ entry เดิมของ job และเพิ่ม entry ใหม่ตาม specs
+func (s *Scheduler) reschedule(job *scheduledJob, value string, specs []string) {
+	for _, id := range job.entryIDs {
+		s.cron.Remove(id)
+	}
+	job.entryIDs = nil
+
+	for _, spec := range specs {
+		id, err := s.cron.AddFunc(spec, func() { s.runJob(job) })
+		if err != nil {
+			log.Printf("Could not schedule %s with %q: %v", job.key(), spec, err)
+			continue
+		}
+		job.entryIDs = append(job.entryIDs, id)
+	}
+	job.value = value
+	job.specs = specs
+	job.err = nil
+	log.Printf("Scheduled %s at %q (%s)", job.key(), value, strings.Join(specs, "; "))
+}
+
+func (s *Scheduler) runJob(job *scheduledJob) {
+	if !job.running.TryLock() {
+		log.Printf("Cron job: %s is still running, skipping this run", job.key())
+		return
+	}
+	defer job.running.Unlock()
+
+	s.mu.Lock()
+	conn := job.conn
+	s.mu.Unlock()
+
+	log.Printf("Cron job: Running %s...", job.key())
+	job.run(s.db, conn)
+}
+
+// pollSettings เรียก Reload เป็นระยะเผื่อ settings ถูกแก้ไขโดยตรงในฐานข้อมูล
+func (s *Scheduler) pollSettings() {
+	ticker := time.NewTicker(SettingsPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.Reload()
+		}
 	}
-	cronSpec = convertToCronFormat(receiptsTime)
-	// log.Printf("Scheduling ReceiptsLoader with cron format: %s", cronSpec)
-	c.AddFunc(cronSpec, func() {
-		log.Println("Cron job: Running ReceiptsLoader...")
-		ReceiptsLoader(dbConn)
-	})
-
-	// Start cron scheduler
-	// log.Println("Starting cron scheduler...")
-	c.Start()
-	defer c.Stop() // เพื่อหยุด cron เมื่อโปรแกรมปิด
 }
 
-// getSyncTime และ convertToCronFormat ยังคงอยู่เหมือนเดิม
-func getSyncTime(db *sql.DB, key, defaultTime string) (string, error) {
-	timeStr, err := repository.GetSetting(db, key)
-	if err != nil || timeStr == "" {
-		return defaultTime, err
+// Entries คืนค่าตารางเวลาปัจจุบันของทุก job ของทุก connection พร้อมเวลาที่จะรันครั้งถัดไป
+func (s *Scheduler) Entries() []models.ScheduledJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().In(s.loc)
+	entries := make([]models.ScheduledJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		entry := models.ScheduledJob{
+			Job:        job.name,
+			MerchantID: job.conn.MerchantID,
+			SettingKey: job.settingKey,
+			Schedule:   job.value,
+			Timezone:   SchedulerTimezone,
+			NextRuns:   nextRuns(job.specs, now, nextRunsShown),
+		}
+		if job.err != nil {
+			message := job.err.Error()
+			entry.Error = &message
+		}
+		entries = append(entries, entry)
 	}
-	return timeStr, nil
+	return entries
 }
 
-func convertToCronFormat(timeStr string) string {
-	parts := strings.Split(timeStr, ":")
-	if len(parts) != 2 {
-		log.Printf("Invalid time format for cron: %s", timeStr)
-		return "0 3 * * *" // Default 03:00 ถ้าเกิดข้อผิดพลาด
+// nextRuns คำนวณ n เวลาถัดไปจากทุก spec รวมกันเรียงตามเวลา
+func nextRuns(specs []string, from time.Time, n int) []time.Time {
+	var runs []time.Time
+	for _, spec := range specs {
+		schedule, err := cron.ParseStandard(spec)
+		if err != nil {
+			continue
+		}
+		next := from
+		for i := 0; i < n; i++ {
+			next = schedule.Next(next)
+			runs = append(runs, next)
+		}
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].Before(runs[j]) })
+	if len(runs) > n {
+		runs = runs[:n]
 	}
-	minute := parts[1]
-	hour := parts[0]
-	cronFormat := minute + " " + hour + " * * *"
-	// log.Printf("Setting cron format to: %s", cronFormat) // ตรวจสอบ cron ที่ตั้งไว้
-	return cronFormat
+	return runs
 }
@@ -0,0 +1,87 @@
+// background/webhook_worker.go
+package background
+
+import (
+	"backend/external/loyverse/models"
+	"backend/external/loyverse/repository"
+	"backend/external/loyverse/services"
+	"context"
+	"database/sql"
+	"log"
+	"time"
+)
+
+// ค่าควบคุมการทำงานของ webhook worker
+const (
+	WebhookPollInterval   = 2 * time.Second
+	WebhookBatchSize      = 20
+	WebhookLease          = 5 * time.Minute // เวลาที่จองรายการไว้ก่อนให้ worker อื่นหยิบไปทำต่อได้
+	WebhookMaxAttempts    = 8
+	WebhookBaseRetryDelay = 30 * time.Second
+	WebhookMaxRetryDelay  = 1 * time.Hour
+)
+
+// RunWebhookWorker ประมวลผล webhook ใน inbox จนกว่า ctx จะถูกยกเลิก
+func RunWebhookWorker(ctx context.Context, dbConn *sql.DB) {
+	log.Println("Starting webhook worker...")
+	ticker := time.NewTicker(WebhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		processDueWebhookEvents(dbConn)
+
+		select {
+		case <-ctx.Done():
+			log.Println("Webhook worker stopped.")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// processDueWebhookEvents จองและประมวลผลรายการที่ถึงเวลาจนกว่าคิวจะว่าง
+func processDueWebhookEvents(dbConn *sql.DB) {
+	for {
+		events, err := repository.ClaimDueWebhookEvents(dbConn, WebhookBatchSize, WebhookLease)
+		if err != nil || len(events) == 0 {
+			return
+		}
+		for _, event := range events {
+			processWebhookEvent(dbConn, event)
+		}
+	}
+}
+
+func processWebhookEvent(dbConn *sql.DB, event models.WebhookEvent) {
+	run, runErr := repository.StartSyncRun(dbConn, event.MerchantID, services.WebhookEntity(event.EventType), models.SyncTriggerWebhook)
+	rows, err := services.ProcessWebhookPayload(dbConn, event.MerchantID, event.Payload)
+	if runErr == nil {
+		run.RowsUpserted = int64(rows)
+		repository.FinishSyncRun(dbConn, run, err)
+	}
+	if err == nil {
+		repository.MarkWebhookEventProcessed(dbConn, event.MerchantID, event.DeliveryID)
+		return
+	}
+
+	dead := services.IsPermanentWebhookError(err) || event.Attempts >= WebhookMaxAttempts
+	delay := webhookRetryDelay(event.Attempts)
+	if dead {
+		log.Printf("Webhook %s (%s) moved to dead letter after %d attempts: %v", event.DeliveryID, event.EventType, event.Attempts, err)
+	} else {
+		log.Printf("Webhook %s (%s) failed on attempt %d, retrying in %s: %v", event.DeliveryID, event.EventType, event.Attempts, delay, err)
+	}
+	repository.MarkWebhookEventFailed(dbConn, event.MerchantID, event.DeliveryID, err, delay, dead)
+}
+
+// webhookRetryDelay คำนวณเวลารอแบบ exponential backoff ตามจำนวนครั้งที่ลองไปแล้ว
+func webhookRetryDelay(attempts int) time.Duration {
+	delay := WebhookBaseRetryDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= WebhookMaxRetryDelay {
+			return WebhookMaxRetryDelay
+		}
+	}
+	return delay
+}
@@ -0,0 +1,65 @@
+// cmd/backfill-receipts/main.go
+// โหลดใบเสร็จย้อนหลังตามช่วงวันที่จากบรรทัดคำสั่ง (ทำงานเหมือน POST /api/sync/receipts/backfill)
+//
+//	go run ./cmd/backfill-receipts -from 2024-01-01 -to 2024-03-31 [-store <store_id>] [-merchant default]
+//
+// กด Ctrl-C เพื่อหยุด แล้วรันคำสั่งเดิมอีกครั้งเพื่อทำต่อจากหน้าที่บันทึกไว้ล่าสุด
+package main
+
+import (
+	"backend/external/loyverse/config"
+	"backend/external/loyverse/handlers"
+	"backend/external/loyverse/models"
+	"backend/external/loyverse/repository"
+	"backend/external/loyverse/utils"
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+)
+
+func main() {
+	merchantID := flag.String("merchant", models.DefaultMerchantID, "merchant_id ของ connection")
+	from := flag.String("from", "", "วันที่เริ่ม (YYYY-MM-DD หรือ RFC3339)")
+	to := flag.String("to", "", "วันที่สิ้นสุด (YYYY-MM-DD หมายถึงสิ้นวัน หรือ RFC3339)")
+	storeID := flag.String("store", "", "store_id ที่ต้องการ (ว่าง = ทุกสาขา)")
+	flag.Parse()
+
+	loc, err := time.LoadLocation(utils.BusinessTimezone)
+	if err != nil {
+		log.Fatalf("Failed to load timezone: %v", err)
+	}
+	start, end, err := utils.ParseDateRange(*from, *to, loc)
+	if err != nil {
+		flag.Usage()
+		log.Fatalf("Invalid date range: %v", err)
+	}
+
+	db, err := config.ConnectDB()
+	if err != nil {
+		log.Fatalf("Failed to connect to the database: %v", err)
+	}
+	defer db.Close()
+
+	if err := repository.EnsureSchema(db); err != nil {
+		log.Fatalf("Failed to ensure database schema: %v", err)
+	}
+	conn, err := repository.GetConnection(db, *merchantID)
+	if err != nil {
+		log.Fatalf("Failed to load connection %q: %v", *merchantID, err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	backfill, err := handlers.BackfillReceipts(ctx, db, conn, start, end, *storeID, models.SyncTriggerManual)
+	if err != nil {
+		if backfill != nil {
+			log.Fatalf("Backfill %d stopped after %d receipts: %v (run the same command again to resume)", backfill.ID, backfill.ReceiptsSaved, err)
+		}
+		log.Fatalf("Backfill failed: %v", err)
+	}
+	log.Printf("Backfill %d finished: %d receipts on %d pages", backfill.ID, backfill.ReceiptsSaved, backfill.PagesFetched)
+}
@@ -0,0 +1,29 @@
+// cmd/fakeloyverse/main.go
+// รัน Loyverse API ปลอมพร้อมข้อมูลตัวอย่างสำหรับพัฒนาแบบ offline
+// ตั้ง LOYVERSE_API_BASE_URL=http://localhost:8090/v1.0 และ LOYVERSE_API_TOKEN=fake-loyverse-token ให้ loyverse-connect
+package main
+
+import (
+	"backend/external/loyverse/fakeloyverse"
+	"log"
+	"net/http"
+	"os"
+)
+
+func main() {
+	server := fakeloyverse.New(fakeloyverse.DefaultSeed())
+
+	// ส่ง webhook กลับไปยัง loyverse-connect เมื่อมีการเขียนข้อมูลผ่าน POST
+	server.WebhookURL = os.Getenv("FAKE_LOYVERSE_WEBHOOK_URL")
+	server.WebhookSecret = os.Getenv("LOYVERSE_WEBHOOK_SECRET")
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8090"
+	}
+
+	log.Printf("Fake Loyverse API listening on :%s%s (token %q)", port, fakeloyverse.PathPrefix, fakeloyverse.Token)
+	if err := http.ListenAndServe(":"+port, server); err != nil {
+		log.Fatalf("Failed to start fake Loyverse API: %v", err)
+	}
+}
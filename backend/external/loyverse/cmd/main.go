@@ -2,9 +2,13 @@
 package main
 
 import (
+	"backend/external/loyverse/background"
 	"backend/external/loyverse/config"
 	"backend/external/loyverse/middleware"
+	"backend/external/loyverse/repository"
 	"backend/external/loyverse/router"
+	"backend/external/loyverse/services"
+	"context"
 	"log"
 	"net/http"
 	"os"
@@ -12,16 +16,41 @@ import (
 
 func main() {
 	// โหลด configuration และตั้งค่าการเชื่อมต่อ database
-	config.GetLoyverseToken()
 	db, err := config.ConnectDB()
 	if err != nil {
 		log.Fatalf("Failed to connect to the database: %v", err)
 	}
 	defer db.Close()
 
+	// สร้างตารางที่ service นี้ดูแลเองถ้ายังไม่มี
+	if err := repository.EnsureSchema(db); err != nil {
+		log.Fatalf("Failed to ensure database schema: %v", err)
+	}
+
+	// connection default มาจาก environment เพื่อให้การตั้งค่าแบบบัญชีเดียวทำงานได้เหมือนเดิม
+	if token := config.GetLoyverseToken(); token != "" {
+		if err := repository.EnsureDefaultConnection(db, token, config.GetWebhookSecret(), config.GetLoyverseBaseURL()); err != nil {
+			log.Fatalf("Failed to save default Loyverse connection: %v", err)
+		}
+	}
+
+	// เริ่ม worker สำหรับประมวลผล webhook ที่อยู่ใน inbox
+	go background.RunWebhookWorker(context.Background(), db)
+
+	// เริ่ม worker สำหรับส่งการเปลี่ยนแปลงจากฝั่งเรากลับไปยัง Loyverse
+	go background.RunOutboundWorker(context.Background(), db, services.NewLoyverseClient)
+
+	// เริ่ม scheduler สำหรับ inventory และ receipts sync ของทุก connection ตามเวลาในตาราง settings
+	scheduler, err := background.NewScheduler(db)
+	if err != nil {
+		log.Fatalf("Failed to create scheduler: %v", err)
+	}
+	scheduler.Start()
+	defer scheduler.Stop()
+
 	// สร้าง mux ใหม่
 	mux := http.NewServeMux()
-	router.RegisterRoutes(mux, db)
+	router.RegisterRoutes(mux, db, scheduler, services.NewSyncJobManager())
 
 	handler := middleware.CORS(mux) // เพิ่ม CORS middleware
 
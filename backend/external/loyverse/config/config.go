@@ -3,12 +3,53 @@ package config
 import (
 	"log"
 	"os"
+	"strings"
 )
 
+// GetLoyverseToken อ่าน token ของ connection default จาก LOYVERSE_API_TOKEN
+// ไม่บังคับตั้งค่าเมื่อเพิ่ม connection ผ่าน /api/connections แล้ว
 func GetLoyverseToken() string {
 	token := os.Getenv("LOYVERSE_API_TOKEN")
 	if token == "" {
-		log.Fatal("LOYVERSE_API_TOKEN is not set in .env")
+		log.Println("LOYVERSE_API_TOKEN is not set, only connections stored in the database will be synced")
 	}
 	return token
 }
+
+// GetWebhookSecret อ่าน secret ที่ใช้ตรวจสอบลายเซ็นของ webhook จาก Loyverse
+func GetWebhookSecret() string {
+	secret := os.Getenv("LOYVERSE_WEBHOOK_SECRET")
+	if secret == "" {
+		log.Println("LOYVERSE_WEBHOOK_SECRET is not set, webhook requests will be rejected")
+	}
+	return secret
+}
+
+// GetLoyverseBaseURL อ่าน base URL ของ Loyverse API จาก LOYVERSE_API_BASE_URL
+// คืนค่าว่างถ้าไม่ได้ตั้งค่า (ใช้ api.DefaultBaseURL) ตั้งค่าเพื่อชี้ไปยัง server ปลอมตอนพัฒนาแบบ offline
+func GetLoyverseBaseURL() string {
+	return os.Getenv("LOYVERSE_API_BASE_URL")
+}
+
+// GetAllowedAPIHosts อ่าน host เพิ่มเติมที่ connection ตั้งเป็น base_url ได้จาก LOYVERSE_ALLOWED_API_HOSTS
+// (คั่นด้วย comma) นอกเหนือจาก host ของ api.DefaultBaseURL
+func GetAllowedAPIHosts() []string {
+	var hosts []string
+	for _, host := range strings.Split(os.Getenv("LOYVERSE_ALLOWED_API_HOSTS"), ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// GetLoyverseRecorder อ่านโหมด record/replay ของ Loyverse client จาก LOYVERSE_API_MODE
+// และไดเรกทอรี fixtures จาก LOYVERSE_FIXTURES_DIR (ค่าเริ่มต้น fixtures/loyverse)
+// mode ว่างหมายถึงเรียก API ตามปกติ
+func GetLoyverseRecorder() (mode, dir string) {
+	dir = os.Getenv("LOYVERSE_FIXTURES_DIR")
+	if dir == "" {
+		dir = "fixtures/loyverse"
+	}
+	return os.Getenv("LOYVERSE_API_MODE"), dir
+}
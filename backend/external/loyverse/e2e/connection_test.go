@@ -0,0 +1,89 @@
+package e2e
+
+import (
+	"backend/external/loyverse/handlers"
+	"backend/external/loyverse/models"
+	"backend/external/loyverse/repository"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// postConnection ส่ง body ไปยัง ConnectionsHandler แล้วคืนค่า status code
+func postConnection(t *testing.T, handler http.HandlerFunc, body string) int {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodPost, "/api/connections", strings.NewReader(body)))
+	return w.Code
+}
+
+func TestPartialConnectionUpdateKeepsOmittedFields(t *testing.T) {
+	db := openTestDB(t)
+	handler := handlers.ConnectionsHandler(db)
+
+	if code := postConnection(t, handler, `{"merchant_id": "shop-1", "api_token": "token-1", "webhook_secret": "secret-1", "base_url": "https://api.loyverse.com/v1.0"}`); code != http.StatusOK {
+		t.Fatalf("create connection status = %d", code)
+	}
+	if code := postConnection(t, handler, `{"merchant_id": "shop-1", "name": "ร้านสาขา 1"}`); code != http.StatusOK {
+		t.Fatalf("rename connection status = %d", code)
+	}
+
+	conn, err := repository.GetConnection(db, "shop-1")
+	if err != nil {
+		t.Fatalf("GetConnection: %v", err)
+	}
+	if conn.Name != "ร้านสาขา 1" || !conn.Enabled || conn.BaseURL != "https://api.loyverse.com/v1.0" ||
+		conn.APIToken != "token-1" || conn.WebhookSecret != "secret-1" {
+		t.Errorf("connection after rename = %+v, want only the name changed", conn)
+	}
+
+	if code := postConnection(t, handler, `{"merchant_id": "shop-1", "enabled": false}`); code != http.StatusOK {
+		t.Fatalf("disable connection status = %d", code)
+	}
+	if conn, _ := repository.GetConnection(db, "shop-1"); conn.Enabled || conn.Name != "ร้านสาขา 1" {
+		t.Errorf("connection after disable = %+v, want disabled with the same name", conn)
+	}
+}
+
+func TestConnectionBaseURLMustBeAnAllowedHTTPSHost(t *testing.T) {
+	db := openTestDB(t)
+	handler := handlers.ConnectionsHandler(db)
+
+	for _, baseURL := range []string{"http://api.loyverse.com/v1.0", "https://attacker.example/v1.0"} {
+		if code := postConnection(t, handler, `{"merchant_id": "shop-1", "api_token": "token-1", "base_url": "`+baseURL+`"}`); code != http.StatusBadRequest {
+			t.Errorf("base_url %s status = %d, want %d", baseURL, code, http.StatusBadRequest)
+		}
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM loyverse_connections WHERE merchant_id = 'shop-1'"); got != 0 {
+		t.Errorf("connection with a rejected base_url was saved")
+	}
+
+	t.Setenv("LOYVERSE_ALLOWED_API_HOSTS", "loyverse.internal.example")
+	if code := postConnection(t, handler, `{"merchant_id": "shop-1", "api_token": "token-1", "base_url": "https://loyverse.internal.example/v1.0"}`); code != http.StatusOK {
+		t.Errorf("allow-listed base_url status = %d, want %d", code, http.StatusOK)
+	}
+}
+
+func TestRestartKeepsWebhookSecretSetThroughTheAPI(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := repository.EnsureDefaultConnection(db, "env-token", "", ""); err != nil {
+		t.Fatalf("EnsureDefaultConnection: %v", err)
+	}
+	if code := postConnection(t, handlers.ConnectionsHandler(db), `{"merchant_id": "default", "webhook_secret": "api-secret", "base_url": "https://api.loyverse.com/v1.0"}`); code != http.StatusOK {
+		t.Fatalf("set webhook secret status = %d", code)
+	}
+	// restart โดยไม่ได้ตั้ง LOYVERSE_WEBHOOK_SECRET และ LOYVERSE_API_BASE_URL
+	if err := repository.EnsureDefaultConnection(db, "env-token", "", ""); err != nil {
+		t.Fatalf("EnsureDefaultConnection: %v", err)
+	}
+
+	conn, err := repository.GetConnection(db, models.DefaultMerchantID)
+	if err != nil {
+		t.Fatalf("GetConnection: %v", err)
+	}
+	if conn.WebhookSecret != "api-secret" || conn.BaseURL != "https://api.loyverse.com/v1.0" {
+		t.Errorf("default connection after restart = %+v, want the secret and base URL kept", conn)
+	}
+}
@@ -0,0 +1,365 @@
+package e2e
+
+import (
+	"backend/external/loyverse/api"
+	"backend/external/loyverse/fakeloyverse"
+	"backend/external/loyverse/handlers"
+	"backend/external/loyverse/models"
+	"backend/external/loyverse/services"
+	"backend/external/loyverse/utils"
+	"backend/pkg/money"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFetchMasterDataFollowsCursors(t *testing.T) {
+	fake := startFake(t, fakeloyverse.DefaultSeed())
+	fake.PageSize = 2
+
+	data, err := services.FetchMasterData(context.Background(), fakeConnection(fake, models.DefaultMerchantID))
+	if err != nil {
+		t.Fatalf("FetchMasterData: %v", err)
+	}
+
+	if len(data.Items) != 5 || len(data.Stores) != 2 || len(data.Categories) != 2 || len(data.Customers) != 2 {
+		t.Fatalf("got %d items, %d stores, %d categories, %d customers", len(data.Items), len(data.Stores), len(data.Categories), len(data.Customers))
+	}
+	if got := fake.Requests("items"); got != 3 {
+		t.Errorf("items requests = %d, want 3 pages", got)
+	}
+	// categories 1 + items 3 + payment types 1 + taxes 1 + discounts 1 + modifiers 1 + stores 1 + employees 1 + POS devices 1
+	// + suppliers 1 + customers 1
+	if data.PagesFetched != 13 {
+		t.Errorf("PagesFetched = %d, want 13", data.PagesFetched)
+	}
+	if len(data.Employees) != 2 || len(data.PosDevices) != 2 {
+		t.Errorf("got %d employees, %d POS devices", len(data.Employees), len(data.PosDevices))
+	}
+	if len(data.Taxes) != 1 || len(data.Discounts) != 1 || len(data.Modifiers) != 1 || len(data.Modifiers[0].ModifierOptions) != 2 {
+		t.Errorf("got %d taxes, %d discounts, %d modifiers", len(data.Taxes), len(data.Discounts), len(data.Modifiers))
+	}
+}
+
+func TestFetchMasterDataIncludesDeletedRecords(t *testing.T) {
+	fake := startFake(t, fakeloyverse.DefaultSeed())
+	fake.Delete("items", "item-3")
+	fake.Delete("suppliers", "supplier-1")
+
+	data, err := services.FetchMasterData(context.Background(), fakeConnection(fake, models.DefaultMerchantID))
+	if err != nil {
+		t.Fatalf("FetchMasterData: %v", err)
+	}
+	if len(data.Items) != 5 {
+		t.Fatalf("got %d items, want 5 including the deleted one", len(data.Items))
+	}
+	for _, item := range data.Items {
+		if deleted := item.DeletedAt != nil; deleted != (item.ID == "item-3") {
+			t.Errorf("item %s deleted_at = %v", item.ID, item.DeletedAt)
+		}
+	}
+	for _, supplier := range data.Suppliers {
+		if supplier.SupplierID == "supplier-1" && supplier.DeletedAt == nil {
+			t.Errorf("supplier-1 was fetched without deleted_at")
+		}
+	}
+}
+
+func TestFetchReceiptsBatchOnlyReturnsUpdatedReceipts(t *testing.T) {
+	fake := startFake(t, fakeloyverse.DefaultSeed())
+
+	since := fakeloyverse.SeedTime.Add(6 * time.Hour)
+	receipts, cursor, err := services.FetchReceiptsBatch(context.Background(), fakeConnection(fake, models.DefaultMerchantID), "", 250, since)
+	if err != nil {
+		t.Fatalf("FetchReceiptsBatch: %v", err)
+	}
+	if len(receipts) != 7 || cursor != "" {
+		t.Fatalf("got %d receipts with cursor %q, want 7 receipts on one page", len(receipts), cursor)
+	}
+	for _, receipt := range receipts {
+		if receipt.UpdatedAt.Before(since) {
+			t.Errorf("receipt %s updated at %s is older than %s", receipt.ReceiptNumber, receipt.UpdatedAt, since)
+		}
+	}
+}
+
+func TestFetchInventoryRecoversFromRateLimitAndServerError(t *testing.T) {
+	fake := startFake(t, fakeloyverse.DefaultSeed())
+	fake.Inject("inventory",
+		fakeloyverse.Fault{Status: http.StatusTooManyRequests, RetryAfter: time.Second},
+		fakeloyverse.Fault{Status: http.StatusInternalServerError},
+	)
+
+	levels, pages, err := services.FetchInventoryLevels(context.Background(), fakeConnection(fake, models.DefaultMerchantID))
+	if err != nil {
+		t.Fatalf("FetchInventoryLevels: %v", err)
+	}
+	if len(levels) != 10 || pages != 1 {
+		t.Fatalf("got %d levels in %d pages, want 10 in 1", len(levels), pages)
+	}
+	if got := fake.Requests("inventory"); got != 3 {
+		t.Errorf("inventory requests = %d, want 3", got)
+	}
+}
+
+func TestMalformedResponseIsReported(t *testing.T) {
+	fake := startFake(t, fakeloyverse.DefaultSeed())
+	fake.Inject("stores", fakeloyverse.Fault{Malformed: true})
+
+	_, err := services.FetchMasterData(context.Background(), fakeConnection(fake, models.DefaultMerchantID))
+	if err == nil || !strings.Contains(err.Error(), "decoding stores response") {
+		t.Fatalf("err = %v, want decoding error for stores", err)
+	}
+}
+
+func TestClientGivesUpAfterMaxRetries(t *testing.T) {
+	fake := startFake(t, fakeloyverse.DefaultSeed())
+	fake.Inject("items",
+		fakeloyverse.Fault{Status: http.StatusBadGateway},
+		fakeloyverse.Fault{Status: http.StatusBadGateway},
+	)
+
+	client := api.NewClient(fakeloyverse.Token)
+	client.BaseURL = fake.BaseURL()
+	client.HTTP.MaxRetries = 1
+	client.HTTP.BaseBackoff = time.Millisecond
+
+	_, err := client.ListItems(context.Background(), api.ListOptions{})
+	var exhausted *utils.RetriesExhaustedError
+	var apiErr *utils.APIError
+	if !errors.As(err, &exhausted) || !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("err = %v, want RetriesExhaustedError wrapping a 502", err)
+	}
+}
+
+func TestClientDoesNotWaitForLongRetryAfter(t *testing.T) {
+	fake := startFake(t, fakeloyverse.DefaultSeed())
+	fake.Inject("items", fakeloyverse.Fault{Status: http.StatusTooManyRequests, RetryAfter: time.Hour})
+
+	client := api.NewClient(fakeloyverse.Token)
+	client.BaseURL = fake.BaseURL()
+	client.HTTP.MaxRetryAfter = time.Minute
+
+	start := time.Now()
+	_, err := client.ListItems(context.Background(), api.ListOptions{})
+	var exhausted *utils.RetriesExhaustedError
+	var apiErr *utils.APIError
+	if errors.As(err, &exhausted) || !errors.As(err, &apiErr) || !apiErr.RateLimited() {
+		t.Fatalf("err = %v, want a 429 APIError without retries", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("client waited %s for a Retry-After above the cap", elapsed)
+	}
+	if got := fake.Requests("items"); got != 1 {
+		t.Errorf("items requests = %d, want 1", got)
+	}
+}
+
+func TestClientDoesNotRetryUnauthorized(t *testing.T) {
+	fake := startFake(t, fakeloyverse.DefaultSeed())
+
+	client := api.NewClient("wrong-token")
+	client.BaseURL = fake.BaseURL()
+
+	_, err := client.ListStores(context.Background(), api.ListOptions{})
+	var apiErr *utils.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("err = %v, want 401 APIError", err)
+	}
+	if got := fake.Requests("stores"); got != 1 {
+		t.Errorf("stores requests = %d, want 1", got)
+	}
+}
+
+func TestWebhookWithWrongSignatureIsRejected(t *testing.T) {
+	fake := startFake(t, fakeloyverse.DefaultSeed())
+	// ลายเซ็นผิดจะถูกปฏิเสธก่อนแตะฐานข้อมูล จึงส่ง db เป็น nil ได้
+	receiver := httptest.NewServer(handlers.LoyverseWebhookHandler(nil, staticSecret("connector-secret")))
+	defer receiver.Close()
+	fake.WebhookURL = receiver.URL
+	fake.WebhookSecret = "another-secret"
+
+	receipt, _ := fake.Get("receipts", "1-0001")
+	err := fake.SendWebhook("receipts.update", receipt)
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("err = %v, want 401 from webhook handler", err)
+	}
+}
+
+func TestSyncJobStreamsProgressAndMergesDuplicateTriggers(t *testing.T) {
+	fake := startFake(t, fakeloyverse.DefaultSeed())
+	fake.PageSize = 4
+	fake.Inject("inventory", fakeloyverse.Fault{Status: http.StatusInternalServerError})
+	conn := fakeConnection(fake, models.DefaultMerchantID)
+
+	jobs := services.NewSyncJobManager()
+	release := make(chan struct{})
+	run := func(ctx context.Context) error {
+		<-release
+		levels, _, err := services.FetchInventoryLevels(ctx, conn)
+		if err != nil {
+			return err
+		}
+		utils.ReportProgress(ctx, models.SyncJobEvent{Type: models.SyncJobEventRows, Resource: "inventory", Rows: int64(len(levels))})
+		return nil
+	}
+
+	job, err := jobs.Start(conn.MerchantID, models.SyncEntityInventoryLevels, models.SyncTriggerManual, run)
+	if err != nil || job.Merged || job.Status != models.SyncStatusRunning {
+		t.Fatalf("Start = %+v, %v", job, err)
+	}
+	duplicate, err := jobs.Start(conn.MerchantID, models.SyncEntityInventoryLevels, models.SyncTriggerManual, run)
+	if err != nil || !duplicate.Merged || duplicate.ID != job.ID {
+		t.Fatalf("second Start = %+v, %v, want it merged into %s", duplicate, err, job.ID)
+	}
+
+	server := httptest.NewServer(handlers.SyncJobHandler(jobs))
+	t.Cleanup(server.Close)
+	readEvents := func(lastEventID string) []models.SyncJobEvent {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/sync/jobs/"+job.ID+"/events", nil)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("GET events: %v", err)
+		}
+		defer resp.Body.Close()
+		if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+			t.Fatalf("Content-Type = %q", ct)
+		}
+
+		// stream ปิดเองหลัง event completed
+		var events []models.SyncJobEvent
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+			if !ok {
+				continue
+			}
+			var event models.SyncJobEvent
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				t.Fatalf("decode event %q: %v", data, err)
+			}
+			events = append(events, event)
+		}
+		return events
+	}
+
+	// เริ่มอ่าน stream ก่อนปล่อยให้ job ทำงาน เพื่อให้ได้ event แบบ live
+	done := make(chan []models.SyncJobEvent)
+	go func() { done <- readEvents("") }()
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	events := <-done
+
+	var types []string
+	for i, event := range events {
+		types = append(types, event.Type)
+		if event.Seq != int64(i+1) || event.Entity != models.SyncEntityInventoryLevels {
+			t.Errorf("event %d = %+v", i, event)
+		}
+	}
+	want := "started,error,page,page,page,rows,completed"
+	if got := strings.Join(types, ","); got != want {
+		t.Fatalf("event types = %s, want %s", got, want)
+	}
+	if page := events[4]; page.Resource != "inventory" || page.Page != 3 || page.Rows != 2 {
+		t.Errorf("last page event = %+v, want page 3 of inventory with 2 rows", page)
+	}
+	if completed := events[len(events)-1]; completed.Status != models.SyncStatusSucceeded {
+		t.Errorf("completed event = %+v", completed)
+	}
+
+	finished, ok := jobs.Get(job.ID)
+	if !ok || finished.Status != models.SyncStatusSucceeded || finished.PagesFetched != 3 || finished.RowsSaved != 10 || finished.FinishedAt == nil {
+		t.Fatalf("job = %+v", finished)
+	}
+
+	// reconnect ด้วย Last-Event-ID ได้เฉพาะ event ที่ยังไม่เคยได้รับ
+	if resumed := readEvents("6"); len(resumed) != 1 || resumed[0].Type != models.SyncJobEventCompleted {
+		t.Errorf("events after Last-Event-ID 6 = %+v", resumed)
+	}
+
+	// เมื่อ job เดิมจบแล้ว การสั่งใหม่จะได้ job ใหม่
+	next, err := jobs.Start(conn.MerchantID, models.SyncEntityInventoryLevels, models.SyncTriggerManual, func(context.Context) error { return nil })
+	if err != nil || next.Merged || next.ID == job.ID {
+		t.Errorf("Start after completion = %+v, %v", next, err)
+	}
+}
+
+func TestMoneyStaysExactFromLoyverseJSONToTotals(t *testing.T) {
+	fake := startFake(t, fakeloyverse.DefaultSeed())
+	later := fakeloyverse.SeedTime.Add(48 * time.Hour)
+	for i, amount := range []string{"0.10", "0.20", "0.05"} {
+		money, err := money.Parse(amount)
+		if err != nil {
+			t.Fatalf("money.Parse: %v", err)
+		}
+		receipt := models.LoyReceipt{
+			ReceiptNumber: fmt.Sprintf("9-%04d", i), ReceiptType: models.ReceiptTypeSale,
+			CreatedAt: later, ReceiptDate: later, UpdatedAt: later, StoreID: "store-1", TotalMoney: money,
+		}
+		if err := fake.Upsert("receipts", receipt); err != nil {
+			t.Fatalf("Upsert: %v", err)
+		}
+	}
+
+	receipts, _, err := services.FetchReceiptsBatch(context.Background(), fakeConnection(fake, models.DefaultMerchantID), "", 250, later)
+	if err != nil {
+		t.Fatalf("FetchReceiptsBatch: %v", err)
+	}
+	var total money.Money
+	for _, receipt := range receipts {
+		total = total.Add(receipt.TotalMoney)
+	}
+	// ด้วย float64 ผลรวมนี้คือ 0.35000000000000003
+	if len(receipts) != 3 || total.String() != "0.35" {
+		t.Fatalf("sum of %d receipts = %s, want 0.35", len(receipts), total)
+	}
+
+	encoded, err := json.Marshal(map[string]money.Money{"total_money": total})
+	if err != nil || string(encoded) != `{"total_money":0.35}` {
+		t.Errorf("JSON = %s, %v", encoded, err)
+	}
+	var decoded money.Money
+	if err := json.Unmarshal([]byte(`"12.50"`), &decoded); err != nil || !decoded.Equal(money.FromInt(25).Div(2)) {
+		t.Errorf("decoded string amount = %s, %v", decoded, err)
+	}
+
+	// การหารปัดเป็นสตางค์ และ Round ปัดครึ่งหนึ่งออกจากศูนย์
+	for _, tc := range []struct {
+		got  money.Money
+		want string
+	}{
+		{money.FromInt(10).Div(3), "3.33"},
+		{money.FromInt(20).Div(3), "6.67"},
+		{money.FromInt(-20).Div(3), "-6.67"},
+		{money.FromInt(5).Div(0), "0"},
+		{mustParseMoney(t, "0.005").Round(), "0.01"},
+		{mustParseMoney(t, "-0.005").Round(), "-0.01"},
+		{mustParseMoney(t, "2.675").Round(), "2.68"},
+		{mustParseMoney(t, "19.99").MulQuantity(0.5), "9.995"},
+	} {
+		if tc.got.String() != tc.want {
+			t.Errorf("got %s, want %s", tc.got, tc.want)
+		}
+	}
+}
+
+func mustParseMoney(t *testing.T, s string) money.Money {
+	t.Helper()
+	money, err := money.Parse(s)
+	if err != nil {
+		t.Fatalf("money.Parse(%q): %v", s, err)
+	}
+	return money
+}
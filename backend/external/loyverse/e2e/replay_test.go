@@ -0,0 +1,101 @@
+package e2e
+
+import (
+	"backend/external/loyverse/fakeloyverse"
+	"backend/external/loyverse/models"
+	"backend/external/loyverse/services"
+	"backend/external/loyverse/utils"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordedSyncCanBeReplayedOffline(t *testing.T) {
+	fake := startFake(t, fakeloyverse.DefaultSeed())
+	fake.PageSize = 2
+	fake.Inject("items", fakeloyverse.Fault{Status: http.StatusTooManyRequests, RetryAfter: time.Second})
+	dir := t.TempDir()
+	conn := fakeConnection(fake, models.DefaultMerchantID)
+
+	t.Setenv("LOYVERSE_FIXTURES_DIR", dir)
+	t.Setenv("LOYVERSE_API_MODE", "record")
+	recorded, err := services.FetchMasterData(context.Background(), conn)
+	if err != nil {
+		t.Fatalf("recording FetchMasterData: %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) == 0 {
+		t.Fatal("no fixtures were recorded")
+	}
+	for _, file := range files {
+		data, _ := os.ReadFile(file)
+		if strings.Contains(string(data), fakeloyverse.Token) {
+			t.Errorf("fixture %s contains the API token", filepath.Base(file))
+		}
+	}
+
+	// ปิด server ปลอมเพื่อยืนยันว่า replay ไม่เรียก network
+	fake.Close()
+	t.Setenv("LOYVERSE_API_MODE", "replay")
+	start := time.Now()
+	replayed, err := services.FetchMasterData(context.Background(), conn)
+	if err != nil {
+		t.Fatalf("replaying FetchMasterData: %v", err)
+	}
+	if !reflect.DeepEqual(recorded, replayed) {
+		t.Errorf("replayed master data differs from recorded data")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("replay waited %s, want no Retry-After delay", elapsed)
+	}
+}
+
+func TestReplayFailsForUnrecordedRequest(t *testing.T) {
+	t.Setenv("LOYVERSE_FIXTURES_DIR", t.TempDir())
+	t.Setenv("LOYVERSE_API_MODE", "replay")
+
+	conn := models.Connection{MerchantID: models.DefaultMerchantID, APIToken: fakeloyverse.Token, BaseURL: "http://127.0.0.1:1/v1.0"}
+	_, _, err := services.FetchInventoryLevels(context.Background(), conn)
+	if err == nil || !strings.Contains(err.Error(), "no recorded response") {
+		t.Fatalf("err = %v, want missing fixture error", err)
+	}
+}
+
+func TestRecorderRedactsTokenOfEveryConnection(t *testing.T) {
+	// server ที่ส่ง token กลับมาใน body เพื่อให้เห็นว่า token ของทุก connection ถูกแทนใน fixtures
+	echo := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"authorization": %q}`, r.Header.Get("Authorization"))
+	}))
+	defer echo.Close()
+	dir := t.TempDir()
+
+	for _, token := range []string{"token-of-shop-a", "token-of-shop-b"} {
+		client := utils.NewClient(token)
+		if err := client.UseRecorder(utils.RecorderModeRecord, dir); err != nil {
+			t.Fatalf("UseRecorder: %v", err)
+		}
+		if _, err := client.Get(context.Background(), echo.URL+"/v1.0/merchant?shop="+token); err != nil {
+			t.Fatalf("Get with %s: %v", token, err)
+		}
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 2 {
+		t.Fatalf("recorded %d fixtures, want 2", len(files))
+	}
+	for _, file := range files {
+		data, _ := os.ReadFile(file)
+		if strings.Contains(string(data), "token-of-shop") {
+			t.Errorf("fixture %s contains an API token: %s", filepath.Base(file), data)
+		}
+	}
+}
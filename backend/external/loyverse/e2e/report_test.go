@@ -0,0 +1,59 @@
+package e2e
+
+import (
+	"backend/external/loyverse/fakeloyverse"
+	"backend/external/loyverse/handlers"
+	"backend/external/loyverse/models"
+	"backend/internal/SaleManagement/infrastructure/data"
+	"backend/pkg/money"
+	"context"
+	"testing"
+)
+
+// รายงานของ SaleManagement อ่านตารางที่ connector นี้เขียน จึงทดสอบกับข้อมูลที่ sync มาจริง
+
+func TestTaxSummaryOfSyncedReceipts(t *testing.T) {
+	seed := fakeloyverse.DefaultSeed()
+	fake := startFake(t, seed)
+	db := openTestDB(t)
+	conn := saveConnection(t, db, fakeConnection(fake, models.DefaultMerchantID))
+	ctx := context.Background()
+
+	if err := handlers.SyncMasterData(ctx, db, conn, models.SyncTriggerManual); err != nil {
+		t.Fatalf("SyncMasterData: %v", err)
+	}
+	if err := handlers.SyncReceipts(ctx, db, conn, models.SyncTriggerManual, false); err != nil {
+		t.Fatalf("SyncReceipts: %v", err)
+	}
+
+	summary, err := data.NewReceiptRepository(db).FetchTaxSummary(conn.MerchantID, false)
+	if err != nil {
+		t.Fatalf("FetchTaxSummary: %v", err)
+	}
+	if len(summary) == 0 {
+		t.Fatal("tax summary is empty, want rows for the VAT on every receipt line")
+	}
+
+	var want, got money.Money
+	for _, receipt := range seed.Receipts {
+		for _, line := range receipt.LineItems {
+			for _, tax := range line.LineTaxes {
+				want = want.Add(tax.MoneyAmount)
+			}
+		}
+	}
+	receipts := 0
+	for _, row := range summary {
+		if row.TaxID != "tax-vat" || row.TaxName != "VAT" || row.Rate != 7 {
+			t.Errorf("unexpected tax summary row %+v", row)
+		}
+		got = got.Add(row.TotalTax)
+		receipts += row.ReceiptCount
+	}
+	if !got.Equal(want) {
+		t.Errorf("total tax = %s, want %s", got, want)
+	}
+	if receipts != len(seed.Receipts) {
+		t.Errorf("receipt count = %d, want %d", receipts, len(seed.Receipts))
+	}
+}
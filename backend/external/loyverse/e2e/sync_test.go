@@ -0,0 +1,781 @@
+package e2e
+
+import (
+	"backend/external/loyverse/background"
+	"backend/external/loyverse/fakeloyverse"
+	"backend/external/loyverse/handlers"
+	"backend/external/loyverse/models"
+	"backend/external/loyverse/repository"
+	"backend/external/loyverse/services"
+	"backend/external/loyverse/utils"
+	"backend/pkg/money"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyncMasterDataSoftDeletesRemovedRows(t *testing.T) {
+	fake := startFake(t, fakeloyverse.DefaultSeed())
+	fake.PageSize = 2
+	db := openTestDB(t)
+	conn := saveConnection(t, db, fakeConnection(fake, models.DefaultMerchantID))
+	ctx := context.Background()
+
+	if err := handlers.SyncMasterData(ctx, db, conn, models.SyncTriggerManual); err != nil {
+		t.Fatalf("first SyncMasterData: %v", err)
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM loyitems WHERE deleted_at IS NULL"); got != 5 {
+		t.Fatalf("active items = %d, want 5", got)
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM loyvariants WHERE sku LIKE 'SKU-%' AND default_pricing_type = 'FIXED'"); got != 5 {
+		t.Errorf("variants = %d, want 5", got)
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM loyvariant_stores WHERE available_for_sale AND price IS NOT NULL"); got != 10 {
+		t.Errorf("variant store prices = %d, want 10", got)
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM loymodifier_options WHERE modifier_id = 'modifier-sweet'"); got != 2 {
+		t.Errorf("modifier options = %d, want 2", got)
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM loytaxes WHERE rate = 7") + countRows(t, db, "SELECT COUNT(*) FROM loydiscounts WHERE discount_percent = 10"); got != 2 {
+		t.Errorf("taxes and discounts = %d, want 2", got)
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM loyemployees") + countRows(t, db, "SELECT COUNT(*) FROM loyposdevices WHERE activated"); got != 4 {
+		t.Errorf("employees and POS devices = %d, want 4", got)
+	}
+
+	fake.Delete("items", "item-3")
+	if err := handlers.SyncMasterData(ctx, db, conn, models.SyncTriggerManual); err != nil {
+		t.Fatalf("second SyncMasterData: %v", err)
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM loyitems WHERE deleted_at IS NOT NULL AND item_id = 'item-3'"); got != 1 {
+		t.Errorf("item-3 was not soft-deleted")
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM loyvariants WHERE deleted_at IS NOT NULL AND variant_id = 'variant-3'"); got != 1 {
+		t.Errorf("variant of deleted item-3 was not soft-deleted")
+	}
+
+	runs, err := repository.ListSyncRuns(db, conn.MerchantID, models.SyncEntityMasterData, 10)
+	if err != nil {
+		t.Fatalf("ListSyncRuns: %v", err)
+	}
+	// item-3 และ variant ของมัน
+	if len(runs) != 2 || runs[0].Status != models.SyncStatusSucceeded || runs[0].RowsDeleted != 2 {
+		t.Errorf("unexpected sync runs: %+v", runs)
+	}
+}
+
+func TestSyncReceiptsIsIncrementalAfterFirstRun(t *testing.T) {
+	fake := startFake(t, fakeloyverse.DefaultSeed())
+	fake.PageSize = 5
+	db := openTestDB(t)
+	conn := saveConnection(t, db, fakeConnection(fake, models.DefaultMerchantID))
+	ctx := context.Background()
+
+	if err := handlers.SyncReceipts(ctx, db, conn, models.SyncTriggerManual, false); err != nil {
+		t.Fatalf("first SyncReceipts: %v", err)
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM loyreceipts"); got != 12 {
+		t.Fatalf("receipts = %d, want 12", got)
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM receipt_line_items WHERE quantity = 2 AND item_id IS NOT NULL"); got != 12 {
+		t.Errorf("receipt_line_items = %d, want 12", got)
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM receipt_payments WHERE payment_type_id = 'payment-cash'"); got != 12 {
+		t.Errorf("receipt_payments = %d, want 12", got)
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM receipt_line_taxes WHERE tax_id = 'tax-vat'"); got != 12 {
+		t.Errorf("receipt_line_taxes = %d, want 12", got)
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM receipt_line_discounts WHERE discount_id = 'discount-member'"); got != 3 {
+		t.Errorf("receipt_line_discounts = %d, want 3", got)
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM loyreceipts WHERE employee_id = 'employee-2' AND pos_device_id LIKE 'pos-store-%'"); got != 6 {
+		t.Errorf("receipts of employee-2 = %d, want 6", got)
+	}
+
+	later := fakeloyverse.SeedTime.Add(48 * time.Hour)
+	fake.Upsert("receipts", models.LoyReceipt{
+		ReceiptNumber: "1-9999", CreatedAt: later, ReceiptDate: later, UpdatedAt: later, StoreID: "store-1", TotalMoney: money.FromInt(99),
+	})
+	if err := handlers.SyncReceipts(ctx, db, conn, models.SyncTriggerManual, false); err != nil {
+		t.Fatalf("second SyncReceipts: %v", err)
+	}
+
+	runs, err := repository.ListSyncRuns(db, conn.MerchantID, models.SyncEntityReceipts, 1)
+	if err != nil {
+		t.Fatalf("ListSyncRuns: %v", err)
+	}
+	if len(runs) != 1 || runs[0].RowsUpserted != 1 || runs[0].PagesFetched != 1 {
+		t.Errorf("incremental run = %+v, want 1 row on 1 page", runs)
+	}
+	watermark, ok, err := repository.GetSyncWatermark(db, conn.MerchantID, models.SyncEntityReceipts)
+	if err != nil || !ok || !watermark.Equal(later) {
+		t.Errorf("watermark = %s (%v, %v), want %s", watermark, ok, err, later)
+	}
+}
+
+func TestRefundReceiptIsLinkedToOriginalSale(t *testing.T) {
+	fake := startFake(t, fakeloyverse.DefaultSeed())
+	db := openTestDB(t)
+	conn := saveConnection(t, db, fakeConnection(fake, models.DefaultMerchantID))
+
+	sale, _ := fake.Get("receipts", "1-0002")
+	later := fakeloyverse.SeedTime.Add(24 * time.Hour)
+	original := "1-0002"
+	reason := "สินค้าชำรุด"
+	refund := models.LoyReceipt{
+		ReceiptNumber: "1-0100", ReceiptType: models.ReceiptTypeRefund, RefundFor: &original, Note: &reason,
+		CreatedAt: later, ReceiptDate: later, UpdatedAt: later, StoreID: sale["store_id"].(string), TotalMoney: money.FromInt(50),
+		LineItems: []models.LineItem{{ID: "line-refund", ItemID: "item-2", VariantID: "variant-2", ItemName: "refund", Quantity: 1, Price: money.FromInt(50), TotalMoney: money.FromInt(50)}},
+	}
+	if err := fake.Upsert("receipts", refund); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+
+	if err := handlers.SyncReceipts(context.Background(), db, conn, models.SyncTriggerManual, false); err != nil {
+		t.Fatalf("SyncReceipts: %v", err)
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM loyreceipts WHERE receipt_type = 'REFUND' AND refund_for = '1-0002'"); got != 1 {
+		t.Errorf("refund receipts linked to 1-0002 = %d, want 1", got)
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM loyreceipts WHERE receipt_type = 'SALE'"); got != 12 {
+		t.Errorf("sale receipts = %d, want 12", got)
+	}
+}
+
+func TestSyncShiftsRefetchesShiftsThatWereOpen(t *testing.T) {
+	fake := startFake(t, fakeloyverse.DefaultSeed())
+	db := openTestDB(t)
+	conn := saveConnection(t, db, fakeConnection(fake, models.DefaultMerchantID))
+	ctx := context.Background()
+
+	openedAt := fakeloyverse.SeedTime.Add(24 * time.Hour)
+	open := models.LoyShift{ShiftID: "shift-open", StoreID: "store-1", PosDeviceID: "pos-store-1", OpenedAt: openedAt, StartingCash: money.FromInt(500), ExpectedCash: money.FromInt(500), CreatedAt: openedAt, UpdatedAt: openedAt}
+	if err := fake.Upsert("shifts", open); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	if err := handlers.SyncShifts(ctx, db, conn, models.SyncTriggerManual); err != nil {
+		t.Fatalf("first SyncShifts: %v", err)
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM loyshifts WHERE actual_cash IS NOT NULL"); got != 2 {
+		t.Errorf("closed shifts = %d, want 2", got)
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM loyshift_cash_movements WHERE type = 'PAY_OUT'"); got != 1 {
+		t.Errorf("cash movements = %d, want 1", got)
+	}
+
+	// กะที่เปิดทีหลังเลื่อน watermark ไปเกินกะที่ยังเปิดค้างอยู่
+	later := openedAt.Add(24 * time.Hour)
+	if err := fake.Upsert("shifts", models.LoyShift{ShiftID: "shift-later", StoreID: "store-2", OpenedAt: later, CreatedAt: later, UpdatedAt: later}); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	if err := handlers.SyncShifts(ctx, db, conn, models.SyncTriggerManual); err != nil {
+		t.Fatalf("second SyncShifts: %v", err)
+	}
+
+	closedAt := later.Add(time.Hour)
+	actual := money.FromInt(480)
+	open.ClosedAt, open.ActualCash, open.UpdatedAt = &closedAt, &actual, closedAt
+	if err := fake.Upsert("shifts", open); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	if err := handlers.SyncShifts(ctx, db, conn, models.SyncTriggerManual); err != nil {
+		t.Fatalf("third SyncShifts: %v", err)
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM loyshifts WHERE shift_id = 'shift-open' AND actual_cash = 480"); got != 1 {
+		t.Errorf("shift that was open was not refreshed after closing")
+	}
+}
+
+func TestReceiptBackfillResumesFromSavedCursor(t *testing.T) {
+	fake := startFake(t, fakeloyverse.DefaultSeed())
+	fake.PageSize = 1
+	db := openTestDB(t)
+	conn := saveConnection(t, db, fakeConnection(fake, models.DefaultMerchantID))
+	ctx := context.Background()
+
+	// ใบเสร็จที่ 2-8 อยู่ในช่วงนี้ ของ store-1 มี 4 ใบ (2, 4, 6, 8)
+	from := fakeloyverse.SeedTime.Add(90 * time.Minute)
+	to := fakeloyverse.SeedTime.Add(8 * time.Hour)
+	fake.Inject("receipts", fakeloyverse.Pass, fakeloyverse.Fault{Malformed: true})
+
+	first, err := handlers.BackfillReceipts(ctx, db, conn, from, to, "store-1", models.SyncTriggerManual)
+	if err == nil {
+		t.Fatalf("first BackfillReceipts succeeded, want decoding error on the second page")
+	}
+	if first == nil || first.Status != models.SyncStatusFailed || first.PagesFetched != 1 || first.Cursor == "" {
+		t.Fatalf("first backfill = %+v, want failed after one page with a cursor", first)
+	}
+
+	second, err := handlers.BackfillReceipts(ctx, db, conn, from, to, "store-1", models.SyncTriggerManual)
+	if err != nil {
+		t.Fatalf("second BackfillReceipts: %v", err)
+	}
+	if second.ID != first.ID || second.Status != models.SyncStatusSucceeded || second.ReceiptsSaved != 4 || second.PagesFetched != 4 {
+		t.Errorf("second backfill = %+v, want backfill %d resumed to 4 receipts on 4 pages", second, first.ID)
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM loyreceipts WHERE store_id = 'store-1'"); got != 4 {
+		t.Errorf("store-1 receipts = %d, want 4", got)
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM loyreceipts"); got != 4 {
+		t.Errorf("receipts outside the window or store were saved: %d rows", got)
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM sync_runs WHERE entity_type = $1 AND status = $2 AND rows_upserted = 3", models.SyncEntityReceiptBackfill, models.SyncStatusSucceeded); got != 1 {
+		t.Errorf("resumed sync run did not record the remaining 3 receipts")
+	}
+}
+
+func TestReceiptBackfillRejectsSecondRunnerOfSameRange(t *testing.T) {
+	db := openTestDB(t)
+	from := fakeloyverse.SeedTime
+	to := fakeloyverse.SeedTime.Add(24 * time.Hour)
+
+	running, resumed, err := repository.StartReceiptBackfill(db, models.DefaultMerchantID, "", from, to, 1)
+	if err != nil || resumed {
+		t.Fatalf("first StartReceiptBackfill = %+v, resumed %v, err %v", running, resumed, err)
+	}
+	if _, _, err := repository.StartReceiptBackfill(db, models.DefaultMerchantID, "", from, to, 2); !errors.Is(err, repository.ErrReceiptBackfillRunning) {
+		t.Fatalf("second StartReceiptBackfill err = %v, want ErrReceiptBackfillRunning", err)
+	}
+
+	// runner ที่หยุดไปโดยไม่มีความคืบหน้าเกิน lease ถูกทำต่อได้
+	if _, err := db.Exec("UPDATE receipt_backfills SET updated_at = NOW() - INTERVAL '1 day' WHERE id = $1", running.ID); err != nil {
+		t.Fatalf("age backfill: %v", err)
+	}
+	taken, resumed, err := repository.StartReceiptBackfill(db, models.DefaultMerchantID, "", from, to, 3)
+	if err != nil || !resumed || taken.ID != running.ID {
+		t.Fatalf("StartReceiptBackfill after lease = %+v, resumed %v, err %v; want backfill %d resumed", taken, resumed, err, running.ID)
+	}
+}
+
+func TestReconciliationFindsDriftAndResyncResolvesIt(t *testing.T) {
+	fake := startFake(t, fakeloyverse.DefaultSeed())
+	db := openTestDB(t)
+	conn := saveConnection(t, db, fakeConnection(fake, models.DefaultMerchantID))
+	ctx := context.Background()
+
+	if err := handlers.SyncMasterData(ctx, db, conn, models.SyncTriggerManual); err != nil {
+		t.Fatalf("SyncMasterData: %v", err)
+	}
+	if err := handlers.SyncReceipts(ctx, db, conn, models.SyncTriggerManual, true); err != nil {
+		t.Fatalf("SyncReceipts: %v", err)
+	}
+	if err := handlers.SyncInventoryLevels(ctx, db, conn, models.SyncTriggerManual); err != nil {
+		t.Fatalf("SyncInventoryLevels: %v", err)
+	}
+
+	// การเปลี่ยนแปลงที่ webhook พลาดไป
+	recent := time.Now().Add(-time.Hour)
+	if err := fake.Upsert("receipts", models.LoyReceipt{
+		ReceiptNumber: "9-0001", ReceiptType: models.ReceiptTypeSale, CreatedAt: recent, ReceiptDate: recent, UpdatedAt: recent,
+		StoreID: "store-1", TotalMoney: money.FromInt(50),
+	}); err != nil {
+		t.Fatalf("Upsert receipt: %v", err)
+	}
+	if err := fake.Upsert("inventory", models.LoyInventoryLevel{VariantID: "variant-2", StoreID: "store-2", InStock: 99, UpdatedAt: recent}); err != nil {
+		t.Fatalf("Upsert inventory: %v", err)
+	}
+	fake.Delete("items", "item-2")
+
+	for i := 0; i < 2; i++ {
+		result, err := handlers.Reconcile(ctx, db, conn, handlers.ReconciliationDays, models.SyncTriggerManual)
+		if err != nil {
+			t.Fatalf("Reconcile: %v", err)
+		}
+		if len(result.Findings) != 3 || result.LocalItems != 5 || result.RemoteItems != 4 {
+			t.Fatalf("reconciliation result = %+v, want 3 findings and 5 local / 4 remote items", result)
+		}
+	}
+	// รอบที่สองต้องอัปเดต finding เดิมแทนการสร้างซ้ำ
+	findings, err := repository.ListReconciliationFindings(db, conn.MerchantID, models.FindingStatusOpen, 10)
+	if err != nil {
+		t.Fatalf("ListReconciliationFindings: %v", err)
+	}
+	keys := make(map[string]bool)
+	for _, finding := range findings {
+		keys[finding.Key] = true
+		// ยอดเงินของ finding ใบเสร็จต้องต่างกันพอดี 50 บาท ไม่คลาดจากการแปลงเป็น float
+		if finding.Kind == models.FindingKindReceiptsDay && !finding.RemoteTotal.Sub(finding.LocalTotal).Equal(money.FromInt(50)) {
+			t.Errorf("receipts finding totals = %s local / %s remote, want 50 apart", finding.LocalTotal, finding.RemoteTotal)
+		}
+	}
+	loc, err := time.LoadLocation(utils.BusinessTimezone)
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+	day := recent.In(loc).Format("2006-01-02")
+	for _, key := range []string{"receipts_day:" + day + ":store-1", "item:item-2", "inventory_level:variant-2:store-2"} {
+		if !keys[key] {
+			t.Errorf("missing open finding %s in %v", key, keys)
+		}
+	}
+	if len(findings) != 3 {
+		t.Fatalf("open findings = %d, want 3", len(findings))
+	}
+
+	for i := range findings {
+		if err := handlers.ResyncFinding(ctx, db, conn, &findings[i]); err != nil {
+			t.Fatalf("ResyncFinding %s: %v", findings[i].Key, err)
+		}
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM loyreceipts WHERE receipt_number = '9-0001'"); got != 1 {
+		t.Errorf("missed receipt was not resynced")
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM loyitems WHERE item_id = 'item-2' AND deleted_at IS NOT NULL"); got != 1 {
+		t.Errorf("deleted item was not resynced")
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM loyinventorylevels WHERE variant_id = 'variant-2' AND store_id = 'store-2' AND in_stock = 99"); got != 1 {
+		t.Errorf("inventory level was not resynced")
+	}
+
+	result, err := handlers.Reconcile(ctx, db, conn, handlers.ReconciliationDays, models.SyncTriggerManual)
+	if err != nil {
+		t.Fatalf("Reconcile after resync: %v", err)
+	}
+	if len(result.Findings) != 0 {
+		t.Errorf("findings after resync = %+v, want none", result.Findings)
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM reconciliation_findings WHERE status = $1", models.FindingStatusResolved); got != 3 {
+		t.Errorf("resolved findings = %d, want 3", got)
+	}
+}
+
+func TestDryRunSyncPreviewsDiffAndAppliesIt(t *testing.T) {
+	fake := startFake(t, fakeloyverse.DefaultSeed())
+	db := openTestDB(t)
+	conn := saveConnection(t, db, fakeConnection(fake, models.DefaultMerchantID))
+	ctx := context.Background()
+
+	if err := handlers.SyncMasterData(ctx, db, conn, models.SyncTriggerManual); err != nil {
+		t.Fatalf("SyncMasterData: %v", err)
+	}
+	if err := handlers.SyncInventoryLevels(ctx, db, conn, models.SyncTriggerManual); err != nil {
+		t.Fatalf("SyncInventoryLevels: %v", err)
+	}
+
+	item, _ := fake.Get("items", "item-1")
+	item["item_name"] = "ชื่อใหม่"
+	if err := fake.Upsert("items", item); err != nil {
+		t.Fatalf("Upsert item: %v", err)
+	}
+	fake.Delete("items", "item-3")
+	if err := fake.Upsert("inventory", models.LoyInventoryLevel{VariantID: "variant-1", StoreID: "store-1", InStock: 42, UpdatedAt: time.Now()}); err != nil {
+		t.Fatalf("Upsert inventory: %v", err)
+	}
+
+	masterPreview, err := handlers.PreviewMasterData(ctx, db, conn)
+	if err != nil {
+		t.Fatalf("PreviewMasterData: %v", err)
+	}
+	if got := strings.Join(masterPreview.Summary, ", "); got != "1 items removed, 1 items renamed, 1 variants removed" {
+		t.Errorf("master data summary = %q", got)
+	}
+	inventoryPreview, err := handlers.PreviewInventoryLevels(ctx, db, conn)
+	if err != nil {
+		t.Fatalf("PreviewInventoryLevels: %v", err)
+	}
+	if diff := inventoryPreview.Diff[0]; diff.Updated != 1 || diff.Inserted != 0 || diff.Deleted != 0 || *diff.Updates[0].Changes["in_stock"].To != "42" {
+		t.Errorf("inventory diff = %+v, want one in_stock change to 42", diff)
+	}
+
+	// dry-run ต้องไม่เขียนอะไรลงตาราง
+	if got := countRows(t, db, "SELECT COUNT(*) FROM loyitems WHERE item_id = 'item-1' AND item_name = 'ชื่อใหม่'"); got != 0 {
+		t.Errorf("dry-run renamed item-1")
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM loyitems WHERE deleted_at IS NOT NULL"); got != 0 {
+		t.Errorf("dry-run soft-deleted items")
+	}
+
+	// apply ใช้ข้อมูลชุดที่เก็บไว้กับ preview แม้ Loyverse จะเปลี่ยนต่อหลังจากนั้น
+	fake.Delete("items", "item-4")
+	if _, err := handlers.ApplySyncPreview(ctx, db, conn, masterPreview.ID, models.SyncTriggerManual); err != nil {
+		t.Fatalf("ApplySyncPreview master data: %v", err)
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM loyitems WHERE item_id = 'item-1' AND item_name = 'ชื่อใหม่'"); got != 1 {
+		t.Errorf("item-1 was not renamed by apply")
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM loyitems WHERE deleted_at IS NOT NULL"); got != 1 {
+		t.Errorf("apply did not soft-delete exactly item-3")
+	}
+	if _, err := handlers.ApplySyncPreview(ctx, db, conn, masterPreview.ID, models.SyncTriggerManual); !errors.Is(err, handlers.ErrSyncPreviewApplied) {
+		t.Errorf("second apply err = %v, want ErrSyncPreviewApplied", err)
+	}
+	if _, err := handlers.ApplySyncPreview(ctx, db, conn, inventoryPreview.ID, models.SyncTriggerManual); err != nil {
+		t.Fatalf("ApplySyncPreview inventory: %v", err)
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM loyinventorylevels WHERE variant_id = 'variant-1' AND store_id = 'store-1' AND in_stock = 42"); got != 1 {
+		t.Errorf("inventory preview was not applied")
+	}
+
+	// preview ที่สร้างก่อน sync ครั้งล่าสุดต้องถูกปฏิเสธ
+	stale, err := handlers.PreviewMasterData(ctx, db, conn)
+	if err != nil {
+		t.Fatalf("PreviewMasterData: %v", err)
+	}
+	if err := handlers.SyncMasterData(ctx, db, conn, models.SyncTriggerManual); err != nil {
+		t.Fatalf("SyncMasterData: %v", err)
+	}
+	if _, err := handlers.ApplySyncPreview(ctx, db, conn, stale.ID, models.SyncTriggerManual); !errors.Is(err, handlers.ErrSyncPreviewStale) {
+		t.Errorf("stale apply err = %v, want ErrSyncPreviewStale", err)
+	}
+}
+
+func TestPreviewIsStaleAfterWriteWithoutSyncRun(t *testing.T) {
+	fake := startFake(t, fakeloyverse.DefaultSeed())
+	db := openTestDB(t)
+	conn := saveConnection(t, db, fakeConnection(fake, models.DefaultMerchantID))
+	ctx := context.Background()
+
+	if err := handlers.SyncInventoryLevels(ctx, db, conn, models.SyncTriggerManual); err != nil {
+		t.Fatalf("SyncInventoryLevels: %v", err)
+	}
+	preview, err := handlers.PreviewInventoryLevels(ctx, db, conn)
+	if err != nil {
+		t.Fatalf("PreviewInventoryLevels: %v", err)
+	}
+
+	// การ push สต็อกเขียนตารางโดยไม่มี sync_run แต่ต้องทำให้ preview เก่า
+	pushed := []models.LoyInventoryLevel{{VariantID: "variant-1", StoreID: "store-1", InStock: 99, UpdatedAt: time.Now()}}
+	if err := repository.SaveInventoryLevels(db, conn.MerchantID, models.InventoryHistorySourcePush, pushed); err != nil {
+		t.Fatalf("SaveInventoryLevels: %v", err)
+	}
+	if _, err := handlers.ApplySyncPreview(ctx, db, conn, preview.ID, models.SyncTriggerManual); !errors.Is(err, handlers.ErrSyncPreviewStale) {
+		t.Fatalf("apply err = %v, want ErrSyncPreviewStale", err)
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM loyinventorylevels WHERE variant_id = 'variant-1' AND store_id = 'store-1' AND in_stock = 99"); got != 1 {
+		t.Errorf("stale preview overwrote the pushed stock")
+	}
+}
+
+func TestConcurrentAppliesOfOnePreviewWriteOnce(t *testing.T) {
+	fake := startFake(t, fakeloyverse.DefaultSeed())
+	db := openTestDB(t)
+	conn := saveConnection(t, db, fakeConnection(fake, models.DefaultMerchantID))
+	ctx := context.Background()
+
+	preview, err := handlers.PreviewInventoryLevels(ctx, db, conn)
+	if err != nil {
+		t.Fatalf("PreviewInventoryLevels: %v", err)
+	}
+
+	errs := make(chan error, 3)
+	for i := 0; i < cap(errs); i++ {
+		go func() {
+			_, err := handlers.ApplySyncPreview(ctx, db, conn, preview.ID, models.SyncTriggerManual)
+			errs <- err
+		}()
+	}
+	applied := 0
+	for i := 0; i < cap(errs); i++ {
+		switch err := <-errs; {
+		case err == nil:
+			applied++
+		case !errors.Is(err, handlers.ErrSyncPreviewApplied):
+			t.Errorf("apply err = %v, want nil or ErrSyncPreviewApplied", err)
+		}
+	}
+	if applied != 1 {
+		t.Errorf("preview applied %d times, want once", applied)
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM sync_runs WHERE entity_type = $1", models.SyncEntityInventoryLevels); got != 1 {
+		t.Errorf("inventory sync runs = %d, want 1", got)
+	}
+}
+
+func TestSyncIsRejectedWhileAnotherRunnerHoldsTheLock(t *testing.T) {
+	fake := startFake(t, fakeloyverse.DefaultSeed())
+	db := openTestDB(t)
+	conn := saveConnection(t, db, fakeConnection(fake, models.DefaultMerchantID))
+	ctx := context.Background()
+
+	// จำลอง cron หรือ process อื่นที่กำลัง sync สต็อกของ merchant เดียวกัน
+	if err := repository.AcquireSyncLock(db, conn.MerchantID, models.SyncEntityInventoryLevels, "other-process"); err != nil {
+		t.Fatalf("AcquireSyncLock: %v", err)
+	}
+	if err := handlers.SyncInventoryLevels(ctx, db, conn, models.SyncTriggerManual); !errors.Is(err, repository.ErrSyncLocked) {
+		t.Fatalf("SyncInventoryLevels err = %v, want ErrSyncLocked", err)
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM sync_runs"); got != 0 {
+		t.Errorf("rejected sync recorded %d runs", got)
+	}
+	if err := handlers.SyncReceipts(ctx, db, conn, models.SyncTriggerManual, false); err != nil {
+		t.Errorf("SyncReceipts of another entity: %v", err)
+	}
+
+	// lock ที่ไม่มี heartbeat เกิน lease ถูกรับช่วงได้ และถูกปลดเมื่อ sync จบ
+	if _, err := db.Exec("UPDATE sync_locks SET heartbeat_at = NOW() - INTERVAL '1 hour'"); err != nil {
+		t.Fatalf("age lock: %v", err)
+	}
+	if err := handlers.SyncInventoryLevels(ctx, db, conn, models.SyncTriggerManual); err != nil {
+		t.Fatalf("SyncInventoryLevels after lease: %v", err)
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM sync_locks"); got != 0 {
+		t.Errorf("sync_locks = %d after syncs finished, want 0", got)
+	}
+}
+
+func TestWebhookIsStoredAndProcessedByWorker(t *testing.T) {
+	fake := startFake(t, fakeloyverse.DefaultSeed())
+	db := openTestDB(t)
+	conn := saveConnection(t, db, fakeConnection(fake, models.DefaultMerchantID))
+	receiver := httptest.NewServer(handlers.LoyverseWebhookHandler(db, handlers.ConnectionWebhookSecrets(db, "")))
+	defer receiver.Close()
+	fake.WebhookURL = receiver.URL
+	fake.WebhookSecret = conn.WebhookSecret
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go background.RunWebhookWorker(ctx, db)
+
+	receipt, _ := fake.Get("receipts", "1-0005")
+	if err := fake.SendWebhook("receipts.update", receipt); err != nil {
+		t.Fatalf("SendWebhook: %v", err)
+	}
+
+	eventually(t, "webhook to be processed", func() bool {
+		return countRows(t, db, "SELECT COUNT(*) FROM webhook_events WHERE status = $1", models.WebhookStatusProcessed) == 1
+	})
+	if got := countRows(t, db, "SELECT COUNT(*) FROM loyreceipts WHERE receipt_number = '1-0005'"); got != 1 {
+		t.Errorf("receipt from webhook was not saved")
+	}
+
+	// webhook ที่สำเร็จต้องไม่ถูกแสดงเป็น sync ใบเสร็จที่สำเร็จครั้งล่าสุด
+	statuses, err := repository.GetSyncStatuses(db, conn.MerchantID)
+	if err != nil {
+		t.Fatalf("GetSyncStatuses: %v", err)
+	}
+	for _, status := range statuses {
+		if status.EntityType == models.SyncEntityReceipts {
+			t.Errorf("webhook run was reported as a receipts sync: %+v", status.LastSuccess)
+		}
+	}
+}
+
+func TestInventoryAdjustmentIsPushedAndEchoIsIgnored(t *testing.T) {
+	fake := startFake(t, fakeloyverse.DefaultSeed())
+	db := openTestDB(t)
+	conn := saveConnection(t, db, fakeConnection(fake, models.DefaultMerchantID))
+	receiver := httptest.NewServer(handlers.LoyverseWebhookHandler(db, handlers.ConnectionWebhookSecrets(db, "")))
+	defer receiver.Close()
+	fake.WebhookURL = receiver.URL
+	fake.WebhookSecret = conn.WebhookSecret
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go background.RunWebhookWorker(ctx, db)
+	go background.RunOutboundWorker(ctx, db, services.NewLoyverseClient)
+
+	received := 5.0
+	_, err := services.EnqueueInventoryAdjustments(db, conn.MerchantID, []models.InventoryAdjustment{
+		{VariantID: "variant-1", StoreID: "store-1", Adjustment: &received},
+	}, "รับของเข้า")
+	if err != nil {
+		t.Fatalf("EnqueueInventoryAdjustments: %v", err)
+	}
+
+	eventually(t, "adjustment to be sent", func() bool {
+		return countRows(t, db, "SELECT COUNT(*) FROM outbound_changes WHERE status = $1", models.OutboundStatusSent) == 1
+	})
+	if level, _ := fake.Get("inventory", "variant-1:store-1"); level["in_stock"] != 15.0 {
+		t.Errorf("fake in_stock = %v, want 15", level["in_stock"])
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM loyinventorylevels WHERE variant_id = 'variant-1' AND store_id = 'store-1' AND in_stock = 15"); got != 1 {
+		t.Errorf("local stock was not updated after push")
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM loyinventory_history WHERE source = $1 AND in_stock = 15", models.InventoryHistorySourcePush); got != 1 {
+		t.Errorf("pushed stock was not recorded in inventory history")
+	}
+
+	// webhook ที่ Loyverse ส่งกลับมาต้องถูกประมวลผลโดยไม่สร้างการเปลี่ยนแปลงใหม่
+	fake.WaitWebhooks()
+	eventually(t, "echo webhook to be processed", func() bool {
+		return countRows(t, db, "SELECT COUNT(*) FROM webhook_events WHERE status = $1", models.WebhookStatusProcessed) == 1
+	})
+	if got := countRows(t, db, "SELECT COUNT(*) FROM outbound_changes"); got != 1 {
+		t.Errorf("outbound changes = %d, want 1", got)
+	}
+}
+
+func TestRetriedInventoryAdjustmentResendsFrozenStock(t *testing.T) {
+	fake := startFake(t, fakeloyverse.DefaultSeed())
+	db := openTestDB(t)
+	conn := saveConnection(t, db, fakeConnection(fake, models.DefaultMerchantID))
+	ctx := context.Background()
+	client := services.NewLoyverseClient(conn)
+	client.HTTP.MaxRetries = 0
+
+	received := 5.0
+	if _, err := services.EnqueueInventoryAdjustments(db, conn.MerchantID, []models.InventoryAdjustment{
+		{VariantID: "variant-1", StoreID: "store-1", Adjustment: &received},
+	}, "รับของเข้า"); err != nil {
+		t.Fatalf("EnqueueInventoryAdjustments: %v", err)
+	}
+
+	// Loyverse บันทึกค่าแล้วแต่ response หายระหว่างทาง การส่งครั้งถัดไปต้องไม่บวก 5 ซ้ำ
+	fake.Inject("inventory", fakeloyverse.Pass, fakeloyverse.Fault{Status: http.StatusBadGateway, AfterWrite: true})
+	for attempt := 1; attempt <= 2; attempt++ {
+		changes, err := repository.ClaimDueOutboundChanges(db, 10, 0)
+		if err != nil || len(changes) != 1 {
+			t.Fatalf("attempt %d: claimed %d changes: %v", attempt, len(changes), err)
+		}
+		_, _, err = services.PushOutboundChange(ctx, db, client, changes[0])
+		if attempt == 1 && err == nil {
+			t.Fatalf("first push succeeded, want the injected 502")
+		}
+		if attempt == 2 && err != nil {
+			t.Fatalf("second push: %v", err)
+		}
+	}
+
+	if level, _ := fake.Get("inventory", "variant-1:store-1"); level["in_stock"] != 15.0 {
+		t.Errorf("fake in_stock = %v, want 15", level["in_stock"])
+	}
+	if got := fake.Requests("inventory"); got != 3 {
+		t.Errorf("inventory requests = %d, want 3 (one read and two posts)", got)
+	}
+}
+
+func TestInventoryHistoryKeepsEverySnapshot(t *testing.T) {
+	fake := startFake(t, fakeloyverse.DefaultSeed())
+	db := openTestDB(t)
+	conn := saveConnection(t, db, fakeConnection(fake, models.DefaultMerchantID))
+	ctx := context.Background()
+
+	if err := handlers.SyncInventoryLevels(ctx, db, conn, models.SyncTriggerManual); err != nil {
+		t.Fatalf("first SyncInventoryLevels: %v", err)
+	}
+	later := fakeloyverse.SeedTime.Add(48 * time.Hour)
+	if err := fake.Upsert("inventory", models.LoyInventoryLevel{VariantID: "variant-1", StoreID: "store-1", InStock: 3, UpdatedAt: later}); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	if err := handlers.SyncInventoryLevels(ctx, db, conn, models.SyncTriggerManual); err != nil {
+		t.Fatalf("second SyncInventoryLevels: %v", err)
+	}
+
+	payload := `{"type": "inventory_levels.update", "inventory_levels": [{"variant_id": "variant-1", "store_id": "store-1", "in_stock": 1, "updated_at": "2024-01-04T09:00:00Z"}]}`
+	if _, err := services.ProcessWebhookPayload(db, conn.MerchantID, []byte(payload)); err != nil {
+		t.Fatalf("ProcessWebhookPayload: %v", err)
+	}
+
+	// ตารางค่าล่าสุดถูกล้างทุกครั้งที่ sync แต่ประวัติต้องสะสมทุก snapshot
+	if got := countRows(t, db, "SELECT COUNT(*) FROM loyinventorylevels"); got != 10 {
+		t.Errorf("inventory levels = %d, want 10", got)
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM loyinventory_history WHERE source = $1", models.InventoryHistorySourceSync); got != 20 {
+		t.Errorf("sync snapshots = %d, want 20", got)
+	}
+	var stocks []float64
+	rows, err := db.Query("SELECT in_stock FROM loyinventory_history WHERE variant_id = 'variant-1' AND store_id = 'store-1' ORDER BY id")
+	if err != nil {
+		t.Fatalf("query history: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var stock float64
+		if err := rows.Scan(&stock); err != nil {
+			t.Fatalf("scan history: %v", err)
+		}
+		stocks = append(stocks, stock)
+	}
+	if len(stocks) != 3 || stocks[1] != 3 || stocks[2] != 1 {
+		t.Errorf("variant-1 store-1 history = %v, want [seed 3 1]", stocks)
+	}
+}
+
+func TestMasterDataSyncIsScopedPerMerchant(t *testing.T) {
+	shop := startFake(t, fakeloyverse.DefaultSeed())
+	empty := startFake(t, fakeloyverse.Seed{})
+	db := openTestDB(t)
+	shopConn := saveConnection(t, db, fakeConnection(shop, "shop"))
+	emptyConn := saveConnection(t, db, fakeConnection(empty, "empty"))
+	ctx := context.Background()
+
+	if err := handlers.SyncMasterData(ctx, db, shopConn, models.SyncTriggerManual); err != nil {
+		t.Fatalf("SyncMasterData shop: %v", err)
+	}
+	// บัญชีที่ไม่มีข้อมูลต้องไม่ soft-delete ข้อมูลของบัญชีอื่น
+	if err := handlers.SyncMasterData(ctx, db, emptyConn, models.SyncTriggerManual); err != nil {
+		t.Fatalf("SyncMasterData empty: %v", err)
+	}
+
+	if got := countRows(t, db, "SELECT COUNT(*) FROM loyitems WHERE merchant_id = 'shop' AND deleted_at IS NULL"); got != 5 {
+		t.Errorf("active shop items = %d, want 5", got)
+	}
+	runs, err := repository.ListSyncRuns(db, emptyConn.MerchantID, models.SyncEntityMasterData, 10)
+	if err != nil {
+		t.Fatalf("ListSyncRuns: %v", err)
+	}
+	if len(runs) != 1 || runs[0].RowsDeleted != 0 {
+		t.Errorf("empty merchant runs = %+v, want one run without deletes", runs)
+	}
+}
+
+func TestWebhookEventsAreScopedPerMerchant(t *testing.T) {
+	db := openTestDB(t)
+	body := []byte(`{"type": "receipts.update", "receipts": []}`)
+
+	// body เดียวกันของสองบัญชีได้ delivery id เดียวกันเมื่อไม่มี header แต่ต้องถูกเก็บแยกกัน
+	for _, merchantID := range []string{"shop-a", "shop-b"} {
+		inserted, err := repository.EnqueueWebhookEvent(db, merchantID, "same-delivery", "receipts.update", body)
+		if err != nil || !inserted {
+			t.Fatalf("EnqueueWebhookEvent %s = %v, %v, want inserted", merchantID, inserted, err)
+		}
+	}
+
+	event, err := repository.GetWebhookEvent(db, "shop-a", "same-delivery")
+	if err != nil || event.MerchantID != "shop-a" {
+		t.Fatalf("GetWebhookEvent shop-a = %+v, %v", event, err)
+	}
+	if _, err := repository.GetWebhookEvent(db, "shop-c", "same-delivery"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetWebhookEvent of another merchant error = %v, want sql.ErrNoRows", err)
+	}
+
+	w := httptest.NewRecorder()
+	handlers.ReplayWebhookEventHandler(db)(w, httptest.NewRequest(http.MethodPost, "/api/webhook-events/replay?merchant_id=shop-c&delivery_id=same-delivery", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("replay of another merchant's event status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestReceiptChildBackfillRunsOnlyOnce(t *testing.T) {
+	db := openTestDB(t)
+	if got := countRows(t, db, "SELECT COUNT(*) FROM schema_migrations WHERE name = 'backfill_receipt_children'"); got != 1 {
+		t.Fatalf("backfill migration recorded %d times, want 1", got)
+	}
+
+	// ใบเสร็จที่มีแค่ JSONB หลังจาก backfill รันไปแล้ว ต้องไม่ถูก scan อีกเมื่อ service เริ่มใหม่
+	if _, err := db.Exec(`INSERT INTO loyreceipts (receipt_number, receipt_date, store_id, line_items, payments)
+		VALUES ('legacy-1', NOW(), 'store-1', '[{"id": "line-1", "item_name": "legacy", "quantity": 1}]', '[]')`); err != nil {
+		t.Fatalf("insert legacy receipt: %v", err)
+	}
+	if err := repository.EnsureSchema(db); err != nil {
+		t.Fatalf("EnsureSchema: %v", err)
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM receipt_line_items WHERE receipt_number = 'legacy-1'"); got != 0 {
+		t.Errorf("backfill ran again on restart and added %d line items", got)
+	}
+}
+
+func TestListHandlersClampLimit(t *testing.T) {
+	db := openTestDB(t)
+	if _, err := db.Exec(`INSERT INTO sync_runs (merchant_id, entity_type, trigger, status)
+		SELECT 'default', 'receipts', 'manual', 'success' FROM generate_series(1, $1)`, handlers.MaxListLimit+100); err != nil {
+		t.Fatalf("insert sync runs: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	handlers.ListSyncRunsHandler(db)(w, httptest.NewRequest(http.MethodGet, "/api/sync/runs?limit=100000", nil))
+	var runs []models.SyncRun
+	if err := json.NewDecoder(w.Body).Decode(&runs); err != nil {
+		t.Fatalf("decode sync runs (status %d): %v", w.Code, err)
+	}
+	if len(runs) != handlers.MaxListLimit {
+		t.Errorf("sync runs returned = %d, want the %d row cap", len(runs), handlers.MaxListLimit)
+	}
+}
@@ -0,0 +1,244 @@
+package fakeloyverse
+
+import (
+	"backend/external/loyverse/models"
+	"backend/pkg/money"
+	"fmt"
+	"time"
+)
+
+// Seed คือข้อมูลเริ่มต้นของ server ปลอม
+type Seed struct {
+	Categories      []models.LoyCategory
+	Items           []models.LoyItem
+	Stores          []models.LoyStore
+	Suppliers       []models.LoySupplier
+	PaymentTypes    []models.LoyPaymentType
+	Taxes           []models.LoyTax
+	Discounts       []models.LoyDiscount
+	Modifiers       []models.LoyModifier
+	Customers       []models.LoyCustomer
+	Employees       []models.LoyEmployee
+	PosDevices      []models.LoyPosDevice
+	Shifts          []models.LoyShift
+	InventoryLevels []models.LoyInventoryLevel
+	Receipts        []models.LoyReceipt
+}
+
+func (seed Seed) load(s *Server) {
+	add := func(resource string, value interface{}) {
+		obj, err := toObject(value)
+		if err != nil {
+			panic(err)
+		}
+		s.upsert(resource, obj)
+	}
+	for _, v := range seed.Categories {
+		add("categories", v)
+	}
+	for _, v := range seed.Items {
+		add("items", v)
+		for _, variant := range v.Variants {
+			add("variants", variant)
+		}
+	}
+	for _, v := range seed.Stores {
+		add("stores", v)
+	}
+	for _, v := range seed.Suppliers {
+		add("suppliers", object{"id": v.SupplierID, "name": v.SupplierName})
+	}
+	for _, v := range seed.PaymentTypes {
+		add("payment_types", v)
+	}
+	for _, v := range seed.Taxes {
+		add("taxes", v)
+	}
+	for _, v := range seed.Discounts {
+		add("discounts", v)
+	}
+	for _, v := range seed.Modifiers {
+		add("modifiers", v)
+	}
+	for _, v := range seed.Customers {
+		add("customers", v)
+	}
+	for _, v := range seed.Employees {
+		add("employees", v)
+	}
+	for _, v := range seed.PosDevices {
+		add("pos_devices", v)
+	}
+	for _, v := range seed.Shifts {
+		add("shifts", v)
+	}
+	for _, v := range seed.InventoryLevels {
+		add("inventory", v)
+	}
+	for _, v := range seed.Receipts {
+		add("receipts", v)
+	}
+}
+
+// SeedTime คือเวลาอ้างอิงของ DefaultSeed ใบเสร็จใบที่ n ถูกสร้างหลังเวลานี้ n ชั่วโมง
+var SeedTime = time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+// DefaultSeed สร้างข้อมูลตัวอย่าง: 2 สาขา, 2 หมวดหมู่, 1 ซัพพลายเออร์, 2 ประเภทการชำระเงิน,
+// ภาษี ส่วนลด และ modifier อย่างละ 1, 5 สินค้า (สินค้าละ 1 variant), สต็อกของทุก variant ในทุกสาขา,
+// ลูกค้า 2 คน, พนักงาน 2 คน, เครื่อง POS สาขาละเครื่อง, ใบเสร็จ 12 ใบ (ทุกใบมี VAT รวมในราคา ใบที่ 4, 8, 12 ใช้ส่วนลดสมาชิก
+// ใบคี่ขายโดย employee-1 ใบคู่โดย employee-2) และกะที่ปิดแล้วสาขาละ 1 กะ (เงินสดสาขาสองขาด 5 บาท)
+func DefaultSeed() Seed {
+	categoryID := "cat-1"
+	memberPercent := 10.0
+	seed := Seed{
+		Categories: []models.LoyCategory{
+			{CategoryID: "cat-1", Name: "เครื่องดื่ม", CreatedAt: SeedTime.Format(time.RFC3339)},
+			{CategoryID: "cat-2", Name: "ขนม", CreatedAt: SeedTime.Format(time.RFC3339)},
+		},
+		Stores: []models.LoyStore{
+			{StoreID: "store-1", StoreName: "สาขาหนึ่ง"},
+			{StoreID: "store-2", StoreName: "สาขาสอง"},
+		},
+		Suppliers: []models.LoySupplier{
+			{SupplierID: "supplier-1", SupplierName: "ซัพพลายเออร์หนึ่ง"},
+		},
+		PaymentTypes: []models.LoyPaymentType{
+			{PaymentTypeID: "payment-cash", Name: "เงินสด", Type: "CASH"},
+			{PaymentTypeID: "payment-card", Name: "บัตร", Type: "NONCASH"},
+		},
+		Taxes: []models.LoyTax{
+			{TaxID: "tax-vat", Type: "INCLUDED", Name: "VAT", Rate: 7, Stores: []string{"store-1", "store-2"}},
+		},
+		Discounts: []models.LoyDiscount{
+			{DiscountID: "discount-member", Type: "FIXED_PERCENT", Name: "ส่วนลดสมาชิก", DiscountPercent: &memberPercent, Stores: []string{"store-1", "store-2"}},
+		},
+		Modifiers: []models.LoyModifier{{
+			ModifierID: "modifier-sweet", Name: "ความหวาน", Stores: []string{"store-1", "store-2"},
+			ModifierOptions: []models.ModifierOption{
+				{ModifierOptionID: "sweet-less", Name: "หวานน้อย", Position: 1},
+				{ModifierOptionID: "sweet-more", Name: "หวานมาก", Price: money.FromInt(5), Position: 2},
+			},
+		}},
+		Customers: []models.LoyCustomer{
+			{CustomerID: "customer-1", Name: "ลูกค้าหนึ่ง", Email: "one@example.com", TotalVisits: 3, TotalSpent: money.FromInt(300), CreatedAt: SeedTime, UpdatedAt: SeedTime},
+			{CustomerID: "customer-2", Name: "ลูกค้าสอง", PhoneNumber: "0800000000", TotalVisits: 1, TotalSpent: money.FromInt(50), CreatedAt: SeedTime, UpdatedAt: SeedTime},
+		},
+		Employees: []models.LoyEmployee{
+			{EmployeeID: "employee-1", Name: "แคชเชียร์หนึ่ง", Stores: []string{"store-1", "store-2"}, IsOwner: true},
+			{EmployeeID: "employee-2", Name: "แคชเชียร์สอง", Stores: []string{"store-1", "store-2"}},
+		},
+		PosDevices: []models.LoyPosDevice{
+			{PosDeviceID: "pos-store-1", Name: "POS 1", StoreID: "store-1", Activated: true},
+			{PosDeviceID: "pos-store-2", Name: "POS 2", StoreID: "store-2", Activated: true},
+		},
+	}
+
+	for i := 1; i <= 5; i++ {
+		price := money.FromInt(int64(20 * i))
+		itemID := fmt.Sprintf("item-%d", i)
+		variantID := fmt.Sprintf("variant-%d", i)
+		seed.Items = append(seed.Items, models.LoyItem{
+			ID:                itemID,
+			ItemName:          fmt.Sprintf("สินค้า %d", i),
+			CategoryID:        &categoryID,
+			PrimarySupplierID: "supplier-1",
+			Variants: []models.Variant{{
+				VariantID: variantID, ItemID: itemID, SKU: fmt.Sprintf("SKU-%d", i), Cost: price.Div(2), PurchaseCost: price.Div(2),
+				DefaultPricingType: "FIXED", DefaultPrice: &price, Stores: variantStores(seed.Stores, price),
+			}},
+			CreatedAt: SeedTime,
+			UpdatedAt: SeedTime,
+		})
+		for _, store := range seed.Stores {
+			seed.InventoryLevels = append(seed.InventoryLevels, models.LoyInventoryLevel{
+				VariantID: variantID, StoreID: store.StoreID, InStock: float64(10 * i), UpdatedAt: SeedTime,
+			})
+		}
+	}
+
+	for i := 1; i <= 12; i++ {
+		item := seed.Items[(i-1)%len(seed.Items)]
+		variant := item.Variants[0]
+		at := SeedTime.Add(time.Duration(i) * time.Hour)
+		total := variant.DefaultPrice.MulQuantity(2)
+		customerID := ""
+		if i%3 == 0 {
+			customerID = "customer-1"
+		}
+		vat := models.LineTax{TaxID: "tax-vat", Type: "INCLUDED", Name: "VAT", Rate: 7, MoneyAmount: total.MulQuantity(7).Div(107)}
+		var discounts []models.LineDiscount
+		if i%4 == 0 {
+			discounts = append(discounts, models.LineDiscount{
+				DiscountID: "discount-member", Type: "FIXED_PERCENT", Name: "ส่วนลดสมาชิก", Percentage: &memberPercent, MoneyAmount: total.Div(10),
+			})
+		}
+		employeeID := "employee-1"
+		if i%2 == 0 {
+			employeeID = "employee-2"
+		}
+		storeID := seed.Stores[i%len(seed.Stores)].StoreID
+		seed.Receipts = append(seed.Receipts, models.LoyReceipt{
+			ReceiptNumber: fmt.Sprintf("1-%04d", i),
+			ReceiptType:   models.ReceiptTypeSale,
+			CreatedAt:     at,
+			ReceiptDate:   at,
+			UpdatedAt:     at,
+			Source:        "point of sale",
+			TotalMoney:    total,
+			CustomerID:    customerID,
+			StoreID:       storeID,
+			PosDeviceId:   "pos-" + storeID,
+			EmployeeID:    employeeID,
+			LineItems: []models.LineItem{{
+				ID: fmt.Sprintf("line-%d", i), ItemID: item.ID, VariantID: variant.VariantID, ItemName: item.ItemName,
+				Quantity: 2, Price: *variant.DefaultPrice, GrossTotalMoney: total, TotalMoney: total,
+				Cost: variant.Cost, CostTotal: variant.Cost.MulQuantity(2),
+				LineTaxes: []models.LineTax{vat}, LineDiscounts: discounts,
+			}},
+			Payments: []models.Payment{{PaymentTypeID: "payment-cash", MoneyAmount: total, Name: "เงินสด", Type: "CASH"}},
+		})
+	}
+
+	// กะละสาขาครอบคลุมใบเสร็จทั้ง 12 ใบ (ทุกใบจ่ายเงินสด)
+	for _, store := range seed.Stores {
+		var cashPayments money.Money
+		for _, receipt := range seed.Receipts {
+			if receipt.StoreID == store.StoreID {
+				cashPayments = cashPayments.Add(receipt.TotalMoney)
+			}
+		}
+		openedAt := SeedTime
+		closedAt := SeedTime.Add(13 * time.Hour)
+		opener, closer := "employee-1", "employee-2"
+		startingCash, paidOut := money.FromInt(500), money.Money{}
+		var movements []models.CashMovement
+		if store.StoreID == "store-1" {
+			paidOut = money.FromInt(50)
+			movements = append(movements, models.CashMovement{
+				Type: models.CashMovementPayOut, MoneyAmount: paidOut, EmployeeID: &closer, CreatedAt: SeedTime.Add(6 * time.Hour),
+			})
+		}
+		expected := startingCash.Add(cashPayments).Sub(paidOut)
+		actual := expected
+		if store.StoreID == "store-2" {
+			actual = actual.Sub(money.FromInt(5))
+		}
+		seed.Shifts = append(seed.Shifts, models.LoyShift{
+			ShiftID: "shift-" + store.StoreID, StoreID: store.StoreID, PosDeviceID: "pos-" + store.StoreID,
+			OpenedAt: openedAt, ClosedAt: &closedAt, OpenedByEmployee: &opener, ClosedByEmployee: &closer,
+			StartingCash: startingCash, CashPayments: cashPayments, PaidOut: paidOut, ExpectedCash: expected, ActualCash: &actual,
+			GrossSales: cashPayments, NetSales: cashPayments, CashMovements: movements, CreatedAt: openedAt, UpdatedAt: closedAt,
+		})
+	}
+	return seed
+}
+
+// variantStores ตั้งราคาเดียวกันทุกสาขาและเปิดขายทุกสาขา
+func variantStores(stores []models.LoyStore, price money.Money) []models.VariantStore {
+	var result []models.VariantStore
+	for _, store := range stores {
+		storePrice := price
+		result = append(result, models.VariantStore{StoreID: store.StoreID, PricingType: "FIXED", Price: &storePrice, AvailableForSale: true})
+	}
+	return result
+}
@@ -0,0 +1,494 @@
+// Package fakeloyverse เป็น Loyverse API ปลอมที่ทำงานใน process เดียวกัน
+// ใช้สำหรับ integration tests และการพัฒนาแบบ offline โดยไม่ต้องมี token จริง
+//
+//	fake := fakeloyverse.NewTestServer(fakeloyverse.DefaultSeed())
+//	defer fake.Close()
+//	client := api.NewClient(fakeloyverse.Token)
+//	client.BaseURL = fake.BaseURL()
+//
+// รองรับ cursor pagination, ตัวกรองเดียวกับ API จริง (updated_at_min, store_id, show_deleted ฯลฯ),
+// การจำลองความผิดพลาด (429, 500, JSON เสีย) และการส่ง webhook ที่ลงลายเซ็นแล้ว
+package fakeloyverse
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Token คือ token ที่ server ปลอมยอมรับเมื่อไม่ได้ตั้ง Server.Token เป็นค่าอื่น
+const Token = "fake-loyverse-token"
+
+// PathPrefix คือ prefix ของทุก endpoint เหมือน API จริง
+const PathPrefix = "/v1.0"
+
+// defaultLimit คือจำนวนรายการต่อหน้าเมื่อ request ไม่ได้ส่ง limit มา (เท่ากับ API จริง)
+const defaultLimit = 50
+
+// Fault คือความผิดพลาดที่จะตอบกลับแทน response ปกติหนึ่งครั้ง
+type Fault struct {
+	Status     int           // status code ที่ตอบกลับ เช่น 429 หรือ 500
+	RetryAfter time.Duration // ถ้ามีค่าจะส่ง header Retry-After (ปัดเป็นวินาที)
+	Malformed  bool          // ตอบ 200 พร้อม JSON ที่ parse ไม่ได้
+	AfterWrite bool          // บันทึก POST ตามปกติก่อนแล้วจึงตอบด้วย Status (จำลอง response ที่หายระหว่างทาง)
+}
+
+// Pass คือ fault ว่างที่ปล่อย request ผ่านไปตามปกติ ใช้เลื่อน fault ตัวถัดไปให้เกิดกับ request หลังๆ
+var Pass = Fault{}
+
+// Server คือ Loyverse API ปลอม ใช้เป็น http.Handler โดยตรงหรือผ่าน NewTestServer ก็ได้
+// ข้อมูลทั้งหมดเก็บเป็น JSON object ตามรูปแบบของ API จริง
+type Server struct {
+	Token         string // ถ้าว่างจะไม่ตรวจ Authorization
+	PageSize      int    // จำนวนรายการสูงสุดต่อหน้า ใช้บังคับให้เกิดหลายหน้าใน test (0 = ตาม limit)
+	WebhookURL    string // ถ้ามีค่า การเขียนผ่าน POST จะส่ง webhook ไปยัง URL นี้
+	WebhookSecret string // secret สำหรับลงลายเซ็น webhook
+
+	mu       sync.Mutex
+	data     map[string][]object
+	faults   map[string][]Fault
+	requests map[string]int
+	sequence int
+
+	webhooks sync.WaitGroup
+	test     *httptest.Server
+}
+
+type object = map[string]interface{}
+
+// resourceKeys คือฟิลด์ที่ใช้ระบุตัวตนของแต่ละรายการในแต่ละ resource
+var resourceKeys = map[string][]string{
+	"categories":    {"id"},
+	"items":         {"id"},
+	"variants":      {"variant_id"},
+	"stores":        {"id"},
+	"suppliers":     {"id"},
+	"payment_types": {"id"},
+	"taxes":         {"id"},
+	"discounts":     {"id"},
+	"modifiers":     {"id"},
+	"customers":     {"id"},
+	"employees":     {"id"},
+	"pos_devices":   {"id"},
+	"shifts":        {"id"},
+	"inventory":     {"variant_id", "store_id"},
+	"receipts":      {"receipt_number"},
+}
+
+// listKeys คือชื่อ field ของ array ใน response ที่ไม่ตรงกับชื่อ resource
+var listKeys = map[string]string{
+	"inventory": "inventory_levels",
+}
+
+// New สร้าง Server จาก seed โดยยังไม่เปิด port
+func New(seed Seed) *Server {
+	s := &Server{
+		Token:    Token,
+		data:     make(map[string][]object),
+		faults:   make(map[string][]Fault),
+		requests: make(map[string]int),
+	}
+	seed.load(s)
+	return s
+}
+
+// NewTestServer สร้าง Server และเปิดด้วย httptest.Server
+func NewTestServer(seed Seed) *Server {
+	s := New(seed)
+	s.test = httptest.NewServer(s)
+	return s
+}
+
+// BaseURL คือ URL ที่ใช้แทน api.DefaultBaseURL (ใช้ได้เฉพาะ server ที่สร้างด้วย NewTestServer)
+func (s *Server) BaseURL() string {
+	return s.test.URL + PathPrefix
+}
+
+// Close รอ webhook ที่ค้างอยู่แล้วปิด httptest.Server
+func (s *Server) Close() {
+	s.WaitWebhooks()
+	if s.test != nil {
+		s.test.Close()
+	}
+}
+
+// Inject ตั้งค่าให้ request ถัดไปของ resource (เช่น "items") ตอบกลับด้วย faults ตามลำดับ
+func (s *Server) Inject(resource string, faults ...Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[resource] = append(s.faults[resource], faults...)
+}
+
+// Requests คืนค่าจำนวน request ที่ได้รับสำหรับ resource นั้น รวม request ที่ตอบด้วย fault
+func (s *Server) Requests(resource string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[resource]
+}
+
+// Upsert เพิ่มหรือแทนที่รายการใน resource ค่าที่ส่งเข้ามาต้อง marshal เป็น JSON object ได้
+// (เช่น models.LoyItem หรือ map)
+func (s *Server) Upsert(resource string, values ...interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, value := range values {
+		obj, err := toObject(value)
+		if err != nil {
+			return err
+		}
+		s.upsert(resource, obj)
+	}
+	return nil
+}
+
+// Delete ทำ soft-delete รายการด้วย id เหมือน API จริง (ตั้ง deleted_at)
+func (s *Server) Delete(resource, id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, obj := range s.data[resource] {
+		if key(resource, obj) == id {
+			now := timestamp(time.Now())
+			obj["deleted_at"] = now
+			obj["updated_at"] = now
+			return true
+		}
+	}
+	return false
+}
+
+// Get คืนค่าสำเนาของรายการใน resource ตาม key (สำหรับตรวจผลใน test)
+func (s *Server) Get(resource, id string) (map[string]interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, obj := range s.data[resource] {
+		if key(resource, obj) == id {
+			return clone(obj), true
+		}
+	}
+	return nil, false
+}
+
+func (s *Server) upsert(resource string, obj object) {
+	id := key(resource, obj)
+	for i, existing := range s.data[resource] {
+		if key(resource, existing) == id {
+			s.data[resource][i] = obj
+			return
+		}
+	}
+	s.data[resource] = append(s.data[resource], obj)
+}
+
+// ServeHTTP จัดการ request ในรูปแบบเดียวกับ Loyverse API v1.0
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, PathPrefix), "/")
+	parts := strings.SplitN(path, "/", 2)
+	resource := parts[0]
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests[resource]++
+
+	if s.Token != "" && r.Header.Get("Authorization") != "Bearer "+s.Token {
+		writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid access token")
+		return
+	}
+	if _, ok := resourceKeys[resource]; !ok {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "Unknown resource "+resource)
+		return
+	}
+	if s.injectFault(w, r, resource) {
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && len(parts) == 1:
+		s.list(w, r, resource)
+	case r.Method == http.MethodGet:
+		s.get(w, resource, parts[1])
+	case r.Method == http.MethodPost && len(parts) == 1:
+		s.post(w, r, resource)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", r.Method+" is not supported")
+	}
+}
+
+// injectFault ตอบกลับด้วย fault ตัวแรกในคิวของ resource ถ้ามี
+func (s *Server) injectFault(w http.ResponseWriter, r *http.Request, resource string) bool {
+	queue := s.faults[resource]
+	if len(queue) == 0 {
+		return false
+	}
+	fault := queue[0]
+	s.faults[resource] = queue[1:]
+	if fault == Pass {
+		return false
+	}
+
+	if fault.Malformed {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"` + listKey(resource) + `": [{"id": `))
+		return true
+	}
+	if fault.AfterWrite && r.Method == http.MethodPost {
+		s.post(httptest.NewRecorder(), r, resource)
+	}
+	if fault.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int((fault.RetryAfter+time.Second-1)/time.Second)))
+	}
+	writeError(w, fault.Status, "INJECTED_FAULT", http.StatusText(fault.Status))
+	return true
+}
+
+func (s *Server) list(w http.ResponseWriter, r *http.Request, resource string) {
+	query := r.URL.Query()
+	filter, err := newFilter(query)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_VALUE", err.Error())
+		return
+	}
+
+	limit := defaultLimit
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > 250 {
+			writeError(w, http.StatusBadRequest, "INVALID_VALUE", "limit must be between 1 and 250")
+			return
+		}
+	}
+	if s.PageSize > 0 && limit > s.PageSize {
+		limit = s.PageSize
+	}
+
+	offset := 0
+	if cursor := query.Get("cursor"); cursor != "" {
+		offset, err = decodeCursor(cursor)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "INVALID_CURSOR", "Invalid cursor")
+			return
+		}
+	}
+
+	var matched []object
+	for _, obj := range s.data[resource] {
+		if filter.match(obj) {
+			matched = append(matched, obj)
+		}
+	}
+
+	page := []object{}
+	if offset < len(matched) {
+		end := offset + limit
+		if end > len(matched) {
+			end = len(matched)
+		}
+		page = matched[offset:end]
+	}
+
+	response := object{listKey(resource): page}
+	if offset+limit < len(matched) {
+		response["cursor"] = encodeCursor(offset + limit)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) get(w http.ResponseWriter, resource, id string) {
+	for _, obj := range s.data[resource] {
+		if key(resource, obj) == id {
+			writeJSON(w, http.StatusOK, obj)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "NOT_FOUND", fmt.Sprintf("%s %s not found", resource, id))
+}
+
+func (s *Server) post(w http.ResponseWriter, r *http.Request, resource string) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+	now := timestamp(time.Now())
+
+	if resource == "inventory" {
+		var request struct {
+			InventoryLevels []struct {
+				VariantID  string  `json:"variant_id"`
+				StoreID    string  `json:"store_id"`
+				StockAfter float64 `json:"stock_after"`
+			} `json:"inventory_levels"`
+		}
+		if err := json.Unmarshal(body, &request); err != nil || len(request.InventoryLevels) == 0 {
+			writeError(w, http.StatusBadRequest, "BAD_REQUEST", "inventory_levels is required")
+			return
+		}
+		levels := []object{}
+		for _, level := range request.InventoryLevels {
+			obj := object{"variant_id": level.VariantID, "store_id": level.StoreID, "in_stock": level.StockAfter, "updated_at": now}
+			s.upsert(resource, obj)
+			levels = append(levels, obj)
+		}
+		s.sendWebhookAsync("inventory_levels.update", levels)
+		writeJSON(w, http.StatusOK, object{"inventory_levels": levels})
+		return
+	}
+
+	var obj object
+	if err := json.Unmarshal(body, &obj); err != nil {
+		writeError(w, http.StatusBadRequest, "BAD_REQUEST", "Invalid JSON")
+		return
+	}
+	if id, _ := obj["id"].(string); id == "" {
+		s.sequence++
+		obj["id"] = fmt.Sprintf("fake-%s-%d", resource, s.sequence)
+	}
+	if _, ok := obj["created_at"]; !ok {
+		obj["created_at"] = now
+	}
+	obj["updated_at"] = now
+	s.upsert(resource, obj)
+
+	switch resource {
+	case "items", "customers":
+		s.sendWebhookAsync(resource+".update", []object{obj})
+	}
+	writeJSON(w, http.StatusOK, obj)
+}
+
+// filter คือตัวกรองจาก query parameter ที่ API จริงรองรับ
+type filter struct {
+	showDeleted  bool
+	storeID      string
+	variantIDs   map[string]bool
+	updatedAtMin time.Time
+	updatedAtMax time.Time
+	createdAtMin time.Time
+	createdAtMax time.Time
+}
+
+func newFilter(query map[string][]string) (filter, error) {
+	get := func(name string) string {
+		if values := query[name]; len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	f := filter{showDeleted: get("show_deleted") == "true", storeID: get("store_id")}
+	if ids := get("variant_ids"); ids != "" {
+		f.variantIDs = make(map[string]bool)
+		for _, id := range strings.Split(ids, ",") {
+			f.variantIDs[id] = true
+		}
+	}
+	for name, dest := range map[string]*time.Time{
+		"updated_at_min": &f.updatedAtMin, "updated_at_max": &f.updatedAtMax,
+		"created_at_min": &f.createdAtMin, "created_at_max": &f.createdAtMax,
+	} {
+		if value := get(name); value != "" {
+			t, err := time.Parse(time.RFC3339Nano, value)
+			if err != nil {
+				return f, fmt.Errorf("invalid %s: %v", name, err)
+			}
+			*dest = t
+		}
+	}
+	return f, nil
+}
+
+func (f filter) match(obj object) bool {
+	if !f.showDeleted && obj["deleted_at"] != nil {
+		return false
+	}
+	if storeID, ok := obj["store_id"].(string); ok && f.storeID != "" && storeID != f.storeID {
+		return false
+	}
+	if variantID, ok := obj["variant_id"].(string); ok && f.variantIDs != nil && !f.variantIDs[variantID] {
+		return false
+	}
+	return inRange(obj["updated_at"], f.updatedAtMin, f.updatedAtMax) && inRange(obj["created_at"], f.createdAtMin, f.createdAtMax)
+}
+
+// inRange ตรวจว่าเวลาใน value อยู่ในช่วง [min, max] (ขอบที่เป็นค่า zero ไม่ถูกตรวจ)
+func inRange(value interface{}, min, max time.Time) bool {
+	if min.IsZero() && max.IsZero() {
+		return true
+	}
+	text, _ := value.(string)
+	t, err := time.Parse(time.RFC3339Nano, text)
+	if err != nil {
+		return false
+	}
+	return (min.IsZero() || !t.Before(min)) && (max.IsZero() || !t.After(max))
+}
+
+func key(resource string, obj object) string {
+	fields := resourceKeys[resource]
+	values := make([]string, len(fields))
+	for i, field := range fields {
+		values[i], _ = obj[field].(string)
+	}
+	return strings.Join(values, ":")
+}
+
+func listKey(resource string) string {
+	if name, ok := listKeys[resource]; ok {
+		return name
+	}
+	return resource
+}
+
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(raw), "offset:"))
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	return offset, nil
+}
+
+func timestamp(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+func toObject(value interface{}) (object, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var obj object
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, fmt.Errorf("fakeloyverse: %T is not a JSON object: %w", value, err)
+	}
+	return obj, nil
+}
+
+func clone(obj object) object {
+	copied, _ := toObject(obj)
+	return copied
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeError ตอบกลับด้วยรูปแบบ error ของ Loyverse API
+func writeError(w http.ResponseWriter, status int, code, details string) {
+	writeJSON(w, status, object{"errors": []object{{"code": code, "details": details}}})
+}
@@ -0,0 +1,108 @@
+package fakeloyverse
+
+import (
+	"backend/external/loyverse/handlers"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// MerchantID คือ merchant_id ที่ใส่ใน webhook ทุกครั้ง
+const MerchantID = "fake-merchant"
+
+// SendWebhook ส่ง webhook ประเภท eventType (เช่น "receipts.update") ไปยัง WebhookURL ทันที
+// values คือรายการที่จะใส่ใน payload และ error จะถูกคืนถ้าปลายทางไม่ได้ตอบ 2xx
+func (s *Server) SendWebhook(eventType string, values ...interface{}) error {
+	objects := make([]object, 0, len(values))
+	for _, value := range values {
+		obj, err := toObject(value)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, obj)
+	}
+
+	s.mu.Lock()
+	url, secret, deliveryID := s.WebhookURL, s.WebhookSecret, s.nextDeliveryID()
+	s.mu.Unlock()
+	if url == "" {
+		return fmt.Errorf("fakeloyverse: WebhookURL is not set")
+	}
+	return deliverWebhook(url, secret, deliveryID, eventType, objects)
+}
+
+// WaitWebhooks รอจนกว่า webhook ที่ส่งอัตโนมัติหลัง POST จะถูกส่งครบ
+func (s *Server) WaitWebhooks() {
+	s.webhooks.Wait()
+}
+
+// sendWebhookAsync ส่ง webhook ใน background เหมือน Loyverse ที่ส่ง webhook หลังตอบ request แล้ว
+// ต้องเรียกขณะถือ s.mu อยู่
+func (s *Server) sendWebhookAsync(eventType string, objects []object) {
+	if s.WebhookURL == "" {
+		return
+	}
+	copies := make([]object, len(objects))
+	for i, obj := range objects {
+		copies[i] = clone(obj)
+	}
+	url, secret, deliveryID := s.WebhookURL, s.WebhookSecret, s.nextDeliveryID()
+
+	s.webhooks.Add(1)
+	go func() {
+		defer s.webhooks.Done()
+		if err := deliverWebhook(url, secret, deliveryID, eventType, copies); err != nil {
+			log.Printf("fakeloyverse: delivering %s webhook: %v", eventType, err)
+		}
+	}()
+}
+
+func (s *Server) nextDeliveryID() string {
+	s.sequence++
+	return fmt.Sprintf("fake-delivery-%d", s.sequence)
+}
+
+// deliverWebhook ลงลายเซ็น HMAC-SHA1 (base64) ด้วย secret แล้ว POST ไปยัง url
+func deliverWebhook(url, secret, deliveryID, eventType string, objects []object) error {
+	body, err := json.Marshal(object{
+		"merchant_id":                            MerchantID,
+		"type":                                   eventType,
+		"created_at":                             timestamp(time.Now()),
+		strings.TrimSuffix(eventType, ".update"): objects,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(handlers.WebhookDeliveryIDHeader, deliveryID)
+	req.Header.Set(handlers.WebhookSignatureHeader, Sign(body, secret))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook %s returned %d", deliveryID, resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign คืนค่าลายเซ็นของ body แบบเดียวกับที่ Loyverse ส่งใน X-Loyverse-Signature
+func Sign(body []byte, secret string) string {
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write(body)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
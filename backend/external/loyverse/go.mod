@@ -1,12 +1,15 @@
 module backend/external/loyverse
 
-go 1.21
+go 1.23
 
 toolchain go1.23.2
 
 require (
+	backend/internal/SaleManagement v0.0.0-00010101000000-000000000000
+	backend/pkg/money v0.0.0-00010101000000-000000000000
 	github.com/lib/pq v1.10.9
 	github.com/robfig/cron/v3 v3.0.1
+	github.com/shopspring/decimal v1.4.0 // indirect
 )
 
 require (
@@ -36,3 +39,8 @@ require (
 	google.golang.org/grpc v1.67.1 // indirect
 	google.golang.org/protobuf v1.35.1 // indirect
 )
+
+replace (
+	backend/internal/SaleManagement => ../../internal/SaleManagement
+	backend/pkg/money => ../../pkg/money
+)
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"backend/external/loyverse/api"
+	"backend/external/loyverse/config"
+	"backend/external/loyverse/models"
+	"backend/external/loyverse/repository"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// merchantIDParam อ่าน merchant จาก ?merchant_id (ไม่ระบุ = models.DefaultMerchantID)
+func merchantIDParam(r *http.Request) string {
+	if merchantID := strings.TrimSpace(r.URL.Query().Get("merchant_id")); merchantID != "" {
+		return merchantID
+	}
+	return models.DefaultMerchantID
+}
+
+// requestConnection อ่าน connection ของ merchant ใน request และตอบ error กลับไปเองถ้าไม่พบ
+func requestConnection(w http.ResponseWriter, r *http.Request, db *sql.DB) (models.Connection, bool) {
+	conn, err := repository.GetConnection(db, merchantIDParam(r))
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Unknown merchant_id", http.StatusNotFound)
+		return conn, false
+	}
+	if err != nil {
+		http.Error(w, "Failed to load connection", http.StatusInternalServerError)
+		return conn, false
+	}
+	if !conn.Enabled {
+		http.Error(w, "Connection is disabled", http.StatusConflict)
+		return conn, false
+	}
+	return conn, true
+}
+
+// validateBaseURL ยอมรับ base URL ที่ว่าง (ใช้ api.DefaultBaseURL) หรือ https ไปยัง host ที่อนุญาตเท่านั้น
+// เพราะ token ของ connection ถูกส่งไปกับทุก request ที่ยิงไปยัง URL นี้
+func validateBaseURL(raw string) error {
+	if raw == "" {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("base_url must be an https URL")
+	}
+	defaultURL, _ := url.Parse(api.DefaultBaseURL)
+	for _, host := range append([]string{defaultURL.Host}, config.GetAllowedAPIHosts()...) {
+		if strings.EqualFold(u.Host, host) {
+			return nil
+		}
+	}
+	return fmt.Errorf("base_url host %s is not allowed (see LOYVERSE_ALLOWED_API_HOSTS)", u.Host)
+}
+
+// ConnectionsHandler แสดงรายการ connection (GET) หรือสร้าง/แก้ไข connection (POST)
+// body ของ POST: {"merchant_id": "...", "name": "...", "api_token": "...", "webhook_secret": "...", "enabled": true}
+// field ที่ไม่ได้ส่งมาจะคงค่าเดิม token และ webhook secret จะไม่ถูกส่งกลับใน response
+func ConnectionsHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			connections, err := repository.ListConnections(db, false)
+			if err != nil {
+				http.Error(w, "Failed to list connections", http.StatusInternalServerError)
+				return
+			}
+			for i := range connections {
+				connections[i].APIToken = ""
+				connections[i].WebhookSecret = ""
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(connections)
+
+		case http.MethodPost:
+			var update models.ConnectionUpdate
+			if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+				http.Error(w, "Invalid JSON", http.StatusBadRequest)
+				return
+			}
+			update.MerchantID = strings.TrimSpace(update.MerchantID)
+			if update.MerchantID == "" || strings.Contains(update.MerchantID, "/") {
+				http.Error(w, "merchant_id is required and must not contain '/'", http.StatusBadRequest)
+				return
+			}
+			if update.Name != nil && *update.Name == "" {
+				update.Name = &update.MerchantID
+			}
+			if update.BaseURL != nil {
+				if err := validateBaseURL(*update.BaseURL); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+			}
+			if err := repository.UpdateConnection(db, update); err != nil {
+				http.Error(w, "Failed to save connection", http.StatusInternalServerError)
+				return
+			}
+			log.Printf("Saved Loyverse connection %s", update.MerchantID)
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("Connection saved"))
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// WebhookSecrets คืนค่า webhook secret ของ merchant (ว่าง = ไม่รับ webhook ของ merchant นี้)
+type WebhookSecrets func(merchantID string) (string, error)
+
+// ConnectionWebhookSecrets อ่าน webhook secret จากตาราง loyverse_connections
+// ถ้า connection ของ merchant default ยังไม่มี secret จะใช้ fallback (LOYVERSE_WEBHOOK_SECRET)
+func ConnectionWebhookSecrets(db *sql.DB, fallback string) WebhookSecrets {
+	return func(merchantID string) (string, error) {
+		conn, err := repository.GetConnection(db, merchantID)
+		if errors.Is(err, sql.ErrNoRows) {
+			if merchantID == models.DefaultMerchantID {
+				return fallback, nil
+			}
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if !conn.Enabled {
+			return "", nil
+		}
+		if conn.WebhookSecret == "" && merchantID == models.DefaultMerchantID {
+			return fallback, nil
+		}
+		return conn.WebhookSecret, nil
+	}
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"backend/external/loyverse/models"
+	"backend/external/loyverse/repository"
+	"backend/external/loyverse/services"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// EnqueueInventoryAdjustmentsHandler รับคำขอปรับสต็อกเพื่อส่งไปยัง Loyverse (POST)
+// body: {"reason": "รับของเข้า", "adjustments": [{"variant_id": "...", "store_id": "...", "adjustment": 12}]}
+// ใช้ "stock_after" แทน "adjustment" เมื่อนับสต็อกได้ค่าที่แน่นอน
+func EnqueueInventoryAdjustmentsHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var request struct {
+			Reason      string                       `json:"reason"`
+			Adjustments []models.InventoryAdjustment `json:"adjustments"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		changes, err := services.EnqueueInventoryAdjustments(db, merchantIDParam(r), request.Adjustments, request.Reason)
+		writeOutboundResult(w, changes, err)
+	}
+}
+
+// EnqueueItemUpdateHandler รับคำขอแก้ไขสินค้าเพื่อส่งไปยัง Loyverse (POST)
+// body: {"id": "<item_id>", "changes": {"item_name": "..."}, "reason": "..."}
+func EnqueueItemUpdateHandler(db *sql.DB) http.HandlerFunc {
+	return enqueueResourceUpdateHandler(db, services.EnqueueItemUpdate)
+}
+
+// EnqueueSupplierUpdateHandler รับคำขอแก้ไขซัพพลายเออร์เพื่อส่งไปยัง Loyverse (POST)
+// body: {"id": "<supplier_id>", "changes": {"name": "..."}, "reason": "..."}
+func EnqueueSupplierUpdateHandler(db *sql.DB) http.HandlerFunc {
+	return enqueueResourceUpdateHandler(db, services.EnqueueSupplierUpdate)
+}
+
+func enqueueResourceUpdateHandler(db *sql.DB, enqueue func(*sql.DB, string, models.ResourceUpdate) (models.OutboundChange, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var update models.ResourceUpdate
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		change, err := enqueue(db, merchantIDParam(r), update)
+		writeOutboundResult(w, change, err)
+	}
+}
+
+// writeOutboundResult ตอบกลับด้วย 202 Accepted และรายการที่เข้าคิว หรือ error ที่เหมาะสม
+func writeOutboundResult(w http.ResponseWriter, result interface{}, err error) {
+	if errors.Is(err, services.ErrInvalidOutboundChange) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Println("Error enqueueing outbound change:", err)
+		http.Error(w, "Failed to enqueue change", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(result)
+}
+
+// ListOutboundChangesHandler แสดงคิวและประวัติการส่งข้อมูลกลับไปยัง Loyverse ของ merchant (?merchant_id=&status=dead&type=item&limit=100)
+func ListOutboundChangesHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, ok := limitParam(w, r, 100)
+		if !ok {
+			return
+		}
+
+		changes, err := repository.ListOutboundChanges(db, merchantIDParam(r), r.URL.Query().Get("status"), r.URL.Query().Get("type"), limit)
+		if err != nil {
+			http.Error(w, "Failed to list outbound changes", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(changes)
+	}
+}
+
+// RetryOutboundChangeHandler ส่งรายการที่ล้มเหลวกลับเข้าคิวเพื่อส่งใหม่ (POST ?id=)
+func RetryOutboundChangeHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid id parameter", http.StatusBadRequest)
+			return
+		}
+
+		err = repository.RetryOutboundChange(db, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Failed outbound change not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to retry outbound change", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("Outbound change queued for retry"))
+	}
+}
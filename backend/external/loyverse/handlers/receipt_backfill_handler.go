@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"backend/external/loyverse/models"
+	"backend/external/loyverse/repository"
+	"backend/external/loyverse/services"
+	"backend/external/loyverse/utils"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+)
+
+// receiptBackfillPageSize คือจำนวนใบเสร็จต่อหน้าที่ขอจาก API ระหว่าง backfill
+const receiptBackfillPageSize = 250
+
+// BackfillReceipts โหลดใบเสร็จที่สร้างในช่วง [from, to] ของสาขาที่ระบุ (ว่าง = ทุกสาขา) ด้วยตัวกรองวันที่ของ API
+// ใบเสร็จถูก upsert ทีละหน้าโดยไม่ล้างข้อมูลเดิมและไม่เลื่อน watermark ของ sync ปกติ
+// cursor ของหน้าถัดไปถูกบันทึกหลังทุกหน้า ถ้าถูกขัดจังหวะ การเรียกช่วงเดิมอีกครั้งจะทำต่อจากหน้านั้น
+// ความคืบหน้าถูกบันทึกลง sync_runs (entity receipts_backfill) ระหว่างทำงาน
+func BackfillReceipts(ctx context.Context, dbConn *sql.DB, conn models.Connection, from, to time.Time, storeID, trigger string) (*models.ReceiptBackfill, error) {
+	var backfill *models.ReceiptBackfill
+	err := recordSyncRun(dbConn, conn.MerchantID, models.SyncEntityReceiptBackfill, trigger, func(run *models.SyncRun) error {
+		var resumed bool
+		var err error
+		backfill, resumed, err = repository.StartReceiptBackfill(dbConn, conn.MerchantID, storeID, from, to, run.ID)
+		if err != nil {
+			return err
+		}
+		if resumed {
+			log.Printf("Resuming receipt backfill %d after %d pages", backfill.ID, backfill.PagesFetched)
+		}
+
+		backfillErr := backfillReceipts(ctx, dbConn, conn, backfill, run)
+		if err := repository.FinishReceiptBackfill(dbConn, backfill, backfillErr); err != nil {
+			log.Printf("Could not record result of receipt backfill %d: %v", backfill.ID, err)
+		}
+		return backfillErr
+	})
+	return backfill, err
+}
+
+func backfillReceipts(ctx context.Context, dbConn *sql.DB, conn models.Connection, backfill *models.ReceiptBackfill, run *models.SyncRun) error {
+	for {
+		receipts, nextCursor, err := services.FetchReceiptsWindow(ctx, conn, backfill.Cursor, receiptBackfillPageSize, backfill.From, backfill.To, backfill.StoreID)
+		if err != nil {
+			return err
+		}
+		reportPageFetched(ctx, "receipts", backfill.PagesFetched+1, len(receipts))
+		if err := repository.SaveReceipts(dbConn, conn.MerchantID, receipts); err != nil {
+			return err
+		}
+		reportRowsSaved(ctx, "receipts", int64(len(receipts)))
+
+		backfill.Cursor = nextCursor
+		backfill.PagesFetched++
+		backfill.ReceiptsSaved += int64(len(receipts))
+		run.PagesFetched++
+		run.RowsUpserted += int64(len(receipts))
+		if err := repository.SaveReceiptBackfillProgress(dbConn, backfill); err != nil {
+			return err
+		}
+		// ความคืบหน้าใน sync_runs เป็นแค่ข้อมูลแสดงผล ถ้าบันทึกไม่ได้ก็ทำต่อ
+		repository.UpdateSyncRunProgress(dbConn, run)
+		log.Printf("Backfill %d: saved %d receipts (%d total)", backfill.ID, len(receipts), backfill.ReceiptsSaved)
+
+		if nextCursor == "" {
+			return nil
+		}
+	}
+}
+
+// ReceiptBackfillHandler เริ่มหรือทำต่อ backfill ใบเสร็จ (POST ?merchant_id=&from=&to=&store_id=)
+// ตอบ 409 ถ้าช่วงเดียวกันหรือ backfill อื่นของ merchant กำลังทำงานอยู่
+// หรือแสดงรายการ backfill ล่าสุด (GET ?merchant_id=&limit=20)
+// from และ to รับทั้ง RFC3339 และ YYYY-MM-DD (เวลา Asia/Bangkok, to หมายถึงสิ้นวัน)
+func ReceiptBackfillHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			limit, ok := limitParam(w, r, 20)
+			if !ok {
+				return
+			}
+			backfills, err := repository.ListReceiptBackfills(db, merchantIDParam(r), limit)
+			if err != nil {
+				http.Error(w, "Failed to list receipt backfills", http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(backfills)
+
+		case http.MethodPost:
+			loc, err := time.LoadLocation(utils.BusinessTimezone)
+			if err != nil {
+				http.Error(w, "Failed to load timezone", http.StatusInternalServerError)
+				return
+			}
+			query := r.URL.Query()
+			from, to, err := utils.ParseDateRange(query.Get("from"), query.Get("to"), loc)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			conn, ok := requestConnection(w, r, db)
+			if !ok {
+				return
+			}
+
+			backfill, err := BackfillReceipts(r.Context(), db, conn, from, to, query.Get("store_id"), models.SyncTriggerManual)
+			if errors.Is(err, repository.ErrReceiptBackfillRunning) || errors.Is(err, repository.ErrSyncLocked) {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			if backfill == nil {
+				http.Error(w, "Failed to start receipt backfill: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+			// ส่งสถานะกลับแม้ล้มเหลว เพื่อให้เห็น cursor และจำนวนที่โหลดไปแล้ว
+			w.Header().Set("Content-Type", "application/json")
+			if err != nil {
+				w.WriteHeader(http.StatusBadGateway)
+			}
+			json.NewEncoder(w).Encode(backfill)
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
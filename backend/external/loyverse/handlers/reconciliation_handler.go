@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"backend/external/loyverse/models"
+	"backend/external/loyverse/repository"
+	"backend/external/loyverse/services"
+	"backend/external/loyverse/utils"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ReconciliationDays คือจำนวนวันย้อนหลัง (รวมวันนี้) ที่เทียบยอดใบเสร็จรายวันตามค่าเริ่มต้น
+const ReconciliationDays = 7
+
+// maxReconciliationDays จำกัดช่วงที่เทียบใบเสร็จต่อครั้ง เพราะต้องโหลดใบเสร็จทั้งหมดในช่วงจาก API
+const maxReconciliationDays = 90
+
+// Reconcile เทียบข้อมูลในฐานข้อมูลกับ Loyverse แล้วบันทึกสิ่งที่ไม่ตรงกันเป็น findings
+// ได้แก่ จำนวนและยอดรวมใบเสร็จรายวัน/สาขาของ days วันล่าสุด รายการสินค้า และสต็อกทุก variant/สาขา
+// finding เดิมที่ไม่พบแล้วจะถูกปิดอัตโนมัติ ผลถูกบันทึกลง sync_runs (entity reconciliation)
+func Reconcile(ctx context.Context, dbConn *sql.DB, conn models.Connection, days int, trigger string) (*models.ReconciliationResult, error) {
+	loc, err := time.LoadLocation(utils.BusinessTimezone)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().In(loc)
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, 1-days)
+	result := &models.ReconciliationResult{From: from, To: now}
+
+	err = recordSyncRun(dbConn, conn.MerchantID, models.SyncEntityReconciliation, trigger, func(run *models.SyncRun) error {
+		result.SyncRunID = run.ID
+
+		receiptFindings, receiptDays, err := services.FindReceiptMismatches(ctx, dbConn, conn, from, now, loc)
+		if err != nil {
+			return err
+		}
+		itemFindings, localItems, remoteItems, err := services.FindItemMismatches(ctx, dbConn, conn)
+		if err != nil {
+			return err
+		}
+		inventoryFindings, inventoryLevels, err := services.FindInventoryMismatches(ctx, dbConn, conn)
+		if err != nil {
+			return err
+		}
+		result.ReceiptDays, result.LocalItems, result.RemoteItems, result.InventoryLevels = receiptDays, localItems, remoteItems, inventoryLevels
+
+		findings := append(append(receiptFindings, itemFindings...), inventoryFindings...)
+		resolved, err := repository.SaveReconciliationFindings(dbConn, conn.MerchantID, run.ID, findings, from.Format("2006-01-02"), now.Format("2006-01-02"))
+		if err != nil {
+			return err
+		}
+		result.Findings = findings
+		result.ResolvedFindings = resolved
+		run.RowsUpserted = int64(len(findings))
+
+		log.Printf("Reconciliation of %s found %d mismatches (%d resolved)", conn.MerchantID, len(findings), resolved)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Findings == nil {
+		result.Findings = []models.ReconciliationFinding{}
+	}
+	return result, nil
+}
+
+// ResyncFinding โหลดข้อมูลใหม่จาก Loyverse เฉพาะขอบเขตของ finding (วัน/สาขา, สินค้า หรือ variant/สาขา) แล้วปิด finding นั้น
+// ใบเสร็จของวันนั้นถูก upsert ผ่าน BackfillReceipts ใบเสร็จที่มีเฉพาะในฐานข้อมูลจะไม่ถูกลบ
+func ResyncFinding(ctx context.Context, dbConn *sql.DB, conn models.Connection, finding *models.ReconciliationFinding) error {
+	switch finding.Kind {
+	case models.FindingKindReceiptsDay:
+		loc, err := time.LoadLocation(utils.BusinessTimezone)
+		if err != nil {
+			return err
+		}
+		day, err := time.ParseInLocation("2006-01-02", finding.BusinessDate, loc)
+		if err != nil {
+			return err
+		}
+		if _, err := BackfillReceipts(ctx, dbConn, conn, day, day.AddDate(0, 0, 1).Add(-time.Millisecond), finding.StoreID, models.SyncTriggerManual); err != nil {
+			return err
+		}
+	case models.FindingKindItem:
+		if err := services.ResyncItem(ctx, dbConn, conn, finding.ItemID); err != nil {
+			return err
+		}
+	case models.FindingKindInventoryLevel:
+		if err := services.ResyncInventoryLevel(ctx, dbConn, conn, finding.VariantID, finding.StoreID); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown reconciliation finding kind %q", finding.Kind)
+	}
+	return repository.ResolveReconciliationFinding(dbConn, finding)
+}
+
+// ReconciliationRunHandler เทียบข้อมูลของ merchant กับ Loyverse ทันที (POST ?merchant_id=&days=7)
+func ReconciliationRunHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		days := ReconciliationDays
+		if value := r.URL.Query().Get("days"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed <= 0 || parsed > maxReconciliationDays {
+				http.Error(w, fmt.Sprintf("Invalid days parameter (1-%d)", maxReconciliationDays), http.StatusBadRequest)
+				return
+			}
+			days = parsed
+		}
+
+		conn, ok := requestConnection(w, r, db)
+		if !ok {
+			return
+		}
+
+		result, err := Reconcile(r.Context(), db, conn, days, models.SyncTriggerManual)
+		if err != nil {
+			http.Error(w, "Failed to reconcile: "+err.Error(), syncErrorStatus(err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}
+}
+
+// ListReconciliationFindingsHandler แสดง findings ล่าสุดของ merchant (?merchant_id=&status=open&limit=100)
+func ListReconciliationFindingsHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, ok := limitParam(w, r, 100)
+		if !ok {
+			return
+		}
+
+		findings, err := repository.ListReconciliationFindings(db, merchantIDParam(r), r.URL.Query().Get("status"), limit)
+		if err != nil {
+			http.Error(w, "Failed to list reconciliation findings", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(findings)
+	}
+}
+
+// ResyncReconciliationFindingHandler resync เฉพาะวัน/สาขา สินค้า หรือสต็อกที่ finding ระบุ แล้วปิด finding (POST ?merchant_id=&id=)
+func ResyncReconciliationFindingHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid id parameter", http.StatusBadRequest)
+			return
+		}
+
+		conn, ok := requestConnection(w, r, db)
+		if !ok {
+			return
+		}
+
+		finding, err := repository.GetReconciliationFinding(db, conn.MerchantID, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Reconciliation finding not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to get reconciliation finding", http.StatusInternalServerError)
+			return
+		}
+
+		if err := ResyncFinding(r.Context(), db, conn, finding); err != nil {
+			http.Error(w, "Failed to resync: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(finding)
+	}
+}
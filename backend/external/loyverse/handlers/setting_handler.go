@@ -2,21 +2,24 @@
 package handlers
 
 import (
+	"backend/external/loyverse/models"
 	"backend/external/loyverse/repository"
+	"backend/external/loyverse/utils"
 	"database/sql"
 	"encoding/json"
 	"log"
 	"net/http"
+	"strings"
 )
 
-// GetSettingsHandler อ่านค่า settings ทั้งหมด
+// GetSettingsHandler อ่านค่า settings ทั้งหมดของ merchant (?merchant_id=)
 func GetSettingsHandler(db *sql.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		keys := []string{"inventory_sync_time", "receipts_sync_time"}
+		keys := []string{"inventory_sync_time", "receipts_sync_time", "reconciliation_sync_time"}
 		settings := make(map[string]string)
 
 		for _, key := range keys {
-			value, err := repository.GetSetting(db, key)
+			value, err := repository.GetSetting(db, merchantIDParam(r), key)
 			if err != nil {
 				log.Printf("Could not retrieve setting for key %s: %v", key, err)
 				continue
@@ -29,8 +32,19 @@ func GetSettingsHandler(db *sql.DB) http.HandlerFunc {
 	}
 }
 
-// UpdateSettingsHandler อัปเดตค่า settings
-func UpdateSettingsHandler(db *sql.DB) http.HandlerFunc {
+// Scheduler คือ scheduler ของ background jobs ที่ต้องถูกแจ้งเมื่อ settings เปลี่ยน
+type Scheduler interface {
+	Reload()
+	Entries() []models.ScheduledJob
+}
+
+// isScheduleSetting คืนค่า true ถ้า key นี้เป็นตารางเวลาของ background job
+func isScheduleSetting(key string) bool {
+	return strings.HasSuffix(key, "_sync_time")
+}
+
+// UpdateSettingsHandler อัปเดตค่า settings ของ merchant (?merchant_id=) และตั้งเวลา jobs ใหม่ทันที
+func UpdateSettingsHandler(db *sql.DB, scheduler Scheduler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var settings map[string]string
 		if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
@@ -38,12 +52,32 @@ func UpdateSettingsHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
+		// ตรวจสอบตารางเวลาทั้งหมดก่อนบันทึก เพื่อไม่ให้บันทึกค่าที่ใช้ไม่ได้ลงไปบางส่วน
 		for key, value := range settings {
-			if err := repository.UpdateSetting(db, key, value); err != nil {
+			if !isScheduleSetting(key) {
+				continue
+			}
+			if _, err := utils.ParseSchedule(value); err != nil {
+				http.Error(w, "Invalid schedule for "+key+": "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		for key, value := range settings {
+			if err := repository.UpdateSetting(db, merchantIDParam(r), key, value); err != nil {
 				log.Printf("Could not update setting for key %s: %v", key, err)
 			}
 		}
+		scheduler.Reload()
 
 		w.WriteHeader(http.StatusOK)
 	}
 }
+
+// GetScheduleHandler แสดงตารางเวลาของ background jobs และเวลาที่จะรันครั้งถัดไป
+func GetScheduleHandler(scheduler Scheduler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(scheduler.Entries())
+	}
+}
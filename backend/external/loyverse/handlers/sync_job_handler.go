@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"backend/external/loyverse/models"
+	"backend/external/loyverse/services"
+	"backend/external/loyverse/utils"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SyncJobKeepAlive คือช่วงเวลาที่ส่ง comment ว่างใน SSE stream เพื่อไม่ให้ proxy ตัดการเชื่อมต่อที่เงียบนาน
+const SyncJobKeepAlive = 15 * time.Second
+
+// reportPageFetched แจ้ง job ที่ติดตาม ctx ว่าดึง resource ได้อีกหนึ่งหน้า
+// ใช้กับ loop ที่เรียก List* ทีละหน้าเอง (หน้าที่ดึงผ่าน api.Iterator ถูกแจ้งให้อัตโนมัติ)
+func reportPageFetched(ctx context.Context, resource string, page, rows int) {
+	utils.ReportProgress(ctx, models.SyncJobEvent{
+		Type:     models.SyncJobEventPage,
+		Resource: resource,
+		Page:     page,
+		Rows:     int64(rows),
+	})
+}
+
+// reportRowsSaved แจ้ง job ที่ติดตาม ctx ว่าบันทึกข้อมูลลงฐานข้อมูลแล้ว rows แถว
+func reportRowsSaved(ctx context.Context, resource string, rows int64) {
+	utils.ReportProgress(ctx, models.SyncJobEvent{
+		Type:     models.SyncJobEventRows,
+		Resource: resource,
+		Rows:     rows,
+	})
+}
+
+// syncJobRunner คืนค่าฟังก์ชัน sync ของ entity ที่รันเป็น job ได้ หรือ false ถ้าไม่รองรับ entity นั้น
+func syncJobRunner(db *sql.DB, conn models.Connection, entity string, fullResync bool) (func(ctx context.Context) error, bool) {
+	switch entity {
+	case models.SyncEntityMasterData:
+		return func(ctx context.Context) error {
+			return SyncMasterData(ctx, db, conn, models.SyncTriggerManual)
+		}, true
+	case models.SyncEntityReceipts:
+		return func(ctx context.Context) error {
+			return SyncReceipts(ctx, db, conn, models.SyncTriggerManual, fullResync)
+		}, true
+	case models.SyncEntityInventoryLevels:
+		return func(ctx context.Context) error {
+			return SyncInventoryLevels(ctx, db, conn, models.SyncTriggerManual)
+		}, true
+	case models.SyncEntityShifts:
+		return func(ctx context.Context) error {
+			return SyncShifts(ctx, db, conn, models.SyncTriggerManual)
+		}, true
+	}
+	return nil, false
+}
+
+// SyncJobsHandler เริ่ม sync เป็น job เบื้องหลัง (POST ?merchant_id=&entity=master_data|receipts|inventory_levels|shifts&full=true)
+// และตอบกลับทันทีด้วย job (202) หรือ job เดิมที่กำลังทำงานอยู่ถ้าสั่ง entity เดียวกันซ้ำ (200, merged = true)
+// GET ?merchant_id= แสดง job ที่ยังเก็บไว้ของ merchant
+func SyncJobsHandler(db *sql.DB, jobs *services.SyncJobManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(jobs.List(merchantIDParam(r)))
+
+		case http.MethodPost:
+			conn, ok := requestConnection(w, r, db)
+			if !ok {
+				return
+			}
+			entity := r.URL.Query().Get("entity")
+			run, ok := syncJobRunner(db, conn, entity, r.URL.Query().Get("full") == "true")
+			if !ok {
+				http.Error(w, "Unsupported entity: "+entity, http.StatusBadRequest)
+				return
+			}
+
+			job, err := jobs.Start(conn.MerchantID, entity, models.SyncTriggerManual, run)
+			if err != nil {
+				http.Error(w, "Failed to start sync job", http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Location", "/api/sync/jobs/"+job.ID)
+			if !job.Merged {
+				w.WriteHeader(http.StatusAccepted)
+			}
+			json.NewEncoder(w).Encode(job)
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// SyncJobHandler แสดงสถานะของ job (GET /api/sync/jobs/{id})
+// หรือส่ง event ความคืบหน้าแบบ Server-Sent Events (GET /api/sync/jobs/{id}/events)
+func SyncJobHandler(jobs *services.SyncJobManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/sync/jobs/"), "/")
+		switch rest {
+		case "":
+			job, ok := jobs.Get(id)
+			if !ok {
+				http.Error(w, "Sync job not found", http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(job)
+		case "events":
+			streamSyncJobEvents(w, r, jobs, id)
+		default:
+			http.NotFound(w, r)
+		}
+	}
+}
+
+// streamSyncJobEvents ส่ง event ทั้งหมดของ job ตั้งแต่ต้น (หรือต่อจาก header Last-Event-ID เมื่อ reconnect)
+// แล้วส่ง event ใหม่ทันทีที่เกิดขึ้นจนถึง event completed จากนั้นจึงปิด stream
+func streamSyncJobEvents(w http.ResponseWriter, r *http.Request, jobs *services.SyncJobManager, id string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	var lastSeq int64
+	if value := r.Header.Get("Last-Event-ID"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid Last-Event-ID header", http.StatusBadRequest)
+			return
+		}
+		lastSeq = parsed
+	}
+
+	events, changed, finished, ok := jobs.Events(id, lastSeq)
+	if !ok {
+		http.Error(w, "Sync job not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(SyncJobKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		for _, event := range events {
+			if err := writeSyncJobEvent(w, event); err != nil {
+				log.Printf("Error streaming sync job %s: %v", id, err)
+				return
+			}
+			lastSeq = event.Seq
+		}
+		flusher.Flush()
+		if finished {
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+		if events, changed, finished, ok = jobs.Events(id, lastSeq); !ok {
+			return
+		}
+	}
+}
+
+func writeSyncJobEvent(w http.ResponseWriter, event models.SyncJobEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Seq, event.Type, data)
+	return err
+}
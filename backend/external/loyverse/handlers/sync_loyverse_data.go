@@ -2,11 +2,18 @@ package handlers
 
 import (
 	"backend/external/loyverse/config"
+	"backend/external/loyverse/models"
 	"backend/external/loyverse/repository"
 	"backend/external/loyverse/services"
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
 )
 
 // SyncMasterDataHandler handles the initial data sync or reset
@@ -24,60 +31,187 @@ func SyncMasterDataHandler(w http.ResponseWriter, r *http.Request) {
 		log.Fatalf("Could not connect to the database: %v", err)
 		return
 	}
-	// เคลียร์ข้อมูลเก่า
-	if err := repository.ClearOldMasterData(dbConn); err != nil {
-		http.Error(w, "Failed to clear old data", http.StatusInternalServerError)
+	defer dbConn.Close()
+
+	conn, ok := requestConnection(w, r, dbConn)
+	if !ok {
 		return
 	}
 
-	// ดึงข้อมูล master data ใหม่
-	masterData, err := services.FetchMasterData()
-	if err != nil {
-		http.Error(w, "Failed to fetch master data", http.StatusInternalServerError)
+	// ?dry_run=true คืนค่า diff ของสิ่งที่จะเปลี่ยนโดยไม่เขียนลงตาราง (apply ภายหลังผ่าน /api/sync/previews/apply)
+	if dryRunParam(r) {
+		preview, err := PreviewMasterData(r.Context(), dbConn, conn)
+		writePreview(w, preview, err)
 		return
 	}
-	log.Printf("Fetched %d items from API", len(masterData.Items))
-	log.Printf("Fetched %d category from API", len(masterData.Categories))
-	log.Printf("Fetched %d PaymentTypes from API", len(masterData.PaymentTypes))
-	log.Printf("Fetched %d Stores from API", len(masterData.Stores))
-	log.Printf("Fetched %d Suppliers from API", len(masterData.Suppliers))
 
-	// บันทึกข้อมูลใหม่ลงในฐานข้อมูล
-	if err := repository.SaveMasterData(dbConn, masterData); err != nil {
-		http.Error(w, "Failed to save master data", http.StatusInternalServerError)
+	if err := SyncMasterData(r.Context(), dbConn, conn, models.SyncTriggerManual); err != nil {
+		http.Error(w, "Failed to sync master data: "+err.Error(), syncErrorStatus(err))
 		return
 	}
 
-	log.Println("Master data synced successfully")
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Master data synced successfully"))
 }
 
+// SyncMasterData ดึง master data ของ connection จาก Loyverse แล้ว merge ลงฐานข้อมูล พร้อมบันทึกลง sync_runs
+func SyncMasterData(ctx context.Context, dbConn *sql.DB, conn models.Connection, trigger string) error {
+	return recordSyncRun(dbConn, conn.MerchantID, models.SyncEntityMasterData, trigger, func(run *models.SyncRun) error {
+		// ดึงข้อมูล master data ใหม่ก่อน ถ้า API ล้มเหลวข้อมูลเดิมในฐานข้อมูลจะไม่ถูกแตะต้อง
+		masterData, err := services.FetchMasterData(ctx, conn)
+		run.PagesFetched = masterData.PagesFetched
+		if err != nil {
+			log.Println("Error fetching master data:", err)
+			return err
+		}
+		log.Printf("Fetched %d items from API", len(masterData.Items))
+		log.Printf("Fetched %d category from API", len(masterData.Categories))
+		log.Printf("Fetched %d PaymentTypes from API", len(masterData.PaymentTypes))
+		log.Printf("Fetched %d Stores from API", len(masterData.Stores))
+		log.Printf("Fetched %d Suppliers from API", len(masterData.Suppliers))
+		log.Printf("Fetched %d Customers from API", len(masterData.Customers))
+
+		if err := applyMasterData(dbConn, conn, run, masterData); err != nil {
+			return err
+		}
+		reportRowsSaved(ctx, "master_data", run.RowsUpserted)
+		return nil
+	})
+}
+
+// applyMasterData merge master data ที่ดึงมาแล้วผ่าน staging tables ภายใน transaction เดียว และสะสม counters ลงใน run
+func applyMasterData(dbConn *sql.DB, conn models.Connection, run *models.SyncRun, masterData models.LoyMasterData) error {
+	stats, err := repository.RefreshMasterData(dbConn, conn.MerchantID, masterData)
+	if err != nil {
+		return err
+	}
+	for _, tableStats := range stats {
+		run.RowsUpserted += tableStats.Upserted
+		run.RowsDeleted += tableStats.Deleted
+	}
+
+	log.Println("Master data synced successfully")
+	return nil
+}
+
+// recordSyncRun บันทึกการทำงานของ fn ลงตาราง sync_runs ของ merchant
+// fn สะสม counters ลงใน run และ error ที่คืนมาจะถูกบันทึกเป็นผลของ run นั้น
+// ทุก sync ผ่านที่นี่จึงถือ lock ของ merchant และ entity ใน sync_locks ระหว่างทำงาน
+// ถ้ามี sync เดียวกันทำงานอยู่ (จาก cron, route เดิม, sync job หรือ process อื่น) จะคืนค่า repository.ErrSyncLocked โดยไม่สร้าง run
+func recordSyncRun(dbConn *sql.DB, merchantID, entityType, trigger string, fn func(run *models.SyncRun) error) error {
+	unlock, err := lockSync(dbConn, merchantID, entityType)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	run, err := repository.StartSyncRun(dbConn, merchantID, entityType, trigger)
+	if err != nil {
+		return err
+	}
+	syncErr := fn(run)
+	if err := repository.FinishSyncRun(dbConn, run, syncErr); err != nil {
+		log.Printf("Could not record result of %s sync run %d: %v", entityType, run.ID, err)
+	}
+	return syncErr
+}
+
+// syncLockOwners นับเพื่อให้ owner ของแต่ละ lock ไม่ซ้ำกันภายใน process เดียว
+var syncLockOwners atomic.Int64
+
+// lockSync จอง lock ของ sync และต่ออายุทุก 1/3 ของ repository.SyncLockLease จนกว่าจะเรียก unlock
+func lockSync(dbConn *sql.DB, merchantID, entityType string) (unlock func(), err error) {
+	hostname, _ := os.Hostname()
+	owner := fmt.Sprintf("%s/%d/%d", hostname, os.Getpid(), syncLockOwners.Add(1))
+	if err := repository.AcquireSyncLock(dbConn, merchantID, entityType, owner); err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(repository.SyncLockLease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				repository.RenewSyncLock(dbConn, merchantID, entityType, owner)
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+		repository.ReleaseSyncLock(dbConn, merchantID, entityType, owner)
+	}, nil
+}
+
+// syncErrorStatus คือ status code ที่ตอบเมื่อ sync ล้มเหลว (409 ถ้ามี sync เดียวกันทำงานอยู่)
+func syncErrorStatus(err error) int {
+	if errors.Is(err, repository.ErrSyncLocked) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// ReceiptsSyncOverlap ช่วงเวลาที่ย้อนกลับจาก watermark เพื่อไม่ให้พลาดใบเสร็จที่ถูกแก้ไขระหว่าง sync
+const ReceiptsSyncOverlap = 10 * time.Minute
+
 // SyncReceipts ดึงข้อมูล receipts และบันทึกลงฐานข้อมูล โดยไม่ใช้ HTTP response
-func SyncReceipts(dbConn *sql.DB) error {
-	// เคลียร์ข้อมูลเก่า
-	if err := repository.ClearOldReceiptsData(dbConn); err != nil {
+// โหมดปกติจะดึงเฉพาะใบเสร็จที่ถูกแก้ไขหลัง watermark ล่าสุด (ลบด้วย ReceiptsSyncOverlap)
+// ถ้า fullResync เป็น true จะดึงใบเสร็จทั้งหมดใหม่ (upsert ทับข้อมูลเดิม ไม่ล้างตาราง)
+func SyncReceipts(ctx context.Context, dbConn *sql.DB, conn models.Connection, trigger string, fullResync bool) error {
+	return recordSyncRun(dbConn, conn.MerchantID, models.SyncEntityReceipts, trigger, func(run *models.SyncRun) error {
+		return syncReceipts(ctx, dbConn, conn, run, fullResync)
+	})
+}
+
+func syncReceipts(ctx context.Context, dbConn *sql.DB, conn models.Connection, run *models.SyncRun, fullResync bool) error {
+	var updatedSince time.Time
+	watermark, hasWatermark, err := repository.GetSyncWatermark(dbConn, conn.MerchantID, models.SyncEntityReceipts)
+	if err != nil {
 		return err
 	}
+	if !fullResync && hasWatermark {
+		updatedSince = watermark.Add(-ReceiptsSyncOverlap)
+		log.Printf("Syncing receipts updated since %s", updatedSince.Format(time.RFC3339))
+	} else {
+		log.Println("Running full receipts resync")
+	}
 
 	// กำหนดค่าการดึงข้อมูลแบบ Batch
 	limit := 250
 	cursor := ""
+	latestUpdatedAt := watermark
 
 	for {
 		// ดึงข้อมูลใบเสร็จทีละ batch
-		receipts, nextCursor, err := services.FetchReceiptsBatch(cursor, limit)
+		receipts, nextCursor, err := services.FetchReceiptsBatch(ctx, conn, cursor, limit, updatedSince)
 		if err != nil {
 			log.Println("Error fetching receipts:", err)
 			return err
 		}
+		run.PagesFetched++
+		reportPageFetched(ctx, "receipts", run.PagesFetched, len(receipts))
 
 		// บันทึกข้อมูลใบเสร็จใน batch นี้
-		if err := repository.SaveReceipts(dbConn, receipts); err != nil {
+		if err := repository.SaveReceipts(dbConn, conn.MerchantID, receipts); err != nil {
 			log.Println("Error saving receipts:", err)
 			return err
 		}
 		log.Printf("Saved %d receipts to database", len(receipts))
+		run.RowsUpserted += int64(len(receipts))
+		reportRowsSaved(ctx, "receipts", int64(len(receipts)))
+
+		for _, receipt := range receipts {
+			if receipt.UpdatedAt.After(latestUpdatedAt) {
+				latestUpdatedAt = receipt.UpdatedAt
+			}
+		}
 
 		// ตรวจสอบว่าเป็น batch สุดท้ายหรือไม่
 		if nextCursor == "" {
@@ -86,6 +220,15 @@ func SyncReceipts(dbConn *sql.DB) error {
 		cursor = nextCursor // อัปเดต cursor สำหรับ batch ถัดไป
 	}
 
+	// เลื่อน watermark หลังจากบันทึกครบทุก batch แล้วเท่านั้น
+	// เพราะ API ไม่ได้เรียงผลลัพธ์ตาม updated_at การเลื่อนระหว่างทางอาจทำให้พลาดข้อมูล
+	if latestUpdatedAt.After(watermark) {
+		if err := repository.SaveSyncWatermark(dbConn, conn.MerchantID, models.SyncEntityReceipts, latestUpdatedAt); err != nil {
+			log.Println("Error saving receipts watermark:", err)
+			return err
+		}
+	}
+
 	log.Println("Receipts synced successfully")
 	return nil
 }
@@ -101,9 +244,17 @@ func SyncReceiptsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	defer dbConn.Close()
 
+	conn, ok := requestConnection(w, r, dbConn)
+	if !ok {
+		return
+	}
+
+	// ?full=true เพื่อบังคับ resync ใบเสร็จทั้งหมด
+	fullResync := r.URL.Query().Get("full") == "true"
+
 	// เรียกใช้ฟังก์ชัน SyncReceipts ที่ทำงานหลัก
-	if err := SyncReceipts(dbConn); err != nil {
-		http.Error(w, "Failed to sync receipts", http.StatusInternalServerError)
+	if err := SyncReceipts(r.Context(), dbConn, conn, models.SyncTriggerManual, fullResync); err != nil {
+		http.Error(w, "Failed to sync receipts: "+err.Error(), syncErrorStatus(err))
 		return
 	}
 
@@ -111,23 +262,30 @@ func SyncReceiptsHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("Receipts synced successfully"))
 }
 
-// SyncInventoryLevels ดึงข้อมูล inventory levels และบันทึกลงฐานข้อมูล
-func SyncInventoryLevels(db *sql.DB) error {
-	// เคลียร์ข้อมูลเก่า
-	if err := repository.ClearOldInventoryLevelsData(db); err != nil {
-		return err
-	}
+// SyncInventoryLevels ดึงข้อมูล inventory levels ของ connection และบันทึกลงฐานข้อมูล
+func SyncInventoryLevels(ctx context.Context, db *sql.DB, conn models.Connection, trigger string) error {
+	return recordSyncRun(db, conn.MerchantID, models.SyncEntityInventoryLevels, trigger, func(run *models.SyncRun) error {
+		// ดึงข้อมูล inventory levels จาก Loyverse API ก่อน ถ้า API ล้มเหลวสต็อกเดิมในฐานข้อมูลจะไม่ถูกแตะต้อง
+		inventoryLevels, pages, err := services.FetchInventoryLevels(ctx, conn)
+		run.PagesFetched = pages
+		if err != nil {
+			return err
+		}
 
-	// ดึงข้อมูล inventory levels จาก Loyverse API
-	inventoryLevels, err := services.FetchInventoryLevels()
-	if err != nil {
-		return err
-	}
+		if err := replaceInventoryLevels(db, conn, run, inventoryLevels); err != nil {
+			return err
+		}
+		reportRowsSaved(ctx, "inventory", run.RowsUpserted)
+		return nil
+	})
+}
 
-	// บันทึกข้อมูล inventory levels ลงฐานข้อมูล
-	if err := repository.SaveInventoryLevels(db, inventoryLevels); err != nil {
+// replaceInventoryLevels แทนที่สต็อกเดิมของ merchant ด้วยชุดที่ดึงมา (ลบและบันทึกใน transaction เดียวกัน)
+func replaceInventoryLevels(db *sql.DB, conn models.Connection, run *models.SyncRun, inventoryLevels []models.LoyInventoryLevel) error {
+	if err := repository.ReplaceInventoryLevels(db, conn.MerchantID, models.InventoryHistorySourceSync, inventoryLevels); err != nil {
 		return err
 	}
+	run.RowsUpserted = int64(len(inventoryLevels))
 
 	log.Println("Inventory levels synced successfully")
 	return nil
@@ -144,12 +302,100 @@ func SyncInventoryLevelsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	defer dbConn.Close()
 
+	conn, ok := requestConnection(w, r, dbConn)
+	if !ok {
+		return
+	}
+
+	if dryRunParam(r) {
+		preview, err := PreviewInventoryLevels(r.Context(), dbConn, conn)
+		writePreview(w, preview, err)
+		return
+	}
+
 	// เรียกใช้ฟังก์ชัน SyncInventoryLevels ที่ทำงานหลัก
-	if err := SyncInventoryLevels(dbConn); err != nil {
-		http.Error(w, "Failed to sync inventory levels", http.StatusInternalServerError)
+	if err := SyncInventoryLevels(r.Context(), dbConn, conn, models.SyncTriggerManual); err != nil {
+		http.Error(w, "Failed to sync inventory levels: "+err.Error(), syncErrorStatus(err))
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Inventory levels synced successfully"))
 }
+
+// ShiftsSyncOverlap ช่วงเวลาที่ย้อนกลับจาก watermark ของกะ เผื่อกะที่เปิดระหว่าง sync
+const ShiftsSyncOverlap = 10 * time.Minute
+
+// SyncShifts ดึงกะการขายของ connection และบันทึกลงฐานข้อมูล
+// ดึงเฉพาะกะที่เปิดหลัง watermark ล่าสุด แต่ถ้ายังมีกะที่เปิดค้างไว้จะย้อนไปถึงกะนั้น
+// เพื่อให้ได้ยอดเงินสดจริงตอนปิดกะ
+func SyncShifts(ctx context.Context, dbConn *sql.DB, conn models.Connection, trigger string) error {
+	return recordSyncRun(dbConn, conn.MerchantID, models.SyncEntityShifts, trigger, func(run *models.SyncRun) error {
+		watermark, hasWatermark, err := repository.GetSyncWatermark(dbConn, conn.MerchantID, models.SyncEntityShifts)
+		if err != nil {
+			return err
+		}
+		var openedSince time.Time
+		if hasWatermark {
+			openedSince = watermark.Add(-ShiftsSyncOverlap)
+			oldestOpen, hasOpen, err := repository.OldestOpenShift(dbConn, conn.MerchantID)
+			if err != nil {
+				return err
+			}
+			if hasOpen && oldestOpen.Before(openedSince) {
+				openedSince = oldestOpen
+			}
+		}
+
+		shifts, pages, err := services.FetchShifts(ctx, conn, openedSince)
+		run.PagesFetched = pages
+		if err != nil {
+			return err
+		}
+		if err := repository.SaveShifts(dbConn, conn.MerchantID, shifts); err != nil {
+			return err
+		}
+		run.RowsUpserted = int64(len(shifts))
+		reportRowsSaved(ctx, "shifts", run.RowsUpserted)
+
+		latest := watermark
+		for _, shift := range shifts {
+			if shift.OpenedAt.After(latest) {
+				latest = shift.OpenedAt
+			}
+		}
+		if latest.After(watermark) {
+			if err := repository.SaveSyncWatermark(dbConn, conn.MerchantID, models.SyncEntityShifts, latest); err != nil {
+				log.Println("Error saving shifts watermark:", err)
+				return err
+			}
+		}
+
+		log.Printf("Synced %d shifts", len(shifts))
+		return nil
+	})
+}
+
+// SyncShiftsHandler handles the syncing of shifts through HTTP request
+func SyncShiftsHandler(w http.ResponseWriter, r *http.Request) {
+	dbConn, err := config.ConnectDB()
+	if err != nil {
+		log.Fatalf("Could not connect to the database: %v", err)
+		http.Error(w, "Failed to connect to the database", http.StatusInternalServerError)
+		return
+	}
+	defer dbConn.Close()
+
+	conn, ok := requestConnection(w, r, dbConn)
+	if !ok {
+		return
+	}
+
+	if err := SyncShifts(r.Context(), dbConn, conn, models.SyncTriggerManual); err != nil {
+		http.Error(w, "Failed to sync shifts: "+err.Error(), syncErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Shifts synced successfully"))
+}
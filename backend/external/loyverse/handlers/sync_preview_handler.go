@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"backend/external/loyverse/models"
+	"backend/external/loyverse/repository"
+	"backend/external/loyverse/services"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+var (
+	// ErrSyncPreviewApplied คือ preview ที่ถูก apply ไปแล้วหรือกำลังถูก apply โดยคำขออื่น
+	ErrSyncPreviewApplied = errors.New("sync preview was already applied")
+	// ErrSyncPreviewStale คือ preview ที่ข้อมูลในฐานข้อมูลเปลี่ยนไปแล้วหลังสร้าง ต้องสร้าง preview ใหม่
+	ErrSyncPreviewStale = errors.New("data changed after the preview was created")
+)
+
+// dryRunParam อ่าน ?dry_run=true
+func dryRunParam(r *http.Request) bool {
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+	return dryRun
+}
+
+// PreviewMasterData ดึง master data จาก Loyverse แล้วคำนวณ diff กับข้อมูลปัจจุบันโดยไม่เขียนลงตาราง
+// ข้อมูลที่ดึงมาถูกเก็บไว้กับ preview เพื่อให้ ApplySyncPreview บันทึกชุดเดียวกับที่แสดงใน diff
+func PreviewMasterData(ctx context.Context, dbConn *sql.DB, conn models.Connection) (*models.SyncPreview, error) {
+	masterData, err := services.FetchMasterData(ctx, conn)
+	if err != nil {
+		log.Println("Error fetching master data:", err)
+		return nil, err
+	}
+	diff, err := repository.DiffMasterData(dbConn, conn.MerchantID, masterData)
+	if err != nil {
+		return nil, err
+	}
+	return savePreview(dbConn, conn, models.SyncEntityMasterData, diff, masterData)
+}
+
+// PreviewInventoryLevels ดึงสต็อกจาก Loyverse แล้วคำนวณ diff กับสต็อกปัจจุบันโดยไม่เขียนลงตาราง
+func PreviewInventoryLevels(ctx context.Context, dbConn *sql.DB, conn models.Connection) (*models.SyncPreview, error) {
+	remote, _, err := services.FetchInventoryLevels(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	diff, err := diffInventoryLevels(dbConn, conn.MerchantID, remote)
+	if err != nil {
+		return nil, err
+	}
+	return savePreview(dbConn, conn, models.SyncEntityInventoryLevels, diff, remote)
+}
+
+// diffInventoryLevels เทียบสต็อกที่ดึงมากับสต็อกปัจจุบันของ merchant
+func diffInventoryLevels(dbConn *sql.DB, merchantID string, remote []models.LoyInventoryLevel) ([]models.TableDiff, error) {
+	local, err := repository.InventoryLevelsOf(dbConn, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	return []models.TableDiff{services.DiffInventoryLevels(local, remote)}, nil
+}
+
+// checkPreviewDiff คำนวณ diff ของ payload กับข้อมูลปัจจุบันใหม่แล้วเทียบกับ diff ที่แสดงตอนสร้าง preview
+// การเขียนใดๆ หลังสร้าง preview (sync, webhook, การ push ไป Loyverse หรือ resync จาก reconciliation)
+// ทำให้ diff ไม่ตรงกันและได้ ErrSyncPreviewStale โดยไม่ต้องพึ่งว่ามี sync_run หรือไม่
+func checkPreviewDiff(preview *models.SyncPreview, current []models.TableDiff) error {
+	shown, err := json.Marshal(preview.Diff)
+	if err != nil {
+		return err
+	}
+	now, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(shown, now) {
+		return ErrSyncPreviewStale
+	}
+	return nil
+}
+
+func savePreview(dbConn *sql.DB, conn models.Connection, entityType string, diff []models.TableDiff, data interface{}) (*models.SyncPreview, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	preview := &models.SyncPreview{
+		MerchantID: conn.MerchantID,
+		EntityType: entityType,
+		Summary:    services.SummarizeDiff(diff),
+		Diff:       diff,
+	}
+	if err := repository.SaveSyncPreview(dbConn, preview, payload); err != nil {
+		return nil, err
+	}
+	log.Printf("Created %s sync preview %d: %v", entityType, preview.ID, preview.Summary)
+	return preview, nil
+}
+
+// ApplySyncPreview บันทึกข้อมูลที่เก็บไว้กับ preview ลงฐานข้อมูลตามที่แสดงใน diff โดยไม่ดึงจาก Loyverse ใหม่
+// preview ถูกจองก่อนเขียนอะไรลงฐานข้อมูล คำขอ apply ที่ซ้อนกันจึงได้ ErrSyncPreviewApplied และไม่เขียนซ้ำ
+// ถ้าข้อมูลในฐานข้อมูลเปลี่ยนไปหลังสร้าง preview (diff ไม่ตรงกับที่แสดง) จะคืนค่า ErrSyncPreviewStale เพื่อไม่ให้ข้อมูลเก่าทับข้อมูลใหม่
+func ApplySyncPreview(ctx context.Context, dbConn *sql.DB, conn models.Connection, id int64, trigger string) (*models.SyncPreview, error) {
+	preview, payload, err := repository.ClaimSyncPreview(dbConn, conn.MerchantID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		// ไม่มี preview นี้ หรือมีแต่ไม่ได้ pending แล้ว
+		existing, _, err := repository.GetSyncPreview(dbConn, conn.MerchantID, id)
+		if err != nil {
+			return nil, err
+		}
+		return existing, ErrSyncPreviewApplied
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	runID, err := applySyncPreview(dbConn, conn, preview, payload, trigger)
+	if err != nil {
+		repository.ReleaseSyncPreview(dbConn, preview)
+		if errors.Is(err, ErrSyncPreviewStale) {
+			return preview, err
+		}
+		return nil, err
+	}
+	if err := repository.MarkSyncPreviewApplied(dbConn, preview, runID); err != nil {
+		return nil, err
+	}
+	return preview, nil
+}
+
+// applySyncPreview ตรวจว่า diff ของ preview ยังตรงกับข้อมูลปัจจุบันแล้วบันทึก payload ผ่าน sync run ใหม่ คืนค่า id ของ run นั้น
+func applySyncPreview(dbConn *sql.DB, conn models.Connection, preview *models.SyncPreview, payload []byte, trigger string) (int64, error) {
+	var runID int64
+	var current []models.TableDiff
+	var err error
+	switch preview.EntityType {
+	case models.SyncEntityMasterData:
+		var masterData models.LoyMasterData
+		if err := json.Unmarshal(payload, &masterData); err != nil {
+			return 0, err
+		}
+		current, err = repository.DiffMasterData(dbConn, conn.MerchantID, masterData)
+		if err != nil {
+			return 0, err
+		}
+		if err := checkPreviewDiff(preview, current); err != nil {
+			return 0, err
+		}
+		err = recordSyncRun(dbConn, conn.MerchantID, models.SyncEntityMasterData, trigger, func(run *models.SyncRun) error {
+			runID = run.ID
+			return applyMasterData(dbConn, conn, run, masterData)
+		})
+	case models.SyncEntityInventoryLevels:
+		var inventoryLevels []models.LoyInventoryLevel
+		if err := json.Unmarshal(payload, &inventoryLevels); err != nil {
+			return 0, err
+		}
+		current, err = diffInventoryLevels(dbConn, conn.MerchantID, inventoryLevels)
+		if err != nil {
+			return 0, err
+		}
+		if err := checkPreviewDiff(preview, current); err != nil {
+			return 0, err
+		}
+		err = recordSyncRun(dbConn, conn.MerchantID, models.SyncEntityInventoryLevels, trigger, func(run *models.SyncRun) error {
+			runID = run.ID
+			return replaceInventoryLevels(dbConn, conn, run, inventoryLevels)
+		})
+	default:
+		return 0, fmt.Errorf("unknown sync preview entity %q", preview.EntityType)
+	}
+	return runID, err
+}
+
+// writePreview ส่ง preview กลับเป็น JSON หรือ error ถ้าสร้าง preview ไม่สำเร็จ
+func writePreview(w http.ResponseWriter, preview *models.SyncPreview, err error) {
+	if err != nil {
+		http.Error(w, "Failed to preview sync: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(preview)
+}
+
+// GetSyncPreviewHandler แสดง diff ของ preview ที่สร้างไว้ (?merchant_id=&id=)
+func GetSyncPreviewHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid id parameter", http.StatusBadRequest)
+			return
+		}
+
+		preview, _, err := repository.GetSyncPreview(db, merchantIDParam(r), id)
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Sync preview not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to get sync preview", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(preview)
+	}
+}
+
+// ApplySyncPreviewHandler apply preview ตามที่แสดงใน diff (POST ?merchant_id=&id=)
+func ApplySyncPreviewHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid id parameter", http.StatusBadRequest)
+			return
+		}
+
+		conn, ok := requestConnection(w, r, db)
+		if !ok {
+			return
+		}
+
+		preview, err := ApplySyncPreview(r.Context(), db, conn, id, models.SyncTriggerManual)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			http.Error(w, "Sync preview not found", http.StatusNotFound)
+			return
+		case errors.Is(err, ErrSyncPreviewApplied), errors.Is(err, ErrSyncPreviewStale):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			http.Error(w, "Failed to apply sync preview: "+err.Error(), syncErrorStatus(err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(preview)
+	}
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"backend/external/loyverse/repository"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// MaxListLimit คือจำนวนแถวสูงสุดที่ handler แบบรายการส่งกลับได้ในครั้งเดียว (?limit ที่มากกว่านี้ถูกลดลงมา)
+const MaxListLimit = 500
+
+// limitParam อ่าน ?limit (ไม่ระบุ = defaultLimit) และจำกัดไม่ให้เกิน MaxListLimit
+// ตอบ 400 กลับไปเองถ้าค่าไม่ใช่จำนวนเต็มบวก
+func limitParam(w http.ResponseWriter, r *http.Request, defaultLimit int) (int, bool) {
+	value := r.URL.Query().Get("limit")
+	if value == "" {
+		return defaultLimit, true
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit <= 0 {
+		http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+		return 0, false
+	}
+	return min(limit, MaxListLimit), true
+}
+
+// ListSyncRunsHandler แสดงประวัติการ sync ล่าสุดของ merchant (?merchant_id=&entity=receipts&limit=50)
+func ListSyncRunsHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, ok := limitParam(w, r, 50)
+		if !ok {
+			return
+		}
+
+		runs, err := repository.ListSyncRuns(db, merchantIDParam(r), r.URL.Query().Get("entity"), limit)
+		if err != nil {
+			http.Error(w, "Failed to list sync runs", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(runs)
+	}
+}
+
+// GetSyncStatusHandler แสดง run ล่าสุดและ run ที่สำเร็จล่าสุดของแต่ละ entity ของ merchant (?merchant_id=)
+func GetSyncStatusHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		statuses, err := repository.GetSyncStatuses(db, merchantIDParam(r))
+		if err != nil {
+			http.Error(w, "Failed to get sync status", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(statuses)
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"backend/external/loyverse/repository"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// ListWebhookEventsHandler แสดงรายการ webhook ใน inbox ของ merchant (?merchant_id=&status=dead&limit=100)
+func ListWebhookEventsHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, ok := limitParam(w, r, 100)
+		if !ok {
+			return
+		}
+
+		events, err := repository.ListWebhookEvents(db, merchantIDParam(r), r.URL.Query().Get("status"), limit)
+		if err != nil {
+			http.Error(w, "Failed to list webhook events", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(events)
+	}
+}
+
+// GetWebhookEventHandler แสดงรายละเอียดของ webhook หนึ่งรายการของ merchant พร้อม payload (?merchant_id=&delivery_id=)
+func GetWebhookEventHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		deliveryID := r.URL.Query().Get("delivery_id")
+		if deliveryID == "" {
+			http.Error(w, "Missing delivery_id parameter", http.StatusBadRequest)
+			return
+		}
+
+		event, err := repository.GetWebhookEvent(db, merchantIDParam(r), deliveryID)
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Webhook event not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Println("Error getting webhook event:", err)
+			http.Error(w, "Failed to get webhook event", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(event)
+	}
+}
+
+// ReplayWebhookEventHandler ส่ง webhook ของ merchant กลับเข้าคิวให้ worker ประมวลผลใหม่ (POST ?merchant_id=&delivery_id=)
+func ReplayWebhookEventHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		deliveryID := r.URL.Query().Get("delivery_id")
+		if deliveryID == "" {
+			http.Error(w, "Missing delivery_id parameter", http.StatusBadRequest)
+			return
+		}
+
+		err := repository.ReplayWebhookEvent(db, merchantIDParam(r), deliveryID)
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Webhook event not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to replay webhook event", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("Webhook event queued for replay"))
+	}
+}
@@ -1,76 +1,104 @@
 package handlers
 
 import (
-	"backend/external/loyverse/models"
 	"backend/external/loyverse/repository"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
-	"io/ioutil"
+	"io"
 	"log"
 	"net/http"
 )
 
-// WebhookHandler จัดการ Webhook จาก Loyverse สำหรับเหตุการณ์ต่าง ๆ
-func LoyverseWebhookHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
-	var webhookPayload struct {
-		Event         string                     `json:"type"`
-		Receipts      []models.LoyReceipt        `json:"receipts"`
-		InventoryData []models.LoyInventoryLevel `json:"inventory_levels"`
-		Items         []models.LoyItem           `json:"items"`
-		Customers     []models.LoyCustomer       `json:"customers"`
-	}
+// Header ที่ Loyverse ส่งมากับ webhook
+const (
+	WebhookSignatureHeader  = "X-Loyverse-Signature"
+	WebhookDeliveryIDHeader = "X-Loyverse-Delivery-Id"
+)
 
-	// อ่านและ Decode JSON จาก body
-	body, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		http.Error(w, "Bad Request", http.StatusBadRequest)
-		return
-	}
-	if err := json.Unmarshal(body, &webhookPayload); err != nil {
-		http.Error(w, "Bad Request", http.StatusBadRequest)
-		return
-	}
+// maxWebhookBodySize จำกัดขนาด body ของ webhook เพื่อป้องกัน request ขนาดใหญ่ผิดปกติ
+const maxWebhookBodySize = 10 << 20
 
-	// ตรวจสอบประเภทของเหตุการณ์และจัดการตามประเภทนั้น ๆ
-	switch webhookPayload.Event {
-	case "receipts.update":
-		if err := repository.SaveReceipts(db, webhookPayload.Receipts); err != nil {
-			log.Println("Error saving receipts:", err)
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+// LoyverseWebhookHandler รับ Webhook จาก Loyverse ตรวจสอบลายเซ็นด้วย secret ของ merchant
+// แล้วบันทึกลง inbox (webhook_events) ให้ background worker ประมวลผล delivery ที่ซ้ำจะถูกข้าม
+// merchant มาจาก ?merchant_id ใน URL ที่ลงทะเบียนไว้กับ Loyverse (ไม่ระบุ = merchant default)
+func LoyverseWebhookHandler(db *sql.DB, secrets WebhookSecrets) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
-		log.Println("Webhook Receipts updated successfully 555.")
 
-	case "inventory_levels.update":
-		if err := repository.SaveInventoryLevels(db, webhookPayload.InventoryData); err != nil {
-			log.Println("Error saving inventory levels:", err)
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		// อ่าน body ก่อน decode เพราะต้องใช้ byte เดิมในการตรวจลายเซ็น
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBodySize))
+		if err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
 			return
 		}
-		log.Println("Webhook Inventory levels updated successfully 555.")
 
-	case "items.update":
-		if err := repository.SaveItems(db, webhookPayload.Items); err != nil {
-			log.Println("Error saving items:", err)
+		merchantID := merchantIDParam(r)
+		secret, err := secrets(merchantID)
+		if err != nil {
+			log.Printf("Could not load webhook secret for merchant %s: %v", merchantID, err)
 			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 			return
 		}
-		log.Println("Items updated successfully.")
+		if !verifyWebhookSignature(body, r.Header.Get(WebhookSignatureHeader), secret) {
+			log.Println("Rejected webhook with invalid signature")
+			http.Error(w, "Invalid signature", http.StatusUnauthorized)
+			return
+		}
 
-	case "customers.update":
-		if err := repository.SaveCustomers(db, webhookPayload.Customers); err != nil {
-			log.Println("Error saving customers:", err)
+		// decode เฉพาะ type เพื่อบันทึกลง inbox ส่วนข้อมูลจริงจะถูก decode โดย worker
+		var envelope struct {
+			Event string `json:"type"`
+		}
+		if err := json.Unmarshal(body, &envelope); err != nil || envelope.Event == "" {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+
+		// บันทึกลง inbox ก่อนตอบกลับ เพื่อไม่ให้ event หายแม้การประมวลผลจะล้มเหลว
+		deliveryID := webhookDeliveryID(r, body)
+		inserted, err := repository.EnqueueWebhookEvent(db, merchantID, deliveryID, envelope.Event, body)
+		if err != nil {
 			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 			return
 		}
-		log.Println("Customers updated successfully.")
+		if !inserted {
+			log.Printf("Duplicate webhook delivery %s (%s), skipping", deliveryID, envelope.Event)
+		}
+
+		// ตอบกลับด้วย 200 OK ทันที worker จะประมวลผลต่อใน background
+		w.WriteHeader(http.StatusOK)
+	}
+}
 
-	default:
-		log.Printf("Unhandled event type: %s\n", webhookPayload.Event)
-		http.Error(w, "Unhandled event type", http.StatusNotImplemented)
-		return
+// verifyWebhookSignature ตรวจสอบว่า signature เป็น HMAC-SHA1 (base64) ของ body ด้วย secret
+// ถ้ายังไม่ได้ตั้งค่า secret จะปฏิเสธทุก request
+func verifyWebhookSignature(body []byte, signature, secret string) bool {
+	if secret == "" || signature == "" {
+		return false
+	}
+	given, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false
 	}
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(given, mac.Sum(nil))
+}
 
-	// ตอบกลับด้วย 200 OK
-	w.WriteHeader(http.StatusOK)
+// webhookDeliveryID ใช้ delivery id จาก header ถ้ามี
+// มิฉะนั้นใช้ SHA-256 ของ body ซึ่งจะเหมือนเดิมทุกครั้งที่ Loyverse ส่ง delivery เดิมซ้ำ
+func webhookDeliveryID(r *http.Request, body []byte) string {
+	if id := r.Header.Get(WebhookDeliveryIDHeader); id != "" {
+		return id
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// DefaultMerchantID คือ merchant ของข้อมูลที่มีอยู่ก่อนรองรับหลายบัญชี
+// และของ connection ที่สร้างจาก LOYVERSE_API_TOKEN
+const DefaultMerchantID = "default"
+
+// Connection คือบัญชี Loyverse หนึ่งบัญชีที่ระบบ sync ข้อมูลอยู่
+// ทุกแถวที่ sync มาจะถูก tag ด้วย MerchantID ของ connection นั้น
+type Connection struct {
+	MerchantID       string    `json:"merchant_id"`
+	Name             string    `json:"name"`
+	APIToken         string    `json:"api_token,omitempty"`      // รับเข้าได้แต่ไม่ส่งกลับใน response
+	WebhookSecret    string    `json:"webhook_secret,omitempty"` // รับเข้าได้แต่ไม่ส่งกลับใน response
+	BaseURL          string    `json:"base_url"`                 // ว่าง = api.DefaultBaseURL
+	Enabled          bool      `json:"enabled"`
+	HasWebhookSecret bool      `json:"has_webhook_secret"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// ConnectionUpdate คือ body ของ POST /api/connections
+// field ที่ไม่ได้ส่งมา (nil) หรือ token/secret ที่ว่างจะคงค่าเดิมไว้ จึงแก้ไขเฉพาะบาง field ได้
+type ConnectionUpdate struct {
+	MerchantID    string  `json:"merchant_id"`
+	Name          *string `json:"name"`
+	APIToken      string  `json:"api_token"`
+	WebhookSecret string  `json:"webhook_secret"`
+	BaseURL       *string `json:"base_url"` // "" = กลับไปใช้ api.DefaultBaseURL
+	Enabled       *bool   `json:"enabled"`  // connection ใหม่ที่ไม่ระบุจะเปิดใช้งาน
+}
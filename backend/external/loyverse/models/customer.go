@@ -1,10 +1,30 @@
 package models
 
+import (
+	"backend/pkg/money"
+	"time"
+)
+
 type LoyCustomer struct {
-	CustomerID  string `json:"customer_id"`
-	Name        string `json:"name"`
-	Email       string `json:"email"`
-	PhoneNumber string `json:"phone_number"`
+	CustomerID   string      `json:"id"`
+	Name         string      `json:"name"`
+	Email        string      `json:"email"`
+	PhoneNumber  string      `json:"phone_number"`
+	Address      string      `json:"address"`
+	City         string      `json:"city"`
+	Region       string      `json:"region"`
+	PostalCode   string      `json:"postal_code"`
+	CountryCode  string      `json:"country_code"`
+	Note         *string     `json:"note"`
+	CustomerCode *string     `json:"customer_code"`
+	FirstVisit   *time.Time  `json:"first_visit"`
+	LastVisit    *time.Time  `json:"last_visit"`
+	TotalVisits  int         `json:"total_visits"`
+	TotalSpent   money.Money `json:"total_spent"`
+	TotalPoints  float64     `json:"total_points"`
+	CreatedAt    time.Time   `json:"created_at"`
+	UpdatedAt    time.Time   `json:"updated_at"`
+	DeletedAt    *time.Time  `json:"deleted_at"`
 }
 
 type LoyCustomersResponse struct {
@@ -2,6 +2,13 @@ package models
 
 import "time"
 
+// ที่มาของ snapshot ในตาราง loyinventory_history
+const (
+	InventoryHistorySourceSync    = "sync"
+	InventoryHistorySourceWebhook = "webhook"
+	InventoryHistorySourcePush    = "push"
+)
+
 type LoyInventoryLevel struct {
 	VariantID string    `json:"variant_id"`
 	StoreID   string    `json:"store_id"`
@@ -14,3 +21,10 @@ type LoyInventoryLevelsResponse struct {
 	InventoryLevels []LoyInventoryLevel `json:"inventory_levels"`
 	Cursor          string              `json:"cursor"`
 }
+
+// LoyInventoryUpdate คือรายการหนึ่งใน body ของ POST /inventory
+type LoyInventoryUpdate struct {
+	VariantID  string  `json:"variant_id"`
+	StoreID    string  `json:"store_id"`
+	StockAfter float64 `json:"stock_after"`
+}
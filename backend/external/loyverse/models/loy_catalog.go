@@ -0,0 +1,67 @@
+package models
+
+import (
+	"backend/pkg/money"
+	"time"
+)
+
+// LoyTax คือภาษีจาก /taxes
+type LoyTax struct {
+	TaxID     string     `json:"id"`
+	Type      string     `json:"type"` // INCLUDED (รวมในราคา) หรือ ADDED (บวกเพิ่ม)
+	Name      string     `json:"name"`
+	Rate      float64    `json:"rate"`   // เปอร์เซ็นต์ เช่น 7
+	Stores    []string   `json:"stores"` // สาขาที่ใช้ภาษีนี้
+	CreatedAt *time.Time `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at"`
+}
+
+type LoyTaxesResponse struct {
+	Taxes  []LoyTax `json:"taxes"`
+	Cursor string   `json:"cursor"`
+}
+
+// LoyDiscount คือส่วนลดจาก /discounts
+type LoyDiscount struct {
+	DiscountID       string       `json:"id"`
+	Type             string       `json:"type"` // FIXED_PERCENT, FIXED_AMOUNT, VARIABLE_PERCENT, VARIABLE_AMOUNT, DISCOUNT_BY_POINTS
+	Name             string       `json:"name"`
+	DiscountAmount   *money.Money `json:"discount_amount"`
+	DiscountPercent  *float64     `json:"discount_percent"`
+	Stores           []string     `json:"stores"`
+	RestrictedAccess bool         `json:"restricted_access"`
+	CreatedAt        *time.Time   `json:"created_at"`
+	UpdatedAt        *time.Time   `json:"updated_at"`
+	DeletedAt        *time.Time   `json:"deleted_at"`
+}
+
+type LoyDiscountsResponse struct {
+	Discounts []LoyDiscount `json:"discounts"`
+	Cursor    string        `json:"cursor"`
+}
+
+// LoyModifier คือกลุ่มตัวเลือกเสริม (เช่น "ความหวาน") จาก /modifiers
+type LoyModifier struct {
+	ModifierID      string           `json:"id"`
+	Name            string           `json:"name"`
+	Position        int              `json:"position"`
+	Stores          []string         `json:"stores"`
+	ModifierOptions []ModifierOption `json:"modifier_options"`
+	CreatedAt       *time.Time       `json:"created_at"`
+	UpdatedAt       *time.Time       `json:"updated_at"`
+	DeletedAt       *time.Time       `json:"deleted_at"`
+}
+
+// ModifierOption คือตัวเลือกหนึ่งในกลุ่ม modifier (เช่น "หวานน้อย") พร้อมราคาที่บวกเพิ่ม
+type ModifierOption struct {
+	ModifierOptionID string      `json:"id"`
+	Name             string      `json:"name"`
+	Price            money.Money `json:"price"`
+	Position         int         `json:"position"`
+}
+
+type LoyModifiersResponse struct {
+	Modifiers []LoyModifier `json:"modifiers"`
+	Cursor    string        `json:"cursor"`
+}
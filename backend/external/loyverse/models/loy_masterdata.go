@@ -1,6 +1,7 @@
 package models
 
 import (
+	"backend/pkg/money"
 	"database/sql"
 	"time"
 )
@@ -10,48 +11,96 @@ type LoyMasterData struct {
 	Categories   []LoyCategory    `json:"categories"`
 	Items        []LoyItem        `json:"items"`
 	PaymentTypes []LoyPaymentType `json:"payment_types"`
+	Taxes        []LoyTax         `json:"taxes"`
+	Discounts    []LoyDiscount    `json:"discounts"`
+	Modifiers    []LoyModifier    `json:"modifiers"`
 	Stores       []LoyStore       `json:"stores"`
+	Employees    []LoyEmployee    `json:"employees"`
+	PosDevices   []LoyPosDevice   `json:"pos_devices"`
 	Suppliers    []LoySupplier    `json:"suppliers"`
+	Customers    []LoyCustomer    `json:"customers"`
+	PagesFetched int              `json:"-"` // จำนวนหน้าที่ดึงจาก API รวมทุก resource
 }
 
 // LoyCategory struct สำหรับเก็บข้อมูลหมวดหมู่
 type LoyCategory struct {
-	CategoryID string  `json:"id"`         // category_id
-	Name       string  `json:"name"`       // name
-	Color      string  `json:"color"`      // color
-	CreatedAt  string  `json:"created_at"` // created_at
-	DeletedAt  *string `json:"deleted_at"` // deleted_at
+	CategoryID string     `json:"id"`         // category_id
+	Name       string     `json:"name"`       // name
+	Color      string     `json:"color"`      // color
+	CreatedAt  string     `json:"created_at"` // created_at
+	DeletedAt  *time.Time `json:"deleted_at"` // deleted_at (มีค่าเมื่อดึงด้วย show_deleted)
+}
+
+type LoyCategoriesResponse struct {
+	Categories []LoyCategory `json:"categories"`
+	Cursor     string        `json:"cursor"`
 }
 
 // LoyItem struct สำหรับเก็บข้อมูลสินค้า
 type LoyItem struct {
-	ID                string    `json:"id"`                  // item_id
-	ItemName          string    `json:"item_name"`           // item_name
-	Description       string    `json:"description"`         // description
-	CategoryID        *string   `json:"category_id"`         // category_id
-	PrimarySupplierID string    `json:"primary_supplier_id"` // supplier_id
-	ImageURL          string    `json:"image_url"`           // image_url
-	Variants          []Variant `json:"variants"`            // variants
-	IsComposite       bool      `json:"is_composite"`        // Indicates if the item is composite
-	UseProduction     bool      `json:"use_production"`      // Indicates if production is used for the item
-	CreatedAt         time.Time `json:"created_at"`          // Creation timestamp
-	UpdatedAt         time.Time `json:"updated_at"`          // Update timestamp
+	ID                string     `json:"id"`                  // item_id
+	ItemName          string     `json:"item_name"`           // item_name
+	Description       string     `json:"description"`         // description
+	CategoryID        *string    `json:"category_id"`         // category_id
+	PrimarySupplierID string     `json:"primary_supplier_id"` // supplier_id
+	ImageURL          string     `json:"image_url"`           // image_url
+	Option1Name       *string    `json:"option1_name"`        // e.g. "ขนาดแพ็ค"
+	Option2Name       *string    `json:"option2_name"`        // option2_name
+	Option3Name       *string    `json:"option3_name"`        // option3_name
+	Variants          []Variant  `json:"variants"`            // variants
+	IsComposite       bool       `json:"is_composite"`        // Indicates if the item is composite
+	UseProduction     bool       `json:"use_production"`      // Indicates if production is used for the item
+	CreatedAt         time.Time  `json:"created_at"`          // Creation timestamp
+	UpdatedAt         time.Time  `json:"updated_at"`          // Update timestamp
+	DeletedAt         *time.Time `json:"deleted_at"`          // Deletion timestamp (set when fetched with show_deleted)
+}
 
+type LoyItemsResponse struct {
+	Items  []LoyItem `json:"items"`
+	Cursor string    `json:"cursor"`
 }
 
-// Variant struct สำหรับเก็บข้อมูล variant ของสินค้า
+// Variant struct สำหรับเก็บข้อมูล variant ของสินค้า (เช่นขนาดแพ็คต่างๆ ของสินค้าเดียวกัน)
 type Variant struct {
-	VariantID    string   `json:"variant_id"`    // variant_id
-	Cost         float64  `json:"cost"`          // cost
-	PurchaseCost float64  `json:"purchase_cost"` // purchase_cost
-	DefaultPrice *float64 `json:"default_price"` // selling_price
+	VariantID          string         `json:"variant_id"`           // variant_id
+	ItemID             string         `json:"item_id"`              // item_id
+	SKU                string         `json:"sku"`                  // sku
+	ReferenceVariantID *string        `json:"reference_variant_id"` // variant ต้นแบบ (ถ้าสร้างจาก variant อื่น)
+	Option1Value       *string        `json:"option1_value"`        // ค่าของ option1_name ของสินค้า
+	Option2Value       *string        `json:"option2_value"`        // option2_value
+	Option3Value       *string        `json:"option3_value"`        // option3_value
+	Barcode            *string        `json:"barcode"`              // barcode
+	Cost               money.Money    `json:"cost"`                 // cost
+	PurchaseCost       money.Money    `json:"purchase_cost"`        // purchase_cost
+	DefaultPricingType string         `json:"default_pricing_type"` // FIXED หรือ VARIABLE
+	DefaultPrice       *money.Money   `json:"default_price"`        // selling_price
+	Stores             []VariantStore `json:"stores"`               // ราคาและการขายรายสาขา
+	CreatedAt          *time.Time     `json:"created_at"`           // created_at
+	UpdatedAt          *time.Time     `json:"updated_at"`           // updated_at
+	DeletedAt          *time.Time     `json:"deleted_at"`           // deleted_at
+}
+
+// VariantStore คือราคาและสถานะการขายของ variant ในสาขาหนึ่ง
+type VariantStore struct {
+	StoreID          string       `json:"store_id"`
+	PricingType      string       `json:"pricing_type"` // FIXED หรือ VARIABLE
+	Price            *money.Money `json:"price"`
+	AvailableForSale bool         `json:"available_for_sale"`
+	OptimalStock     *float64     `json:"optimal_stock"`
+	LowStock         *float64     `json:"low_stock"`
+}
+
+type LoyVariantsResponse struct {
+	Variants []Variant `json:"variants"`
+	Cursor   string    `json:"cursor"`
 }
 
 // LoyPaymentType struct สำหรับเก็บข้อมูลประเภทการชำระเงิน
 type LoyPaymentType struct {
-	PaymentTypeID string `json:"id"`   // payment_type_id
-	Name          string `json:"name"` // name
-	Type          string `json:"type"` // type
+	PaymentTypeID string     `json:"id"`         // payment_type_id
+	Name          string     `json:"name"`       // name
+	Type          string     `json:"type"`       // type
+	DeletedAt     *time.Time `json:"deleted_at"` // deleted_at
 }
 type LoyPaymentTypesResponse struct {
 	PaymentTypes []LoyPaymentType `json:"payment_types"`
@@ -59,8 +108,9 @@ type LoyPaymentTypesResponse struct {
 }
 
 type LoyStore struct {
-	StoreID   string `json:"id"`
-	StoreName string `json:"name"`
+	StoreID   string     `json:"id"`
+	StoreName string     `json:"name"`
+	DeletedAt *time.Time `json:"deleted_at"`
 }
 
 type LoyStoresResponse struct {
@@ -74,6 +124,7 @@ type LoySupplier struct {
 	OrderCycle   sql.NullString `json:"order_cycle" db:"order_cycle"`     // Order cycle, e.g., "daily", "alternate_days"
 	SelectedDays sql.NullString `json:"selected_days" db:"selected_days"` // Comma-separated list of selected days
 	SortOrder    int            `json:"sort_order" db:"sort_order"`       // Display or processing order
+	DeletedAt    *time.Time     `json:"deleted_at" db:"deleted_at"`       // Set when the supplier was deleted in Loyverse
 }
 type SupplierInput struct {
 	SupplierID   string   `json:"id"`
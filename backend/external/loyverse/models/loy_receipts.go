@@ -1,48 +1,94 @@
 package models
 
-import "time"
+import (
+	"backend/pkg/money"
+	"time"
+)
+
+// ประเภทใบเสร็จจาก receipt_type ของ Loyverse
+// ใบคืนเงิน (REFUND) มียอดเป็นบวกเหมือนใบขาย และอ้างถึงใบขายเดิมผ่าน refund_for
+const (
+	ReceiptTypeSale   = "SALE"
+	ReceiptTypeRefund = "REFUND"
+)
 
 type LoyReceipt struct {
-	ReceiptNumber string     `json:"receipt_number"`
-	Note          *string    `json:"note"`
-	CreatedAt     time.Time  `json:"created_at"`
-	ReceiptDate   time.Time  `json:"receipt_date"`
-	UpdatedAt     time.Time  `json:"updated_at"`
-	CancelledAt   *time.Time `json:"cancelled_at"`
-	Source        string     `json:"source"`
-	TotalMoney    float64    `json:"total_money"`
-	TotalTax      float64    `json:"total_tax"`
-	CustomerID    string     `json:"customer_id"`
-	TotalDiscount float64    `json:"total_discount"`
-	LineItems     []LineItem `json:"line_items"`
-	Payments      []Payment  `json:"payments"`
-	StoreID       string     `json:"store_id"`
-	PosDeviceId   string     `json:"pos_device_id"`
+	ReceiptNumber string      `json:"receipt_number"`
+	ReceiptType   string      `json:"receipt_type"` // SALE หรือ REFUND
+	RefundFor     *string     `json:"refund_for"`   // เลขที่ใบขายที่ถูกคืนเงิน (เฉพาะ REFUND)
+	Note          *string     `json:"note"`
+	CreatedAt     time.Time   `json:"created_at"`
+	ReceiptDate   time.Time   `json:"receipt_date"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+	CancelledAt   *time.Time  `json:"cancelled_at"`
+	Source        string      `json:"source"`
+	TotalMoney    money.Money `json:"total_money"`
+	TotalTax      money.Money `json:"total_tax"`
+	CustomerID    string      `json:"customer_id"`
+	TotalDiscount money.Money `json:"total_discount"`
+	LineItems     []LineItem  `json:"line_items"`
+	Payments      []Payment   `json:"payments"`
+	StoreID       string      `json:"store_id"`
+	PosDeviceId   string      `json:"pos_device_id"`
+	EmployeeID    string      `json:"employee_id"` // พนักงานที่ทำรายการ
+}
+
+type LoyReceiptsResponse struct {
+	Receipts []LoyReceipt `json:"receipts"`
+	Cursor   string       `json:"cursor"`
 }
 
 type LineItem struct {
-	ID              string        `json:"id"`
-	ItemID          string        `json:"item_id"`
-	VariantID       string        `json:"variant_id"`
-	ItemName        string        `json:"item_name"`
-	VariantName     *string       `json:"variant_name"`
-	SKU             string        `json:"sku"`
-	Quantity        float64       `json:"quantity"` // เปลี่ยนเป็น float64
-	Price           float64       `json:"price"`
-	GrossTotalMoney float64       `json:"gross_total_money"`
-	TotalMoney      float64       `json:"total_money"`
-	Cost            float64       `json:"cost"`
-	CostTotal       float64       `json:"cost_total"`
-	LineNote        *string       `json:"line_note"`
-	LineTaxes       []interface{} `json:"line_taxes"`
-	TotalDiscount   float64       `json:"total_discount"`
-	LineDiscounts   []interface{} `json:"line_discounts"`
-	LineModifiers   []interface{} `json:"line_modifiers"`
+	ID              string         `json:"id"`
+	ItemID          string         `json:"item_id"`
+	VariantID       string         `json:"variant_id"`
+	ItemName        string         `json:"item_name"`
+	VariantName     *string        `json:"variant_name"`
+	SKU             string         `json:"sku"`
+	Quantity        float64        `json:"quantity"` // เปลี่ยนเป็น float64
+	Price           money.Money    `json:"price"`
+	GrossTotalMoney money.Money    `json:"gross_total_money"`
+	TotalMoney      money.Money    `json:"total_money"`
+	Cost            money.Money    `json:"cost"`
+	CostTotal       money.Money    `json:"cost_total"`
+	LineNote        *string        `json:"line_note"`
+	LineTaxes       []LineTax      `json:"line_taxes"`
+	TotalDiscount   money.Money    `json:"total_discount"`
+	LineDiscounts   []LineDiscount `json:"line_discounts"`
+	LineModifiers   []LineModifier `json:"line_modifiers"`
+}
+
+// LineTax คือภาษีที่คิดกับรายการสินค้าหนึ่งรายการ (id อ้างถึง /taxes)
+type LineTax struct {
+	TaxID       string      `json:"id"`
+	Type        string      `json:"type"`
+	Name        string      `json:"name"`
+	Rate        float64     `json:"rate"`
+	MoneyAmount money.Money `json:"money_amount"`
+}
+
+// LineDiscount คือส่วนลดที่ใช้กับรายการสินค้าหนึ่งรายการ (id อ้างถึง /discounts)
+type LineDiscount struct {
+	DiscountID  string      `json:"id"`
+	Type        string      `json:"type"`
+	Name        string      `json:"name"`
+	Percentage  *float64    `json:"percentage"`
+	MoneyAmount money.Money `json:"money_amount"`
+}
+
+// LineModifier คือตัวเลือกเสริมที่ลูกค้าเลือกในรายการสินค้าหนึ่งรายการ
+type LineModifier struct {
+	ModifierID       string      `json:"id"`
+	ModifierOptionID string      `json:"modifier_option_id"`
+	Name             string      `json:"name"`
+	Option           string      `json:"option"`
+	Price            money.Money `json:"price"`
+	MoneyAmount      money.Money `json:"money_amount"`
 }
 
 type Payment struct {
-	PaymentTypeID string  `json:"payment_type_id"`
-	MoneyAmount   float64 `json:"money_amount"`
-	Name          string  `json:"name"`
-	Type          string  `json:"type"`
+	PaymentTypeID string      `json:"payment_type_id"`
+	MoneyAmount   money.Money `json:"money_amount"`
+	Name          string      `json:"name"`
+	Type          string      `json:"type"`
 }
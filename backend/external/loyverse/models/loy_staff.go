@@ -0,0 +1,89 @@
+package models
+
+import (
+	"backend/pkg/money"
+	"time"
+)
+
+// LoyEmployee คือพนักงานจาก /employees (id ตรงกับ employee_id ในใบเสร็จและกะ)
+type LoyEmployee struct {
+	EmployeeID  string     `json:"id"`
+	Name        string     `json:"name"`
+	Email       *string    `json:"email"`
+	PhoneNumber *string    `json:"phone_number"`
+	Stores      []string   `json:"stores"` // สาขาที่พนักงานเข้าใช้งานได้
+	IsOwner     bool       `json:"is_owner"`
+	CreatedAt   *time.Time `json:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at"`
+}
+
+type LoyEmployeesResponse struct {
+	Employees []LoyEmployee `json:"employees"`
+	Cursor    string        `json:"cursor"`
+}
+
+// LoyPosDevice คือเครื่อง POS (ลิ้นชักเก็บเงิน) จาก /pos_devices
+type LoyPosDevice struct {
+	PosDeviceID string     `json:"id"`
+	Name        string     `json:"name"`
+	StoreID     string     `json:"store_id"`
+	Activated   bool       `json:"activated"`
+	CreatedAt   *time.Time `json:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at"`
+}
+
+type LoyPosDevicesResponse struct {
+	PosDevices []LoyPosDevice `json:"pos_devices"`
+	Cursor     string         `json:"cursor"`
+}
+
+// ประเภทการเคลื่อนไหวของเงินสดในกะ
+const (
+	CashMovementPayIn  = "PAY_IN"
+	CashMovementPayOut = "PAY_OUT"
+)
+
+// LoyShift คือกะการขายของเครื่อง POS หนึ่งเครื่องจาก /shifts
+// expected_cash = starting_cash + cash_payments - cash_refunds + paid_in - paid_out
+// actual_cash เป็น nil จนกว่าจะปิดกะ
+type LoyShift struct {
+	ShiftID          string         `json:"id"`
+	StoreID          string         `json:"store_id"`
+	PosDeviceID      string         `json:"pos_device_id"`
+	OpenedAt         time.Time      `json:"opened_at"`
+	ClosedAt         *time.Time     `json:"closed_at"`
+	OpenedByEmployee *string        `json:"opened_by_employee"`
+	ClosedByEmployee *string        `json:"closed_by_employee"`
+	StartingCash     money.Money    `json:"starting_cash"`
+	CashPayments     money.Money    `json:"cash_payments"`
+	CashRefunds      money.Money    `json:"cash_refunds"`
+	PaidIn           money.Money    `json:"paid_in"`
+	PaidOut          money.Money    `json:"paid_out"`
+	ExpectedCash     money.Money    `json:"expected_cash"`
+	ActualCash       *money.Money   `json:"actual_cash"`
+	GrossSales       money.Money    `json:"gross_sales"`
+	Refunds          money.Money    `json:"refunds"`
+	Discounts        money.Money    `json:"discounts"`
+	NetSales         money.Money    `json:"net_sales"`
+	Tip              money.Money    `json:"tip"`
+	Surcharge        money.Money    `json:"surcharge"`
+	CashMovements    []CashMovement `json:"cash_movements"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+}
+
+// CashMovement คือการนำเงินเข้า (PAY_IN) หรือออก (PAY_OUT) จากลิ้นชักระหว่างกะ
+type CashMovement struct {
+	Type        string      `json:"type"`
+	MoneyAmount money.Money `json:"money_amount"`
+	Comment     *string     `json:"comment"`
+	EmployeeID  *string     `json:"employee_id"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+type LoyShiftsResponse struct {
+	Shifts []LoyShift `json:"shifts"`
+	Cursor string     `json:"cursor"`
+}
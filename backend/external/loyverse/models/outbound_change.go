@@ -0,0 +1,59 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ประเภทของการเปลี่ยนแปลงที่ส่งกลับไปยัง Loyverse
+const (
+	OutboundTypeInventory = "inventory_level"
+	OutboundTypeItem      = "item"
+	OutboundTypeSupplier  = "supplier"
+)
+
+// สถานะของการเปลี่ยนแปลงในคิว outbound_changes
+const (
+	OutboundStatusPending    = "pending"    // รอส่งครั้งแรก
+	OutboundStatusProcessing = "processing" // worker กำลังส่ง
+	OutboundStatusSent       = "sent"       // Loyverse ยืนยันแล้ว
+	OutboundStatusFailed     = "failed"     // ล้มเหลว รอ retry ตาม next_attempt_at
+	OutboundStatusDead       = "dead"       // ล้มเหลวถาวร ต้องสั่ง retry ด้วยมือ
+)
+
+// OutboundChange คือการเปลี่ยนแปลงหนึ่งรายการที่รอส่ง (หรือส่งแล้ว) ไปยัง Loyverse
+// ตาราง outbound_changes เป็นทั้งคิวและ audit log: เก็บสิ่งที่ผู้ใช้ขอ, body ที่ส่งจริง และ response
+type OutboundChange struct {
+	ID            int64           `json:"id"`
+	MerchantID    string          `json:"merchant_id"`
+	ChangeType    string          `json:"change_type"`
+	EntityID      string          `json:"entity_id"`
+	Payload       json.RawMessage `json:"payload"`
+	Reason        *string         `json:"reason"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	LastError     *string         `json:"last_error"`
+	RequestBody   json.RawMessage `json:"request_body,omitempty"`
+	ResponseBody  json.RawMessage `json:"response_body,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	NextAttemptAt *time.Time      `json:"next_attempt_at"`
+	SentAt        *time.Time      `json:"sent_at"`
+}
+
+// InventoryAdjustment คือคำขอปรับสต็อกหนึ่งรายการ
+// ระบุ StockAfter เมื่อนับสต็อก (ค่าสัมบูรณ์) หรือ Adjustment เมื่อรับของเข้า/ตัดออก (ค่าเปลี่ยนแปลง)
+// Adjustment จะถูกคำนวณกับสต็อกปัจจุบันใน Loyverse ตอนส่ง ไม่ใช่ตอนเข้าคิว
+type InventoryAdjustment struct {
+	VariantID  string   `json:"variant_id"`
+	StoreID    string   `json:"store_id"`
+	StockAfter *float64 `json:"stock_after,omitempty"`
+	Adjustment *float64 `json:"adjustment,omitempty"`
+}
+
+// ResourceUpdate คือคำขอแก้ไขฟิลด์ของ item หรือ supplier
+// Changes มีเฉพาะฟิลด์ที่ต้องการเปลี่ยน ฟิลด์อื่นจะคงค่าเดิมใน Loyverse
+type ResourceUpdate struct {
+	ID      string                 `json:"id"`
+	Changes map[string]interface{} `json:"changes"`
+	Reason  string                 `json:"reason"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// ReceiptBackfill คือการโหลดใบเสร็จย้อนหลังในช่วงวันที่หนึ่ง (ตาราง receipt_backfills)
+// Cursor คือ cursor ของหน้าถัดไปที่ยังไม่ได้บันทึก ถ้าหยุดกลางคัน backfill ช่วงเดิมจะเริ่มต่อจาก cursor นี้
+// Status ใช้ค่าเดียวกับ SyncRun (running, succeeded, failed)
+type ReceiptBackfill struct {
+	ID            int64      `json:"id"`
+	MerchantID    string     `json:"merchant_id"`
+	StoreID       string     `json:"store_id"` // ว่าง = ทุกสาขา
+	From          time.Time  `json:"from"`
+	To            time.Time  `json:"to"`
+	Cursor        string     `json:"cursor"`
+	Status        string     `json:"status"`
+	PagesFetched  int        `json:"pages_fetched"`  // รวมทุกครั้งที่รันช่วงนี้
+	ReceiptsSaved int64      `json:"receipts_saved"` // รวมทุกครั้งที่รันช่วงนี้
+	SyncRunID     *int64     `json:"sync_run_id"`    // sync run ล่าสุดที่ทำงานกับช่วงนี้
+	Error         *string    `json:"error"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	FinishedAt    *time.Time `json:"finished_at"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"backend/pkg/money"
+	"time"
+)
+
+// ชนิดของสิ่งที่ไม่ตรงกันระหว่างฐานข้อมูลกับ Loyverse (kind ในตาราง reconciliation_findings)
+const (
+	FindingKindReceiptsDay    = "receipts_day"    // จำนวนหรือยอดรวมใบเสร็จของวัน/สาขาไม่ตรง
+	FindingKindItem           = "item"            // สินค้าขาดหายหรือสถานะการลบไม่ตรง
+	FindingKindInventoryLevel = "inventory_level" // สต็อกของ variant/สาขาไม่ตรง
+)
+
+// สถานะของ finding
+const (
+	FindingStatusOpen     = "open"
+	FindingStatusResolved = "resolved"
+)
+
+// ReconciliationFinding คือสิ่งที่ไม่ตรงกันหนึ่งรายการที่พบจากการ reconcile
+// Key ระบุขอบเขตที่ต้อง resync เช่น "receipts_day:2024-01-01:store-1" และใช้รวม finding เดิมที่ยังเปิดอยู่
+type ReconciliationFinding struct {
+	ID           int64       `json:"id"`
+	MerchantID   string      `json:"merchant_id"`
+	Kind         string      `json:"kind"`
+	Key          string      `json:"key"`
+	BusinessDate string      `json:"business_date,omitempty"` // YYYY-MM-DD ตามเวลาไทย (เฉพาะ receipts_day)
+	StoreID      string      `json:"store_id,omitempty"`
+	ItemID       string      `json:"item_id,omitempty"`
+	VariantID    string      `json:"variant_id,omitempty"`
+	LocalCount   int64       `json:"local_count"`
+	RemoteCount  int64       `json:"remote_count"`
+	LocalValue   float64     `json:"local_value"`  // จำนวนสต็อก (เฉพาะ inventory_level)
+	RemoteValue  float64     `json:"remote_value"` // ค่าเดียวกันจาก Loyverse
+	LocalTotal   money.Money `json:"local_total"`  // ยอดรวมใบเสร็จหักคืนเงินแล้ว (เฉพาะ receipts_day)
+	RemoteTotal  money.Money `json:"remote_total"` // ยอดเดียวกันจาก Loyverse
+	Detail       string      `json:"detail"`
+	Status       string      `json:"status"`
+	SyncRunID    *int64      `json:"sync_run_id"` // reconciliation run ล่าสุดที่ยังพบ finding นี้
+	DetectedAt   time.Time   `json:"detected_at"`
+	LastSeenAt   time.Time   `json:"last_seen_at"`
+	ResolvedAt   *time.Time  `json:"resolved_at"`
+}
+
+// DailyReceiptTotal คือจำนวนและยอดรวมใบเสร็จของหนึ่งวันในหนึ่งสาขา (ใบคืนเงินนับเป็นยอดติดลบ)
+type DailyReceiptTotal struct {
+	BusinessDate string      `json:"business_date"`
+	StoreID      string      `json:"store_id"`
+	Count        int64       `json:"count"`
+	Total        money.Money `json:"total"`
+}
+
+// ReconciliationResult สรุปผลการ reconcile หนึ่งครั้ง
+type ReconciliationResult struct {
+	SyncRunID        int64                   `json:"sync_run_id"`
+	From             time.Time               `json:"from"`
+	To               time.Time               `json:"to"`
+	ReceiptDays      int                     `json:"receipt_days"` // จำนวนคู่ วัน/สาขา ที่ตรวจ
+	LocalItems       int                     `json:"local_items"`  // สินค้าที่ยังไม่ถูกลบ
+	RemoteItems      int                     `json:"remote_items"`
+	InventoryLevels  int                     `json:"inventory_levels"`
+	Findings         []ReconciliationFinding `json:"findings"`
+	ResolvedFindings int64                   `json:"resolved_findings"` // finding เดิมที่ไม่พบแล้วในรอบนี้
+}
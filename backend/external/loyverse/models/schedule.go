@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// ScheduledJob แสดงตารางเวลาของ background job หนึ่งตัวและเวลาที่จะรันครั้งถัดไป
+type ScheduledJob struct {
+	Job        string      `json:"job"`
+	MerchantID string      `json:"merchant_id"`
+	SettingKey string      `json:"setting_key"`
+	Schedule   string      `json:"schedule"`
+	Timezone   string      `json:"timezone"`
+	NextRuns   []time.Time `json:"next_runs"`
+	Error      *string     `json:"error,omitempty"` // ค่าใน settings ที่ไม่ถูกต้อง (ถ้ามี)
+}
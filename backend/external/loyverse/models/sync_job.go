@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// ชนิดของ event ที่ส่งออกทาง /api/sync/jobs/{id}/events
+const (
+	SyncJobEventStarted   = "started"   // job เริ่มทำงาน
+	SyncJobEventPage      = "page"      // ดึงข้อมูลจาก API ได้หนึ่งหน้า (resource, page, rows = จำนวนรายการในหน้านั้น)
+	SyncJobEventRows      = "rows"      // บันทึกข้อมูลลงฐานข้อมูลแล้ว (rows = จำนวนแถวที่บันทึกในขั้นนั้น)
+	SyncJobEventError     = "error"     // เกิด error ระหว่างทาง เช่น API ล้มเหลวและกำลัง retry
+	SyncJobEventCompleted = "completed" // job จบแล้ว (status บอกผล และ message คือ error ถ้าล้มเหลว)
+)
+
+// SyncJobEvent คือความคืบหน้าหนึ่งขั้นของ sync job
+// Seq เพิ่มขึ้นทีละหนึ่งภายใน job และใช้เป็น id ของ SSE event (Last-Event-ID)
+type SyncJobEvent struct {
+	Seq      int64     `json:"seq"`
+	Type     string    `json:"type"`
+	Entity   string    `json:"entity"`
+	Resource string    `json:"resource,omitempty"`
+	Page     int       `json:"page,omitempty"`
+	Rows     int64     `json:"rows,omitempty"`
+	Status   string    `json:"status,omitempty"`
+	Message  string    `json:"message,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// SyncJob คือ sync ที่สั่งให้ทำงานเบื้องหลังผ่าน /api/sync/jobs
+// Merged เป็น true เมื่อคำสั่งถูกรวมเข้ากับ job ของ merchant และ entity เดียวกันที่กำลังทำงานอยู่
+type SyncJob struct {
+	ID           string     `json:"id"`
+	MerchantID   string     `json:"merchant_id"`
+	Entity       string     `json:"entity"`
+	Trigger      string     `json:"trigger"`
+	Status       string     `json:"status"`
+	StartedAt    time.Time  `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at"`
+	PagesFetched int        `json:"pages_fetched"`
+	RowsSaved    int64      `json:"rows_saved"`
+	Error        *string    `json:"error"`
+	Merged       bool       `json:"merged"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// สถานะของ sync preview
+const (
+	SyncPreviewPending  = "pending"  // รอ apply
+	SyncPreviewApplying = "applying" // ถูกจองโดยคำขอ apply ที่กำลังทำงาน
+	SyncPreviewApplied  = "applied"
+)
+
+// ValueChange คือค่าเดิมและค่าใหม่ของคอลัมน์หนึ่ง (nil = NULL)
+type ValueChange struct {
+	From *string `json:"from"`
+	To   *string `json:"to"`
+}
+
+// RowChange คือคอลัมน์ที่จะเปลี่ยนของแถวหนึ่ง
+type RowChange struct {
+	Key     string                 `json:"key"`
+	Changes map[string]ValueChange `json:"changes"`
+}
+
+// TableDiff สรุปสิ่งที่ sync จะเปลี่ยนในตารางหนึ่ง
+// ตัวนับครอบคลุมทุกแถว ส่วนรายการ Inserts, Deletes และ Updates ถูกจำกัดจำนวนเพื่อไม่ให้ response ใหญ่เกินไป
+type TableDiff struct {
+	Table         string         `json:"table"`
+	Inserted      int            `json:"inserted"`
+	Updated       int            `json:"updated"`
+	Deleted       int            `json:"deleted"`
+	ColumnChanges map[string]int `json:"column_changes"` // จำนวนแถวที่คอลัมน์นั้นเปลี่ยน
+	Inserts       []string       `json:"inserts"`
+	Deletes       []string       `json:"deletes"`
+	Updates       []RowChange    `json:"updates"`
+}
+
+// SyncPreview คือผลของ dry-run sync ที่เก็บข้อมูลที่ดึงมาไว้ เพื่อ apply ชุดเดิมได้โดยไม่ต้องดึงใหม่
+type SyncPreview struct {
+	ID         int64       `json:"id"`
+	MerchantID string      `json:"merchant_id"`
+	EntityType string      `json:"entity_type"` // SyncEntityMasterData หรือ SyncEntityInventoryLevels
+	Status     string      `json:"status"`
+	Summary    []string    `json:"summary"`
+	Diff       []TableDiff `json:"diff"`
+	SyncRunID  *int64      `json:"sync_run_id"` // sync run ที่ apply preview นี้
+	CreatedAt  time.Time   `json:"created_at"`
+	AppliedAt  *time.Time  `json:"applied_at"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// ชนิดของข้อมูลที่ถูก sync (entity_type ในตาราง sync_runs)
+const (
+	SyncEntityMasterData      = "master_data"
+	SyncEntityReceipts        = "receipts"
+	SyncEntityInventoryLevels = "inventory_levels"
+	SyncEntityItems           = "items"
+	SyncEntityCustomers       = "customers"
+	SyncEntityShifts          = "shifts"
+	SyncEntityReceiptBackfill = "receipts_backfill"
+	SyncEntityReconciliation  = "reconciliation"
+
+	// SyncEntityWebhookSuffix ต่อท้ายชื่อ entity ของ run ที่มาจาก webhook (เช่น "receipts_webhook")
+	SyncEntityWebhookSuffix = "_webhook"
+)
+
+// สาเหตุที่ทำให้เกิดการ sync (trigger ในตาราง sync_runs)
+const (
+	SyncTriggerCron    = "cron"
+	SyncTriggerManual  = "manual"
+	SyncTriggerWebhook = "webhook"
+)
+
+// สถานะของการ sync
+const (
+	SyncStatusRunning   = "running"
+	SyncStatusSucceeded = "succeeded"
+	SyncStatusFailed    = "failed"
+)
+
+// SyncRun คือประวัติการ sync หนึ่งครั้ง
+type SyncRun struct {
+	ID           int64      `json:"id"`
+	MerchantID   string     `json:"merchant_id"`
+	EntityType   string     `json:"entity_type"`
+	Trigger      string     `json:"trigger"`
+	Status       string     `json:"status"`
+	StartedAt    time.Time  `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at"`
+	PagesFetched int        `json:"pages_fetched"`
+	RowsUpserted int64      `json:"rows_upserted"`
+	RowsDeleted  int64      `json:"rows_deleted"`
+	Error        *string    `json:"error"`
+}
+
+// SyncStatus สรุปสถานะล่าสุดของ entity หนึ่งสำหรับแสดงในหน้า settings
+type SyncStatus struct {
+	EntityType  string   `json:"entity_type"`
+	LastRun     *SyncRun `json:"last_run"`
+	LastSuccess *SyncRun `json:"last_success"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// สถานะของ webhook event ใน inbox
+const (
+	WebhookStatusPending    = "pending"    // รอประมวลผลครั้งแรก
+	WebhookStatusProcessing = "processing" // worker กำลังประมวลผล
+	WebhookStatusProcessed  = "processed"  // ประมวลผลสำเร็จแล้ว
+	WebhookStatusFailed     = "failed"     // ล้มเหลว รอ retry ตาม next_attempt_at
+	WebhookStatusDead       = "dead"       // ล้มเหลวถาวร ต้อง replay ด้วยมือ
+)
+
+// WebhookEvent คือ webhook หนึ่ง delivery ที่บันทึกไว้ในตาราง webhook_events
+type WebhookEvent struct {
+	DeliveryID    string          `json:"delivery_id"`
+	MerchantID    string          `json:"merchant_id"`
+	EventType     string          `json:"event_type"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	LastError     *string         `json:"last_error"`
+	ReceivedAt    time.Time       `json:"received_at"`
+	ProcessedAt   *time.Time      `json:"processed_at"`
+	NextAttemptAt *time.Time      `json:"next_attempt_at"`
+	Payload       json.RawMessage `json:"payload,omitempty"`
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"backend/external/loyverse/models"
+	"database/sql"
+	"fmt"
+	"log"
+)
+
+const connectionColumns = `merchant_id, name, api_token, webhook_secret, base_url, enabled, created_at`
+
+// ListConnections แสดง connection ทั้งหมด หรือเฉพาะที่เปิดใช้งานถ้า enabledOnly เป็น true
+func ListConnections(db *sql.DB, enabledOnly bool) ([]models.Connection, error) {
+	rows, err := db.Query(`
+		SELECT `+connectionColumns+`
+		FROM loyverse_connections
+		WHERE NOT $1 OR enabled
+		ORDER BY created_at, merchant_id`,
+		enabledOnly,
+	)
+	if err != nil {
+		log.Println("Error listing connections:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	connections := []models.Connection{}
+	for rows.Next() {
+		var conn models.Connection
+		if err := scanConnection(rows, &conn); err != nil {
+			return nil, err
+		}
+		connections = append(connections, conn)
+	}
+	return connections, rows.Err()
+}
+
+// GetConnection อ่าน connection ของ merchant (คืน sql.ErrNoRows ถ้าไม่มี)
+func GetConnection(db *sql.DB, merchantID string) (models.Connection, error) {
+	var conn models.Connection
+	row := db.QueryRow(`SELECT `+connectionColumns+` FROM loyverse_connections WHERE merchant_id = $1`, merchantID)
+	if err := scanConnection(row, &conn); err != nil {
+		if err == sql.ErrNoRows {
+			return conn, fmt.Errorf("connection %s not found: %w", merchantID, err)
+		}
+		return conn, err
+	}
+	return conn, nil
+}
+
+// SaveConnection สร้างหรืออัปเดต connection ด้วยค่าทุก field ของ conn
+// token และ webhook secret ที่ว่างจะไม่ทับค่าเดิม การแก้ไขบาง field จาก API ให้ใช้ UpdateConnection
+func SaveConnection(db *sql.DB, conn models.Connection) error {
+	_, err := db.Exec(`
+		INSERT INTO loyverse_connections (merchant_id, name, api_token, webhook_secret, base_url, enabled)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (merchant_id) DO UPDATE SET
+			name = EXCLUDED.name,
+			api_token = COALESCE(NULLIF(EXCLUDED.api_token, ''), loyverse_connections.api_token),
+			webhook_secret = COALESCE(NULLIF(EXCLUDED.webhook_secret, ''), loyverse_connections.webhook_secret),
+			base_url = EXCLUDED.base_url,
+			enabled = EXCLUDED.enabled`,
+		conn.MerchantID, conn.Name, conn.APIToken, conn.WebhookSecret, conn.BaseURL, conn.Enabled,
+	)
+	if err != nil {
+		log.Println("Error saving connection:", err)
+	}
+	return err
+}
+
+// UpdateConnection สร้างหรืออัปเดต connection เฉพาะ field ที่ระบุใน update
+// field ที่เป็น nil และ token/webhook secret ที่ว่างจะคงค่าเดิมไว้ (connection ใหม่ใช้ชื่อ = merchant_id และเปิดใช้งาน)
+func UpdateConnection(db *sql.DB, update models.ConnectionUpdate) error {
+	_, err := db.Exec(`
+		INSERT INTO loyverse_connections (merchant_id, name, api_token, webhook_secret, base_url, enabled)
+		VALUES ($1, COALESCE($2::text, $1), $3, $4, COALESCE($5::text, ''), COALESCE($6::boolean, TRUE))
+		ON CONFLICT (merchant_id) DO UPDATE SET
+			name = COALESCE($2::text, loyverse_connections.name),
+			api_token = COALESCE(NULLIF(EXCLUDED.api_token, ''), loyverse_connections.api_token),
+			webhook_secret = COALESCE(NULLIF(EXCLUDED.webhook_secret, ''), loyverse_connections.webhook_secret),
+			base_url = COALESCE($5::text, loyverse_connections.base_url),
+			enabled = COALESCE($6::boolean, loyverse_connections.enabled)`,
+		update.MerchantID, update.Name, update.APIToken, update.WebhookSecret, update.BaseURL, update.Enabled,
+	)
+	if err != nil {
+		log.Println("Error updating connection:", err)
+	}
+	return err
+}
+
+// EnsureDefaultConnection สร้างหรืออัปเดต connection ของ models.DefaultMerchantID จากค่าใน environment
+// เพื่อให้ระบบที่ตั้งค่าด้วย LOYVERSE_API_TOKEN อย่างเดียวทำงานได้เหมือนเดิม
+// webhook secret และ base URL ที่ไม่ได้ตั้งใน environment จะไม่ทับค่าที่ตั้งไว้ผ่าน /api/connections
+func EnsureDefaultConnection(db *sql.DB, token, webhookSecret, baseURL string) error {
+	_, err := db.Exec(`
+		INSERT INTO loyverse_connections (merchant_id, name, api_token, webhook_secret, base_url)
+		VALUES ($1, $1, $2, $3, $4)
+		ON CONFLICT (merchant_id) DO UPDATE SET
+			api_token = EXCLUDED.api_token,
+			webhook_secret = COALESCE(NULLIF(EXCLUDED.webhook_secret, ''), loyverse_connections.webhook_secret),
+			base_url = COALESCE(NULLIF(EXCLUDED.base_url, ''), loyverse_connections.base_url)`,
+		models.DefaultMerchantID, token, webhookSecret, baseURL,
+	)
+	if err != nil {
+		log.Println("Error ensuring default connection:", err)
+	}
+	return err
+}
+
+func scanConnection(row rowScanner, conn *models.Connection) error {
+	if err := row.Scan(
+		&conn.MerchantID, &conn.Name, &conn.APIToken, &conn.WebhookSecret, &conn.BaseURL, &conn.Enabled, &conn.CreatedAt,
+	); err != nil {
+		return err
+	}
+	conn.HasWebhookSecret = conn.WebhookSecret != ""
+	return nil
+}
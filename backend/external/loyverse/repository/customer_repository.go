@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"backend/external/loyverse/models"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// customerColumns คือคอลัมน์ของ loycustomers ที่ข้อมูลมาจาก Loyverse (customer_id เป็น key)
+var customerColumns = []string{
+	"customer_id", "name", "email", "phone_number", "address", "city", "region", "postal_code", "country_code",
+	"note", "customer_code", "first_visit", "last_visit", "total_visits", "total_spent", "total_points",
+	"created_at", "updated_at",
+}
+
+var customerColumnTypes = []string{
+	"TEXT", "TEXT", "TEXT", "TEXT", "TEXT", "TEXT", "TEXT", "TEXT", "TEXT",
+	"TEXT", "TEXT", "TIMESTAMPTZ", "TIMESTAMPTZ", "INTEGER", "NUMERIC", "NUMERIC",
+	"TIMESTAMPTZ", "TIMESTAMPTZ",
+}
+
+// customerRow เรียงค่าของ customer ตามลำดับ customerColumns
+func customerRow(customer models.LoyCustomer) []interface{} {
+	return []interface{}{
+		customer.CustomerID, customer.Name, customer.Email, customer.PhoneNumber,
+		customer.Address, customer.City, customer.Region, customer.PostalCode, customer.CountryCode,
+		customer.Note, customer.CustomerCode, customer.FirstVisit, customer.LastVisit,
+		customer.TotalVisits, customer.TotalSpent, customer.TotalPoints,
+		customer.CreatedAt, customer.UpdatedAt,
+	}
+}
+
+// SaveCustomers บันทึกข้อมูล customers ของ merchant ลงในฐานข้อมูล (ใช้กับ webhook customers.update)
+func SaveCustomers(db *sql.DB, merchantID string, customers []models.LoyCustomer) error {
+	placeholders := make([]string, len(customerColumns)+2)
+	updates := make([]string, 0, len(customerColumns))
+	for i, column := range customerColumns {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		if i > 0 {
+			updates = append(updates, column+" = EXCLUDED."+column)
+		}
+	}
+	placeholders[len(customerColumns)] = fmt.Sprintf("$%d", len(customerColumns)+1)
+	placeholders[len(customerColumns)+1] = fmt.Sprintf("$%d", len(customerColumns)+2)
+	updates = append(updates, "deleted_at = EXCLUDED.deleted_at", "merchant_id = EXCLUDED.merchant_id")
+
+	query := fmt.Sprintf(`
+		INSERT INTO loycustomers (%s, deleted_at, merchant_id)
+		VALUES (%s)
+		ON CONFLICT (customer_id) DO UPDATE SET %s`,
+		strings.Join(customerColumns, ", "), strings.Join(placeholders, ", "), strings.Join(updates, ", "))
+
+	for _, customer := range customers {
+		args := append(customerRow(customer), customer.DeletedAt, merchantID)
+		if _, err := db.Exec(query, args...); err != nil {
+			log.Println("Error saving customer:", err)
+			return err
+		}
+	}
+	log.Println("Customers saved successfully.")
+	return nil
+}
@@ -6,9 +6,30 @@ import (
 	"log"
 )
 
-// SaveInventoryLevels saves inventory levels to the database with conflict resolution.
+// SaveInventoryLevels saves inventory levels of one merchant to the database with conflict resolution.
 // If an entry with the same variant_id and store_id exists, it updates the in_stock and updated_at values.
-func SaveInventoryLevels(db *sql.DB, inventoryLevels []models.LoyInventoryLevel) error {
+// Every level is also appended to loyinventory_history with the given source (models.InventoryHistorySource*).
+func SaveInventoryLevels(db *sql.DB, merchantID, source string, inventoryLevels []models.LoyInventoryLevel) error {
+	return inInventoryTx(db, func(tx *sql.Tx) error {
+		return saveInventoryLevels(tx, merchantID, source, inventoryLevels)
+	})
+}
+
+// ReplaceInventoryLevels แทนที่สต็อกทั้งหมดของ merchant ด้วยชุดที่ดึงมาจาก Loyverse
+// การลบของเดิม, upsert และการต่อท้าย history อยู่ใน transaction เดียว
+// ระหว่าง sync ผู้อ่านจึงเห็นชุดเดิมครบจนกว่าชุดใหม่จะ commit และถ้าล้มเหลวจะไม่เหลือตารางว่าง
+func ReplaceInventoryLevels(db *sql.DB, merchantID, source string, inventoryLevels []models.LoyInventoryLevel) error {
+	return inInventoryTx(db, func(tx *sql.Tx) error {
+		if _, err := tx.Exec("DELETE FROM loyinventorylevels WHERE merchant_id = $1", merchantID); err != nil {
+			log.Println("Error clearing old data:", err)
+			return err
+		}
+		return saveInventoryLevels(tx, merchantID, source, inventoryLevels)
+	})
+}
+
+// inInventoryTx runs fn in a transaction and commits only when fn succeeds
+func inInventoryTx(db *sql.DB, fn func(tx *sql.Tx) error) (err error) {
 	// Begin a transaction for batch insert/update
 	tx, err := db.Begin()
 	if err != nil {
@@ -20,17 +41,21 @@ func SaveInventoryLevels(db *sql.DB, inventoryLevels []models.LoyInventoryLevel)
 		if err != nil {
 			tx.Rollback()
 		} else {
-			tx.Commit()
+			err = tx.Commit()
 		}
 	}()
+	return fn(tx)
+}
 
+func saveInventoryLevels(tx *sql.Tx, merchantID, source string, inventoryLevels []models.LoyInventoryLevel) error {
 	// Prepare the SQL statement once for better performance in batch inserts
 	stmt, err := tx.Prepare(`
-		INSERT INTO loyinventorylevels (variant_id, store_id, in_stock, updated_at)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO loyinventorylevels (variant_id, store_id, in_stock, updated_at, merchant_id)
+		VALUES ($1, $2, $3, $4, $5)
 		ON CONFLICT (variant_id, store_id) DO UPDATE 
 		SET in_stock = EXCLUDED.in_stock,
-			updated_at = EXCLUDED.updated_at
+			updated_at = EXCLUDED.updated_at,
+			merchant_id = EXCLUDED.merchant_id
 	`)
 	if err != nil {
 		log.Println("Error preparing statement:", err)
@@ -40,24 +65,38 @@ func SaveInventoryLevels(db *sql.DB, inventoryLevels []models.LoyInventoryLevel)
 
 	// Loop through each inventory level and execute the prepared statement
 	for _, level := range inventoryLevels {
-		_, err := stmt.Exec(level.VariantID, level.StoreID, level.InStock, level.UpdatedAt)
+		_, err = stmt.Exec(level.VariantID, level.StoreID, level.InStock, level.UpdatedAt, merchantID)
 		if err != nil {
 			log.Println("Error saving inventory level for variant:", level.VariantID, "store:", level.StoreID, "error:", err)
 			return err
 		}
 	}
 
+	if err = appendInventoryHistory(tx, merchantID, source, inventoryLevels); err != nil {
+		return err
+	}
+
 	log.Println("Inventory levels saved successfully.")
 	return nil
 }
 
-// ClearOldInventoryLevelsData clears the old data in the loyinventorylevels table
-func ClearOldInventoryLevelsData(db *sql.DB) error {
-	_, err := db.Exec("TRUNCATE TABLE loyinventorylevels RESTART IDENTITY")
+// appendInventoryHistory ต่อท้าย snapshot ของสต็อกลง loyinventory_history ใน transaction เดียวกับการบันทึกค่าล่าสุด
+func appendInventoryHistory(tx *sql.Tx, merchantID, source string, inventoryLevels []models.LoyInventoryLevel) error {
+	stmt, err := tx.Prepare(`
+		INSERT INTO loyinventory_history (merchant_id, variant_id, store_id, in_stock, updated_at, source)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`)
 	if err != nil {
-		log.Println("Error clearing old data:", err)
+		log.Println("Error preparing inventory history statement:", err)
 		return err
 	}
-	log.Println("Old loyinventorylevels data cleared successfully.")
+	defer stmt.Close()
+
+	for _, level := range inventoryLevels {
+		if _, err := stmt.Exec(merchantID, level.VariantID, level.StoreID, level.InStock, nullTime(level.UpdatedAt), source); err != nil {
+			log.Println("Error appending inventory history for variant:", level.VariantID, "store:", level.StoreID, "error:", err)
+			return err
+		}
+	}
 	return nil
 }
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"backend/external/loyverse/models"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// DiffDetailLimit คือจำนวนแถวสูงสุดที่แสดงรายละเอียดในแต่ละรายการของ TableDiff
+const DiffDetailLimit = 100
+
+// diffIgnoredColumns คือคอลัมน์ที่เปลี่ยนทุกครั้งที่ Loyverse แก้ไขแถว จึงไม่นับเป็นการเปลี่ยนแปลงใน diff
+var diffIgnoredColumns = map[string]bool{"created_at": true, "updated_at": true}
+
+// DiffMasterData เทียบ master data ที่ดึงมากับข้อมูลปัจจุบันของ merchant โดยไม่เขียนอะไรลงตารางจริง
+// ใช้ staging table ชุดเดียวกับ RefreshMasterData ใน transaction ที่ rollback เสมอ
+// ผลลัพธ์ตรงกับสิ่งที่ RefreshMasterData จะทำ: แถวใหม่, แถวที่คอลัมน์จาก API เปลี่ยน และแถวที่จะถูก soft-delete
+// (ราคารายสาขาใน loyvariant_stores และตัวเลือกของ modifier ถูกแทนที่ทั้งชุดตอน apply จึงไม่อยู่ใน diff)
+func DiffMasterData(db *sql.DB, merchantID string, data models.LoyMasterData) ([]models.TableDiff, error) {
+	tables, err := masterDataStagingTables(data)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Println("Failed to begin transaction:", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	diffs := make([]models.TableDiff, 0, len(tables))
+	for _, table := range tables {
+		diff, err := diffTable(tx, merchantID, table)
+		if err != nil {
+			log.Printf("Error diffing %s: %v", table.target, err)
+			return nil, err
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs, nil
+}
+
+func diffTable(tx *sql.Tx, merchantID string, table stagingTable) (models.TableDiff, error) {
+	diff := models.TableDiff{
+		Table:         table.target,
+		ColumnChanges: map[string]int{},
+		Inserts:       []string{},
+		Deletes:       []string{},
+		Updates:       []models.RowChange{},
+	}
+
+	if _, err := loadStagingTable(tx, table); err != nil {
+		return diff, err
+	}
+
+	var compared []string
+	selects := []string{
+		"COALESCE(s." + table.key() + ", t." + table.key() + ")",
+		"s." + table.key() + " IS NOT NULL",
+		"t." + table.key() + " IS NOT NULL",
+		"s.deleted_at IS NOT NULL",
+		"t.deleted_at::text",
+	}
+	for i, column := range table.columns[1:] {
+		if diffIgnoredColumns[column] {
+			continue
+		}
+		// แปลงคอลัมน์ของตารางจริงเป็นชนิดเดียวกับ staging ก่อนเทียบ
+		target := fmt.Sprintf("t.%s::%s", column, table.types[i+1])
+		compared = append(compared, column)
+		selects = append(selects, target+"::text", "s."+column+"::text", fmt.Sprintf("%s IS DISTINCT FROM s.%s", target, column))
+	}
+
+	rows, err := tx.Query(fmt.Sprintf(`
+		SELECT %[4]s
+		FROM (SELECT DISTINCT ON (%[3]s) * FROM %[2]s) s
+		FULL OUTER JOIN (SELECT * FROM %[1]s WHERE merchant_id = $1) t ON t.%[3]s = s.%[3]s
+		ORDER BY 1`,
+		table.target, table.stage(), table.key(), strings.Join(selects, ", ")), merchantID)
+	if err != nil {
+		return diff, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		var staged, existing, stagedDeleted bool
+		var existingDeletedAt sql.NullString
+		before := make([]sql.NullString, len(compared))
+		after := make([]sql.NullString, len(compared))
+		changed := make([]bool, len(compared))
+		dest := []interface{}{&key, &staged, &existing, &stagedDeleted, &existingDeletedAt}
+		for i := range compared {
+			dest = append(dest, &before[i], &after[i], &changed[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return diff, err
+		}
+
+		existingActive := existing && !existingDeletedAt.Valid
+		switch {
+		case !existing:
+			// แถวที่ถูกลบไปแล้วตั้งแต่ก่อนเข้ามาในฐานข้อมูลไม่ถือเป็นการเพิ่ม
+			if !stagedDeleted {
+				diff.Inserted++
+				if len(diff.Inserts) < DiffDetailLimit {
+					diff.Inserts = append(diff.Inserts, key)
+				}
+			}
+		case !staged || stagedDeleted:
+			if existingActive {
+				diff.Deleted++
+				if len(diff.Deletes) < DiffDetailLimit {
+					diff.Deletes = append(diff.Deletes, key)
+				}
+			}
+		default:
+			change := models.RowChange{Key: key, Changes: map[string]models.ValueChange{}}
+			for i, column := range compared {
+				if changed[i] {
+					change.Changes[column] = models.ValueChange{From: nullStringPtr(before[i]), To: nullStringPtr(after[i])}
+					diff.ColumnChanges[column]++
+				}
+			}
+			if !existingActive {
+				change.Changes["deleted_at"] = models.ValueChange{From: nullStringPtr(existingDeletedAt)}
+				diff.ColumnChanges["deleted_at"]++
+			}
+			if len(change.Changes) > 0 {
+				diff.Updated++
+				if len(diff.Updates) < DiffDetailLimit {
+					diff.Updates = append(diff.Updates, change)
+				}
+			}
+		}
+	}
+	return diff, rows.Err()
+}
+
+func nullStringPtr(value sql.NullString) *string {
+	if !value.Valid {
+		return nil
+	}
+	return &value.String
+}
@@ -0,0 +1,350 @@
+package repository
+
+import (
+	"backend/external/loyverse/models"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// RefreshStats สรุปผลการ refresh ของตารางหนึ่ง
+type RefreshStats struct {
+	Upserted int64 `json:"upserted"`
+	Deleted  int64 `json:"deleted"`
+}
+
+// stagingTable อธิบายตาราง master data หนึ่งตารางที่จะ refresh ผ่าน staging table
+// columns คือคอลัมน์ที่ข้อมูลมาจาก Loyverse เท่านั้น (คอลัมน์แรกเป็น key)
+// คอลัมน์อื่นของตารางจริง เช่น order_cycle ของ loysuppliers จะไม่ถูกแตะต้อง
+// ค่าสุดท้ายของแต่ละแถวใน rows คือ deleted_at จาก Loyverse (nil = ยังใช้งานอยู่)
+type stagingTable struct {
+	target  string
+	columns []string
+	types   []string
+	rows    [][]interface{}
+}
+
+func (t stagingTable) key() string {
+	return t.columns[0]
+}
+
+func (t stagingTable) stage() string {
+	return "stage_" + t.target
+}
+
+// RefreshMasterData โหลด master data ของ merchant ลง staging table แล้ว merge เข้าตารางจริงภายใน transaction เดียว
+// แถวที่มีอยู่จะถูกอัปเดตเฉพาะคอลัมน์จาก API รวมถึง deleted_at ที่ได้จาก show_deleted
+// แถวของ merchant นี้ที่ไม่มีใน API เลยจะถูก soft-delete ด้วยเวลาปัจจุบัน
+// ถ้าเกิด error ระหว่างทาง ข้อมูลเดิมจะยังอยู่ครบ
+func RefreshMasterData(db *sql.DB, merchantID string, data models.LoyMasterData) (map[string]RefreshStats, error) {
+	tables, err := masterDataStagingTables(data)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Println("Failed to begin transaction:", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stats := make(map[string]RefreshStats, len(tables))
+	for _, table := range tables {
+		tableStats, err := refreshTable(tx, merchantID, table)
+		if err != nil {
+			log.Printf("Error refreshing %s: %v", table.target, err)
+			return nil, err
+		}
+		stats[table.target] = tableStats
+		log.Printf("Refreshed %s: %d upserted, %d soft-deleted", table.target, tableStats.Upserted, tableStats.Deleted)
+	}
+	if err := saveVariantStores(tx, data.Items); err != nil {
+		log.Println("Error refreshing loyvariant_stores:", err)
+		return nil, err
+	}
+	if err := saveModifierOptions(tx, data.Modifiers); err != nil {
+		log.Println("Error refreshing loymodifier_options:", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("Error committing master data refresh:", err)
+		return nil, err
+	}
+	log.Println("Master data refreshed successfully.")
+	return stats, nil
+}
+
+// loadStagingTable สร้าง staging table ของตารางแล้วโหลดแถวจาก API ลงไป คืนค่ารายชื่อคอลัมน์ (รวม deleted_at)
+// staging table ถูกลบอัตโนมัติเมื่อ transaction จบ
+func loadStagingTable(tx *sql.Tx, table stagingTable) ([]string, error) {
+	columns := append(append([]string{}, table.columns...), "deleted_at")
+	types := append(append([]string{}, table.types...), "TIMESTAMPTZ")
+
+	columnDefs := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	for i, column := range columns {
+		columnDefs[i] = column + " " + types[i]
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+
+	if _, err := tx.Exec(fmt.Sprintf("CREATE TEMP TABLE %s (%s) ON COMMIT DROP", table.stage(), strings.Join(columnDefs, ", "))); err != nil {
+		return nil, err
+	}
+
+	stmt, err := tx.Prepare(fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table.stage(), strings.Join(columns, ", "), strings.Join(placeholders, ", ")))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+	for _, row := range table.rows {
+		if _, err := stmt.Exec(row...); err != nil {
+			return nil, err
+		}
+	}
+	return columns, nil
+}
+
+func refreshTable(tx *sql.Tx, merchantID string, table stagingTable) (RefreshStats, error) {
+	var stats RefreshStats
+
+	columns, err := loadStagingTable(tx, table)
+	if err != nil {
+		return stats, err
+	}
+	updates := make([]string, 0, len(columns))
+	for _, column := range columns[1:] {
+		updates = append(updates, column+" = EXCLUDED."+column)
+	}
+	updates = append(updates, "merchant_id = EXCLUDED.merchant_id")
+	columnList := strings.Join(columns, ", ")
+
+	// นับแถวที่ Loyverse ลบไปตั้งแต่ refresh ครั้งก่อน (ได้มาพร้อม deleted_at จาก show_deleted)
+	if err := tx.QueryRow(fmt.Sprintf(`
+		SELECT COUNT(DISTINCT s.%[3]s) FROM %[2]s s
+		JOIN %[1]s t ON t.%[3]s = s.%[3]s
+		WHERE t.merchant_id = $1 AND t.deleted_at IS NULL AND s.deleted_at IS NOT NULL`,
+		table.target, table.stage(), table.key()), merchantID).Scan(&stats.Deleted); err != nil {
+		return stats, err
+	}
+
+	// merge ลงตารางจริง (DISTINCT ON กันกรณี API ส่ง id ซ้ำมาในหลายหน้า)
+	result, err := tx.Exec(fmt.Sprintf(`
+		INSERT INTO %[1]s (merchant_id, %[3]s)
+		SELECT DISTINCT ON (%[4]s) $1, %[3]s FROM %[2]s
+		ON CONFLICT (%[4]s) DO UPDATE SET %[5]s`,
+		table.target, table.stage(), columnList, table.key(), strings.Join(updates, ", ")), merchantID)
+	if err != nil {
+		return stats, err
+	}
+	stats.Upserted, _ = result.RowsAffected()
+
+	// soft-delete แถวของ merchant นี้ที่ไม่มีใน API แล้ว
+	result, err = tx.Exec(fmt.Sprintf(`
+		UPDATE %[1]s SET deleted_at = NOW()
+		WHERE merchant_id = $1 AND deleted_at IS NULL
+		AND NOT EXISTS (SELECT 1 FROM %[2]s s WHERE s.%[3]s = %[1]s.%[3]s)`,
+		table.target, table.stage(), table.key()), merchantID)
+	if err != nil {
+		return stats, err
+	}
+	missing, _ := result.RowsAffected()
+	stats.Deleted += missing
+
+	return stats, nil
+}
+
+// masterDataStagingTables แปลง master data เป็นแถวสำหรับ staging table ของแต่ละตาราง
+func masterDataStagingTables(data models.LoyMasterData) ([]stagingTable, error) {
+	categories := stagingTable{
+		target:  "loycategories",
+		columns: []string{"category_id", "name"},
+		types:   []string{"TEXT", "TEXT"},
+	}
+	for _, category := range data.Categories {
+		if category.CategoryID == "" {
+			log.Println("Skipping category with empty category_id")
+			continue
+		}
+		categories.rows = append(categories.rows, []interface{}{category.CategoryID, category.Name, category.DeletedAt})
+	}
+
+	items := stagingTable{
+		target:  "loyitems",
+		columns: []string{"item_id", "item_name", "description", "category_id", "primary_supplier_id", "image_url", "variants", "is_composite", "use_production", "option1_name", "option2_name", "option3_name"},
+		types:   []string{"TEXT", "TEXT", "TEXT", "TEXT", "TEXT", "TEXT", "JSONB", "BOOLEAN", "BOOLEAN", "TEXT", "TEXT", "TEXT"},
+	}
+	// loyvariants ต้อง refresh หลัง loyitems เพราะมี foreign key ไปยังสินค้า
+	variants := stagingTable{
+		target:  "loyvariants",
+		columns: variantColumns,
+		types:   variantColumnTypes,
+	}
+	for _, item := range data.Items {
+		// แปลง `Variants` ให้เป็น JSONB
+		variantsJSON, err := json.Marshal(item.Variants)
+		if err != nil {
+			log.Println("Error marshalling variants:", err)
+			return nil, err
+		}
+		items.rows = append(items.rows, []interface{}{
+			item.ID, item.ItemName, item.Description, item.CategoryID, item.PrimarySupplierID, item.ImageURL, variantsJSON, item.IsComposite, item.UseProduction,
+			item.Option1Name, item.Option2Name, item.Option3Name, item.DeletedAt,
+		})
+		for _, variant := range item.Variants {
+			if variant.VariantID == "" {
+				continue
+			}
+			variants.rows = append(variants.rows, append(variantRow(item, variant), variantDeletedAt(item, variant)))
+		}
+	}
+
+	paymentTypes := stagingTable{
+		target:  "loypaymenttypes",
+		columns: []string{"payment_type_id", "name"},
+		types:   []string{"TEXT", "TEXT"},
+	}
+	for _, paymentType := range data.PaymentTypes {
+		if paymentType.PaymentTypeID == "" {
+			log.Println("PaymentTypeID is empty, skipping this payment type.")
+			continue
+		}
+		paymentTypes.rows = append(paymentTypes.rows, []interface{}{paymentType.PaymentTypeID, paymentType.Name, paymentType.DeletedAt})
+	}
+
+	taxes := stagingTable{
+		target:  "loytaxes",
+		columns: []string{"tax_id", "type", "name", "rate", "stores", "created_at", "updated_at"},
+		types:   []string{"TEXT", "TEXT", "TEXT", "NUMERIC", "JSONB", "TIMESTAMPTZ", "TIMESTAMPTZ"},
+	}
+	for _, tax := range data.Taxes {
+		storesJSON, err := json.Marshal(tax.Stores)
+		if err != nil {
+			log.Println("Error marshalling tax stores:", err)
+			return nil, err
+		}
+		taxes.rows = append(taxes.rows, []interface{}{tax.TaxID, tax.Type, tax.Name, tax.Rate, storesJSON, tax.CreatedAt, tax.UpdatedAt, tax.DeletedAt})
+	}
+
+	discounts := stagingTable{
+		target:  "loydiscounts",
+		columns: []string{"discount_id", "type", "name", "discount_amount", "discount_percent", "stores", "restricted_access", "created_at", "updated_at"},
+		types:   []string{"TEXT", "TEXT", "TEXT", "NUMERIC", "NUMERIC", "JSONB", "BOOLEAN", "TIMESTAMPTZ", "TIMESTAMPTZ"},
+	}
+	for _, discount := range data.Discounts {
+		storesJSON, err := json.Marshal(discount.Stores)
+		if err != nil {
+			log.Println("Error marshalling discount stores:", err)
+			return nil, err
+		}
+		discounts.rows = append(discounts.rows, []interface{}{
+			discount.DiscountID, discount.Type, discount.Name, discount.DiscountAmount, discount.DiscountPercent, storesJSON,
+			discount.RestrictedAccess, discount.CreatedAt, discount.UpdatedAt, discount.DeletedAt,
+		})
+	}
+
+	// ตัวเลือกของ modifier ถูกแทนที่ทั้งชุดหลัง merge (ดู saveModifierOptions)
+	modifiers := stagingTable{
+		target:  "loymodifiers",
+		columns: []string{"modifier_id", "name", "position", "stores", "created_at", "updated_at"},
+		types:   []string{"TEXT", "TEXT", "INTEGER", "JSONB", "TIMESTAMPTZ", "TIMESTAMPTZ"},
+	}
+	for _, modifier := range data.Modifiers {
+		storesJSON, err := json.Marshal(modifier.Stores)
+		if err != nil {
+			log.Println("Error marshalling modifier stores:", err)
+			return nil, err
+		}
+		modifiers.rows = append(modifiers.rows, []interface{}{
+			modifier.ModifierID, modifier.Name, modifier.Position, storesJSON, modifier.CreatedAt, modifier.UpdatedAt, modifier.DeletedAt,
+		})
+	}
+
+	stores := stagingTable{
+		target:  "loystores",
+		columns: []string{"store_id", "store_name"},
+		types:   []string{"TEXT", "TEXT"},
+	}
+	for _, store := range data.Stores {
+		stores.rows = append(stores.rows, []interface{}{store.StoreID, store.StoreName, store.DeletedAt})
+	}
+
+	employees := stagingTable{
+		target:  "loyemployees",
+		columns: []string{"employee_id", "name", "email", "phone_number", "stores", "is_owner", "created_at", "updated_at"},
+		types:   []string{"TEXT", "TEXT", "TEXT", "TEXT", "JSONB", "BOOLEAN", "TIMESTAMPTZ", "TIMESTAMPTZ"},
+	}
+	for _, employee := range data.Employees {
+		storesJSON, err := json.Marshal(employee.Stores)
+		if err != nil {
+			log.Println("Error marshalling employee stores:", err)
+			return nil, err
+		}
+		employees.rows = append(employees.rows, []interface{}{
+			employee.EmployeeID, employee.Name, employee.Email, employee.PhoneNumber, storesJSON, employee.IsOwner,
+			employee.CreatedAt, employee.UpdatedAt, employee.DeletedAt,
+		})
+	}
+
+	posDevices := stagingTable{
+		target:  "loyposdevices",
+		columns: []string{"pos_device_id", "name", "store_id", "activated", "created_at", "updated_at"},
+		types:   []string{"TEXT", "TEXT", "TEXT", "BOOLEAN", "TIMESTAMPTZ", "TIMESTAMPTZ"},
+	}
+	for _, device := range data.PosDevices {
+		posDevices.rows = append(posDevices.rows, []interface{}{
+			device.PosDeviceID, device.Name, device.StoreID, device.Activated, device.CreatedAt, device.UpdatedAt, device.DeletedAt,
+		})
+	}
+
+	// loysuppliers: order_cycle, selected_days และ sort_order เป็นค่าที่ทีมตั้งเอง จึงไม่อยู่ใน columns
+	suppliers := stagingTable{
+		target:  "loysuppliers",
+		columns: []string{"supplier_id", "supplier_name"},
+		types:   []string{"TEXT", "TEXT"},
+	}
+	for _, supplier := range data.Suppliers {
+		suppliers.rows = append(suppliers.rows, []interface{}{supplier.SupplierID, supplier.SupplierName, supplier.DeletedAt})
+	}
+
+	customers := stagingTable{
+		target:  "loycustomers",
+		columns: customerColumns,
+		types:   customerColumnTypes,
+	}
+	for _, customer := range data.Customers {
+		customers.rows = append(customers.rows, append(customerRow(customer), customer.DeletedAt))
+	}
+
+	return []stagingTable{categories, items, variants, paymentTypes, taxes, discounts, modifiers, stores, employees, posDevices, suppliers, customers}, nil
+}
+
+// saveModifierOptions แทนที่ตัวเลือกทั้งหมดของแต่ละ modifier ด้วยชุดล่าสุดจาก Loyverse
+func saveModifierOptions(db execer, modifiers []models.LoyModifier) error {
+	for _, modifier := range modifiers {
+		if modifier.ModifierID == "" {
+			continue
+		}
+		if _, err := db.Exec(`DELETE FROM loymodifier_options WHERE modifier_id = $1`, modifier.ModifierID); err != nil {
+			return err
+		}
+		for _, option := range modifier.ModifierOptions {
+			if option.ModifierOptionID == "" {
+				continue
+			}
+			_, err := db.Exec(`
+				INSERT INTO loymodifier_options (modifier_option_id, modifier_id, name, price, position)
+				VALUES ($1, $2, $3, $4, $5)
+				ON CONFLICT (modifier_option_id) DO UPDATE
+				SET modifier_id = $2, name = $3, price = $4, position = $5`,
+				option.ModifierOptionID, modifier.ModifierID, option.Name, option.Price, option.Position)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
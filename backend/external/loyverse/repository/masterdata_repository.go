@@ -7,41 +7,8 @@ import (
 	"log"
 )
 
-// ClearOldData เคลียร์ข้อมูลเก่าในตารางที่เกี่ยวข้อง
-func ClearOldMasterData(db *sql.DB) error {
-	_, err := db.Exec("TRUNCATE TABLE loycategories, loyitems, loypaymenttypes, loystores, loysuppliers RESTART IDENTITY")
-	if err != nil {
-		log.Println("Error clearing old data:", err)
-		return err
-	}
-	log.Println("Old data cleared successfully.")
-	return nil
-}
-
-// SaveMasterData บันทึก master data ทั้งหมดลงฐานข้อมูล
-func SaveMasterData(db *sql.DB, data models.LoyMasterData) error {
-	if err := SaveCategories(db, data.Categories); err != nil {
-		return err
-	}
-	if err := SaveItems(db, data.Items); err != nil {
-		return err
-	}
-
-	if err := SavePaymentTypes(db, data.PaymentTypes); err != nil {
-		return err
-	}
-	if err := SaveStores(db, data.Stores); err != nil {
-		return err
-	}
-	if err := SaveSuppliers(db, data.Suppliers); err != nil {
-		return err
-	}
-	log.Println("Master data saved to database successfully.")
-	return nil
-}
-
-// SaveItems บันทึกข้อมูลสินค้า (items) ลงในฐานข้อมูล
-func SaveItems(db *sql.DB, items []models.LoyItem) error {
+// SaveItems บันทึกข้อมูลสินค้า (items) ของ merchant พร้อม variant และราคารายสาขาลงในฐานข้อมูล
+func SaveItems(db *sql.DB, merchantID string, items []models.LoyItem) error {
 	for _, item := range items {
 		// แปลง `Variants` ให้เป็น JSONB
 		variantsJSON, err := json.Marshal(item.Variants)
@@ -51,26 +18,32 @@ func SaveItems(db *sql.DB, items []models.LoyItem) error {
 		}
 
 		_, err = db.Exec(
-			`INSERT INTO loyitems (item_id, item_name, description, category_id, primary_supplier_id, image_url, variants, is_composite, use_production) 
-            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) 
+			`INSERT INTO loyitems (item_id, item_name, description, category_id, primary_supplier_id, image_url, variants, is_composite, use_production, merchant_id, deleted_at, option1_name, option2_name, option3_name) 
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) 
             ON CONFLICT (item_id) DO UPDATE 
-            SET item_name = $2, description = $3, category_id = $4, primary_supplier_id = $5, image_url = $6, variants = $7, is_composite = $8, use_production = $9`,
-			item.ID, item.ItemName, item.Description, item.CategoryID, item.PrimarySupplierID, item.ImageURL, variantsJSON, item.IsComposite, item.UseProduction,
+            SET item_name = $2, description = $3, category_id = $4, primary_supplier_id = $5, image_url = $6, variants = $7, is_composite = $8, use_production = $9, merchant_id = $10, deleted_at = $11,
+                option1_name = $12, option2_name = $13, option3_name = $14`,
+			item.ID, item.ItemName, item.Description, item.CategoryID, item.PrimarySupplierID, item.ImageURL, variantsJSON, item.IsComposite, item.UseProduction, merchantID, item.DeletedAt,
+			item.Option1Name, item.Option2Name, item.Option3Name,
 		)
 		if err != nil {
 			log.Println("Error saving item:", err)
 			return err
 		}
+		if err := saveVariants(db, merchantID, item); err != nil {
+			log.Printf("Error saving variants of item %s: %v", item.ID, err)
+			return err
+		}
 	}
 	log.Println("Items saved successfully.")
 	return nil
 }
 
-// SaveSuppliers บันทึกข้อมูลซัพพลายเออร์ (suppliers) ลงในฐานข้อมูล
-func SaveSuppliers(db *sql.DB, suppliers []models.LoySupplier) error {
+// SaveSuppliers บันทึกข้อมูลซัพพลายเออร์ (suppliers) ของ merchant ลงในฐานข้อมูล
+func SaveSuppliers(db *sql.DB, merchantID string, suppliers []models.LoySupplier) error {
 	for _, supplier := range suppliers {
-		_, err := db.Exec("INSERT INTO loysuppliers (supplier_id, supplier_name) VALUES ($1, $2) ON CONFLICT (supplier_id) DO UPDATE SET supplier_name = $2",
-			supplier.SupplierID, supplier.SupplierName)
+		_, err := db.Exec("INSERT INTO loysuppliers (supplier_id, supplier_name, merchant_id, deleted_at) VALUES ($1, $2, $3, $4) ON CONFLICT (supplier_id) DO UPDATE SET supplier_name = $2, merchant_id = $3, deleted_at = $4",
+			supplier.SupplierID, supplier.SupplierName, merchantID, supplier.DeletedAt)
 		if err != nil {
 			log.Println("Error saving supplier:", err)
 			return err
@@ -80,19 +53,19 @@ func SaveSuppliers(db *sql.DB, suppliers []models.LoySupplier) error {
 	return nil
 }
 
-// SaveCategories บันทึกข้อมูลหมวดหมู่ (categories) ลงในฐานข้อมูล
-func SaveCategories(db *sql.DB, categories []models.LoyCategory) error {
+// SaveCategories บันทึกข้อมูลหมวดหมู่ (categories) ของ merchant ลงในฐานข้อมูล
+func SaveCategories(db *sql.DB, merchantID string, categories []models.LoyCategory) error {
 	for _, category := range categories {
 		if category.CategoryID == "" {
 			log.Println("Skipping category with empty category_id")
 			continue
 		}
 		_, err := db.Exec(`
-			INSERT INTO loycategories (category_id, name)
-			VALUES ($1, $2)
+			INSERT INTO loycategories (category_id, name, merchant_id, deleted_at)
+			VALUES ($1, $2, $3, $4)
 			ON CONFLICT (category_id) DO UPDATE
-			SET name = $2`,
-			category.CategoryID, category.Name)
+			SET name = $2, merchant_id = $3, deleted_at = $4`,
+			category.CategoryID, category.Name, merchantID, category.DeletedAt)
 		if err != nil {
 			log.Println("Error saving category:", err)
 			return err
@@ -102,15 +75,15 @@ func SaveCategories(db *sql.DB, categories []models.LoyCategory) error {
 	return nil
 }
 
-// SaveStores บันทึกข้อมูลสาขา (stores) ลงในฐานข้อมูล
-func SaveStores(db *sql.DB, stores []models.LoyStore) error {
+// SaveStores บันทึกข้อมูลสาขา (stores) ของ merchant ลงในฐานข้อมูล
+func SaveStores(db *sql.DB, merchantID string, stores []models.LoyStore) error {
 	for _, store := range stores {
 		_, err := db.Exec(`
-			INSERT INTO loystores (store_id, store_name)
-			VALUES ($1, $2)
+			INSERT INTO loystores (store_id, store_name, merchant_id, deleted_at)
+			VALUES ($1, $2, $3, $4)
 			ON CONFLICT (store_id) DO UPDATE 
-			SET store_name = $2`,
-			store.StoreID, store.StoreName,
+			SET store_name = $2, merchant_id = $3, deleted_at = $4`,
+			store.StoreID, store.StoreName, merchantID, store.DeletedAt,
 		)
 		if err != nil {
 			log.Printf("Error saving store %s: %v", store.StoreID, err)
@@ -121,16 +94,16 @@ func SaveStores(db *sql.DB, stores []models.LoyStore) error {
 	return nil
 }
 
-// SavePaymentTypes บันทึกข้อมูลประเภทการชำระเงิน (paymentTypes) ลงในฐานข้อมูล
-func SavePaymentTypes(db *sql.DB, paymentTypes []models.LoyPaymentType) error {
+// SavePaymentTypes บันทึกข้อมูลประเภทการชำระเงิน (paymentTypes) ของ merchant ลงในฐานข้อมูล
+func SavePaymentTypes(db *sql.DB, merchantID string, paymentTypes []models.LoyPaymentType) error {
 	for _, paymenttype := range paymentTypes {
 		if paymenttype.PaymentTypeID == "" { // ตรวจสอบให้แน่ใจว่า PaymentTypeID ไม่ว่าง
 			log.Println("PaymentTypeID is empty, skipping this payment type.")
 			continue
 		}
 
-		_, err := db.Exec("INSERT INTO loypaymenttypes (payment_type_id, name) VALUES ($1, $2) ON CONFLICT (payment_type_id) DO UPDATE SET name = $2",
-			paymenttype.PaymentTypeID, paymenttype.Name)
+		_, err := db.Exec("INSERT INTO loypaymenttypes (payment_type_id, name, merchant_id, deleted_at) VALUES ($1, $2, $3, $4) ON CONFLICT (payment_type_id) DO UPDATE SET name = $2, merchant_id = $3, deleted_at = $4",
+			paymenttype.PaymentTypeID, paymenttype.Name, merchantID, paymenttype.DeletedAt)
 		if err != nil {
 			log.Println("Error saving payment type:", err)
 			return err
@@ -139,17 +112,3 @@ func SavePaymentTypes(db *sql.DB, paymentTypes []models.LoyPaymentType) error {
 	log.Println("PaymentTypes saved successfully.")
 	return nil
 }
-
-// SaveCustomers บันทึกข้อมูล customers ลงในฐานข้อมูล
-func SaveCustomers(db *sql.DB, customers []models.LoyCustomer) error {
-	for _, customer := range customers {
-		_, err := db.Exec("INSERT INTO loycustomers (customer_id, name, email, phone_number) VALUES ($1, $2, $3, $4) ON CONFLICT (customer_id) DO UPDATE SET name = $2, email = $3, phone_number = $4",
-			customer.CustomerID, customer.Name, customer.Email, customer.PhoneNumber)
-		if err != nil {
-			log.Println("Error saving customer:", err)
-			return err
-		}
-	}
-	log.Println("Customers saved successfully.")
-	return nil
-}
@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"backend/external/loyverse/models"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ErrOutboundClaimLost คือรายการที่ถูก worker อื่นจองต่อไปแล้วระหว่างที่กำลังส่ง
+var ErrOutboundClaimLost = errors.New("outbound change was claimed by another worker")
+
+// outboundChangeColumns คอลัมน์ที่ใช้ scan ลง models.OutboundChange
+const outboundChangeColumns = `id, merchant_id, change_type, entity_id, payload, reason, status, attempts, last_error, request_body, response_body, created_at, next_attempt_at, sent_at`
+
+// EnqueueOutboundChange เพิ่มการเปลี่ยนแปลงของ merchant ลงคิวเพื่อรอ worker ส่งไปยัง Loyverse
+func EnqueueOutboundChange(db *sql.DB, merchantID, changeType, entityID string, payload []byte, reason string) (models.OutboundChange, error) {
+	var change models.OutboundChange
+	row := db.QueryRow(`
+		INSERT INTO outbound_changes (merchant_id, change_type, entity_id, payload, reason, status, next_attempt_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, NOW())
+		RETURNING `+outboundChangeColumns,
+		merchantID, changeType, entityID, payload, reason, models.OutboundStatusPending,
+	)
+	if err := scanOutboundChange(row, &change); err != nil {
+		log.Println("Error enqueueing outbound change:", err)
+		return change, err
+	}
+	return change, nil
+}
+
+// ClaimDueOutboundChanges จองรายการที่ถึงเวลาส่ง เหมือน ClaimDueWebhookEvents
+// รายการของ entity เดียวกันจะถูกส่งตามลำดับที่เข้าคิว: รายการที่ยังมีรายการก่อนหน้าค้างอยู่จะยังไม่ถูกจอง
+// เพื่อไม่ให้การ retry ของรายการเก่าเขียนทับค่าที่ใหม่กว่าใน Loyverse
+func ClaimDueOutboundChanges(db *sql.DB, limit int, lease time.Duration) ([]models.OutboundChange, error) {
+	rows, err := db.Query(`
+		UPDATE outbound_changes
+		SET status = $1, attempts = attempts + 1, next_attempt_at = NOW() + $2 * INTERVAL '1 second'
+		WHERE id IN (
+			SELECT c.id FROM outbound_changes c
+			WHERE c.status IN ($3, $4, $1) AND c.next_attempt_at <= NOW()
+			AND NOT EXISTS (
+				SELECT 1 FROM outbound_changes prev
+				WHERE prev.merchant_id = c.merchant_id AND prev.change_type = c.change_type AND prev.entity_id = c.entity_id
+				AND prev.id < c.id AND prev.status IN ($3, $4, $1)
+			)
+			ORDER BY c.id
+			LIMIT $5
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+outboundChangeColumns,
+		models.OutboundStatusProcessing, lease.Seconds(), models.OutboundStatusPending, models.OutboundStatusFailed, limit,
+	)
+	if err != nil {
+		log.Println("Error claiming outbound changes:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []models.OutboundChange
+	for rows.Next() {
+		var change models.OutboundChange
+		if err := scanOutboundChange(rows, &change); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
+
+// FreezeOutboundPayload แทนที่ payload ของรายการที่จองไว้ด้วยค่าที่คำนวณแล้ว (เช่น stock_after แบบค่าสัมบูรณ์)
+// ก่อนส่งครั้งแรก เพื่อให้ทุกครั้งที่ retry ส่งค่าเดียวกัน
+// ถ้ารายการถูกจองใหม่โดย worker อื่นไปแล้ว (หมด lease) จะไม่แก้ไขและคืนค่า ErrOutboundClaimLost
+func FreezeOutboundPayload(db *sql.DB, change models.OutboundChange, payload []byte) error {
+	result, err := db.Exec(`
+		UPDATE outbound_changes
+		SET payload = $3
+		WHERE id = $1 AND status = $2 AND attempts = $4`,
+		change.ID, models.OutboundStatusProcessing, payload, change.Attempts,
+	)
+	if err != nil {
+		log.Println("Error freezing outbound change payload:", err)
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("outbound change %d: %w", change.ID, ErrOutboundClaimLost)
+	}
+	return nil
+}
+
+// MarkOutboundChangeSent บันทึก body ที่ส่งและ response หลังจาก Loyverse ยืนยันการเปลี่ยนแปลง
+func MarkOutboundChangeSent(db *sql.DB, id int64, requestBody, responseBody []byte) error {
+	_, err := db.Exec(`
+		UPDATE outbound_changes
+		SET status = $2, sent_at = NOW(), last_error = NULL, request_body = $3, response_body = $4
+		WHERE id = $1`,
+		id, models.OutboundStatusSent, nullJSON(requestBody), nullJSON(responseBody),
+	)
+	if err != nil {
+		log.Println("Error marking outbound change sent:", err)
+	}
+	return err
+}
+
+// MarkOutboundChangeFailed บันทึก error และกำหนดเวลาลองใหม่
+// ถ้า dead เป็น true รายการจะถูกย้ายไปสถานะ dead และจะไม่ถูกลองใหม่อัตโนมัติ
+func MarkOutboundChangeFailed(db *sql.DB, id int64, cause error, requestBody []byte, retryAfter time.Duration, dead bool) error {
+	status := models.OutboundStatusFailed
+	if dead {
+		status = models.OutboundStatusDead
+	}
+	_, err := db.Exec(`
+		UPDATE outbound_changes
+		SET status = $2, last_error = $3, request_body = COALESCE($4, request_body), next_attempt_at = NOW() + $5 * INTERVAL '1 second'
+		WHERE id = $1`,
+		id, status, cause.Error(), nullJSON(requestBody), retryAfter.Seconds(),
+	)
+	if err != nil {
+		log.Println("Error marking outbound change failed:", err)
+	}
+	return err
+}
+
+// ListOutboundChanges แสดงรายการล่าสุดของ merchant กรองตามสถานะและประเภทได้ (ว่าง = ทั้งหมด)
+func ListOutboundChanges(db *sql.DB, merchantID, status, changeType string, limit int) ([]models.OutboundChange, error) {
+	rows, err := db.Query(`
+		SELECT `+outboundChangeColumns+`
+		FROM outbound_changes
+		WHERE merchant_id = $1 AND ($2 = '' OR status = $2) AND ($3 = '' OR change_type = $3)
+		ORDER BY id DESC
+		LIMIT $4`,
+		merchantID, status, changeType, limit,
+	)
+	if err != nil {
+		log.Println("Error listing outbound changes:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []models.OutboundChange{}
+	for rows.Next() {
+		var change models.OutboundChange
+		if err := scanOutboundChange(rows, &change); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
+
+// RetryOutboundChange ส่งรายการที่ล้มเหลวกลับเข้าคิวเพื่อส่งใหม่ทันที
+// รายการที่ส่งสำเร็จแล้วจะไม่ถูกส่งซ้ำ
+func RetryOutboundChange(db *sql.DB, id int64) error {
+	result, err := db.Exec(`
+		UPDATE outbound_changes
+		SET status = $2, attempts = 0, last_error = NULL, next_attempt_at = NOW()
+		WHERE id = $1 AND status IN ($3, $4)`,
+		id, models.OutboundStatusPending, models.OutboundStatusFailed, models.OutboundStatusDead,
+	)
+	if err != nil {
+		log.Println("Error retrying outbound change:", err)
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("failed outbound change %d not found: %w", id, sql.ErrNoRows)
+	}
+	return nil
+}
+
+// IsInventoryEcho ตรวจว่า inventory level ที่ได้จาก webhook เป็นผลจากการเปลี่ยนแปลงที่เราส่งไปเอง
+// ภายในช่วงเวลา window หรือไม่ (variant/store เดียวกันและ stock_after ตรงกัน)
+func IsInventoryEcho(db *sql.DB, merchantID string, level models.LoyInventoryLevel, window time.Duration) (bool, error) {
+	var echo bool
+	err := db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM outbound_changes
+			WHERE merchant_id = $6 AND change_type = $1 AND entity_id = $2 AND status = $3
+			AND sent_at > NOW() - $4 * INTERVAL '1 second'
+			AND (request_body->'inventory_levels'->0->>'stock_after')::NUMERIC = $5
+		)`,
+		models.OutboundTypeInventory, InventoryEntityID(level.VariantID, level.StoreID), models.OutboundStatusSent, window.Seconds(), level.InStock, merchantID,
+	).Scan(&echo)
+	if err != nil {
+		log.Println("Error checking inventory echo:", err)
+	}
+	return echo, err
+}
+
+// InventoryEntityID คือ entity_id ของการปรับสต็อก ซึ่งระบุด้วย variant และ store คู่กัน
+func InventoryEntityID(variantID, storeID string) string {
+	return variantID + ":" + storeID
+}
+
+// nullJSON แปลง body ว่างเป็น NULL เพื่อไม่ให้ JSONB column ได้รับค่าที่ parse ไม่ได้
+func nullJSON(body []byte) interface{} {
+	if len(body) == 0 {
+		return nil
+	}
+	return body
+}
+
+// scanOutboundChange อ่าน outboundChangeColumns ลงใน change
+func scanOutboundChange(row rowScanner, change *models.OutboundChange) error {
+	var payload, requestBody, responseBody []byte
+	var reason, lastError sql.NullString
+	var nextAttemptAt, sentAt sql.NullTime
+	err := row.Scan(
+		&change.ID, &change.MerchantID, &change.ChangeType, &change.EntityID, &payload, &reason, &change.Status, &change.Attempts,
+		&lastError, &requestBody, &responseBody, &change.CreatedAt, &nextAttemptAt, &sentAt,
+	)
+	if err != nil {
+		return err
+	}
+	change.Payload = payload
+	change.RequestBody = requestBody
+	change.ResponseBody = responseBody
+	if reason.Valid {
+		change.Reason = &reason.String
+	}
+	if lastError.Valid {
+		change.LastError = &lastError.String
+	}
+	if nextAttemptAt.Valid {
+		change.NextAttemptAt = &nextAttemptAt.Time
+	}
+	if sentAt.Valid {
+		change.SentAt = &sentAt.Time
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"backend/external/loyverse/models"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+)
+
+const receiptBackfillColumns = `id, merchant_id, store_id, from_at, to_at, cursor, status, pages_fetched, receipts_saved, sync_run_id, error, created_at, updated_at, finished_at`
+
+// ReceiptBackfillLease คือเวลาที่ backfill สถานะ running ต้องไม่มีความคืบหน้า (updated_at ไม่ขยับ)
+// ก่อนจะถือว่า process ที่ทำอยู่หยุดไปแล้วและให้ runner อื่นทำต่อได้
+const ReceiptBackfillLease = 15 * time.Minute
+
+// ErrReceiptBackfillRunning คือ backfill ของช่วงเดียวกันที่ยังมี runner อื่นทำอยู่
+var ErrReceiptBackfillRunning = errors.New("receipt backfill for this range is already running")
+
+// StartReceiptBackfill เริ่ม backfill ของช่วงวันที่และสาขาที่ระบุ
+// ถ้าช่วงเดียวกันเคยเริ่มแล้วแต่ยังไม่สำเร็จ (ล้มเหลว หรือ running แต่ไม่มีความคืบหน้าเกิน ReceiptBackfillLease
+// เพราะ process ถูกหยุดกลางคัน) จะจองรายการเดิมพร้อม cursor ล่าสุดและคืนค่า resumed = true แทนการสร้างรายการใหม่
+// ถ้ายังมี runner อื่นทำช่วงนี้อยู่จะคืนค่า ErrReceiptBackfillRunning
+func StartReceiptBackfill(db *sql.DB, merchantID, storeID string, from, to time.Time, syncRunID int64) (*models.ReceiptBackfill, bool, error) {
+	backfill, err := scanReceiptBackfill(db.QueryRow(`
+		UPDATE receipt_backfills SET status = $6, sync_run_id = $7, error = NULL, updated_at = NOW()
+		WHERE id = (
+			SELECT b.id FROM receipt_backfills b
+			WHERE b.merchant_id = $1 AND b.store_id = $2 AND b.from_at = $3 AND b.to_at = $4
+			AND (b.status = $5 OR (b.status = $6 AND b.updated_at < NOW() - $8 * INTERVAL '1 second'))
+			AND NOT EXISTS (
+				SELECT 1 FROM receipt_backfills active
+				WHERE active.merchant_id = b.merchant_id AND active.store_id = b.store_id
+				AND active.from_at = b.from_at AND active.to_at = b.to_at
+				AND active.status = $6 AND active.updated_at >= NOW() - $8 * INTERVAL '1 second'
+			)
+			ORDER BY b.status = $6 DESC, b.id DESC
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+receiptBackfillColumns,
+		merchantID, storeID, from, to, models.SyncStatusFailed, models.SyncStatusRunning, syncRunID, ReceiptBackfillLease.Seconds()))
+	if err == nil {
+		return backfill, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		log.Println("Error resuming receipt backfill:", err)
+		return nil, false, err
+	}
+
+	// unique index ของรายการ running ทำให้มี runner ได้แค่ตัวเดียวต่อช่วง แม้จะเริ่มพร้อมกัน
+	backfill, err = scanReceiptBackfill(db.QueryRow(`
+		INSERT INTO receipt_backfills (merchant_id, store_id, from_at, to_at, status, sync_run_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (merchant_id, store_id, from_at, to_at) WHERE status = 'running' DO NOTHING
+		RETURNING `+receiptBackfillColumns,
+		merchantID, storeID, from, to, models.SyncStatusRunning, syncRunID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, ErrReceiptBackfillRunning
+	}
+	if err != nil {
+		log.Println("Error starting receipt backfill:", err)
+		return nil, false, err
+	}
+	return backfill, false, nil
+}
+
+// SaveReceiptBackfillProgress บันทึก cursor ของหน้าถัดไปและ counters หลังบันทึกใบเสร็จแต่ละหน้าเสร็จ
+func SaveReceiptBackfillProgress(db *sql.DB, backfill *models.ReceiptBackfill) error {
+	_, err := db.Exec(`
+		UPDATE receipt_backfills SET cursor = $2, pages_fetched = $3, receipts_saved = $4, updated_at = NOW()
+		WHERE id = $1`,
+		backfill.ID, backfill.Cursor, backfill.PagesFetched, backfill.ReceiptsSaved)
+	if err != nil {
+		log.Println("Error saving receipt backfill progress:", err)
+	}
+	return err
+}
+
+// FinishReceiptBackfill บันทึกผลของ backfill (cursor ยังเก็บไว้เพื่อทำต่อถ้าล้มเหลว)
+func FinishReceiptBackfill(db *sql.DB, backfill *models.ReceiptBackfill, backfillErr error) error {
+	backfill.Status = models.SyncStatusSucceeded
+	var errorText sql.NullString
+	if backfillErr != nil {
+		backfill.Status = models.SyncStatusFailed
+		errorText = sql.NullString{String: backfillErr.Error(), Valid: true}
+	}
+
+	var finishedAt sql.NullTime
+	err := db.QueryRow(`
+		UPDATE receipt_backfills SET status = $2, error = $3, updated_at = NOW(),
+			finished_at = CASE WHEN $2 = 'succeeded' THEN NOW() END
+		WHERE id = $1
+		RETURNING finished_at`,
+		backfill.ID, backfill.Status, errorText).Scan(&finishedAt)
+	if err != nil {
+		log.Println("Error finishing receipt backfill:", err)
+		return err
+	}
+	if finishedAt.Valid {
+		backfill.FinishedAt = &finishedAt.Time
+	}
+	if errorText.Valid {
+		backfill.Error = &errorText.String
+	}
+	return nil
+}
+
+// ListReceiptBackfills แสดง backfill ล่าสุดของ merchant
+func ListReceiptBackfills(db *sql.DB, merchantID string, limit int) ([]models.ReceiptBackfill, error) {
+	rows, err := db.Query(`
+		SELECT `+receiptBackfillColumns+`
+		FROM receipt_backfills
+		WHERE merchant_id = $1
+		ORDER BY id DESC
+		LIMIT $2`, merchantID, limit)
+	if err != nil {
+		log.Println("Error listing receipt backfills:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	backfills := []models.ReceiptBackfill{}
+	for rows.Next() {
+		backfill, err := scanReceiptBackfill(rows)
+		if err != nil {
+			return nil, err
+		}
+		backfills = append(backfills, *backfill)
+	}
+	return backfills, rows.Err()
+}
+
+func scanReceiptBackfill(row rowScanner) (*models.ReceiptBackfill, error) {
+	var backfill models.ReceiptBackfill
+	var syncRunID sql.NullInt64
+	var errorText sql.NullString
+	var finishedAt sql.NullTime
+	err := row.Scan(
+		&backfill.ID, &backfill.MerchantID, &backfill.StoreID, &backfill.From, &backfill.To, &backfill.Cursor, &backfill.Status,
+		&backfill.PagesFetched, &backfill.ReceiptsSaved, &syncRunID, &errorText, &backfill.CreatedAt, &backfill.UpdatedAt, &finishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if syncRunID.Valid {
+		backfill.SyncRunID = &syncRunID.Int64
+	}
+	if errorText.Valid {
+		backfill.Error = &errorText.String
+	}
+	if finishedAt.Valid {
+		backfill.FinishedAt = &finishedAt.Time
+	}
+	return &backfill, nil
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"backend/external/loyverse/models"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// saveReceiptLines เขียน receipt_line_items และ receipt_payments ของใบเสร็จใหม่ทั้งชุดภายใน transaction เดียวกับใบเสร็จ
+// สินค้าหรือประเภทการชำระเงินที่ยังไม่เคย sync จะถูกสร้างเป็นแถวชั่วคราวเพื่อให้ foreign key ผ่าน
+// แล้ว master sync ครั้งถัดไปจะเติมข้อมูลที่เหลือให้
+func saveReceiptLines(tx *sql.Tx, merchantID string, receipt models.LoyReceipt) error {
+	receiptDate := receipt.ReceiptDate.In(time.UTC)
+
+	if _, err := tx.Exec(`DELETE FROM receipt_line_items WHERE merchant_id = $1 AND receipt_number = $2`, merchantID, receipt.ReceiptNumber); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM receipt_payments WHERE merchant_id = $1 AND receipt_number = $2`, merchantID, receipt.ReceiptNumber); err != nil {
+		return err
+	}
+
+	for i, line := range receipt.LineItems {
+		// variant ต้องอยู่ใต้สินค้า ถ้าไม่รู้สินค้าก็ไม่เก็บ variant
+		variantID := line.VariantID
+		if line.ItemID == "" {
+			variantID = ""
+		}
+		if line.ItemID != "" {
+			if _, err := tx.Exec(`
+				INSERT INTO loyitems (item_id, item_name, merchant_id) VALUES ($1, $2, $3)
+				ON CONFLICT (item_id) DO NOTHING`,
+				line.ItemID, line.ItemName, merchantID); err != nil {
+				return err
+			}
+		}
+		if variantID != "" {
+			if _, err := tx.Exec(`
+				INSERT INTO loyvariants (variant_id, item_id, merchant_id, sku) VALUES ($1, $2, $3, NULLIF($4, ''))
+				ON CONFLICT (variant_id) DO NOTHING`,
+				variantID, line.ItemID, merchantID, line.SKU); err != nil {
+				return err
+			}
+		}
+		taxes, err := json.Marshal(line.LineTaxes)
+		if err != nil {
+			return err
+		}
+		discounts, err := json.Marshal(line.LineDiscounts)
+		if err != nil {
+			return err
+		}
+		modifiers, err := json.Marshal(line.LineModifiers)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`
+			INSERT INTO receipt_line_items (
+				merchant_id, receipt_number, line_no, line_item_id, receipt_date, store_id, item_id, variant_id,
+				item_name, variant_name, sku, quantity, price, gross_total_money, total_money, total_discount,
+				cost, cost_total, line_note, line_taxes, line_discounts, line_modifiers
+			) VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)`,
+			merchantID, receipt.ReceiptNumber, i+1, line.ID, receiptDate, receipt.StoreID, line.ItemID, variantID,
+			line.ItemName, line.VariantName, line.SKU, line.Quantity, line.Price, line.GrossTotalMoney, line.TotalMoney, line.TotalDiscount,
+			line.Cost, line.CostTotal, line.LineNote, taxes, discounts, modifiers,
+		)
+		if err != nil {
+			return err
+		}
+		if err := saveLineAdjustments(tx, merchantID, receipt.ReceiptNumber, i+1, line); err != nil {
+			return err
+		}
+	}
+
+	for i, payment := range receipt.Payments {
+		if payment.PaymentTypeID != "" {
+			if _, err := tx.Exec(`
+				INSERT INTO loypaymenttypes (payment_type_id, name, merchant_id) VALUES ($1, $2, $3)
+				ON CONFLICT (payment_type_id) DO NOTHING`,
+				payment.PaymentTypeID, payment.Name, merchantID); err != nil {
+				return err
+			}
+		}
+		_, err := tx.Exec(`
+			INSERT INTO receipt_payments (merchant_id, receipt_number, line_no, receipt_date, store_id, payment_type_id, name, type, money_amount)
+			VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, $9)`,
+			merchantID, receipt.ReceiptNumber, i+1, receiptDate, receipt.StoreID, payment.PaymentTypeID, payment.Name, payment.Type, payment.MoneyAmount,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveLineAdjustments เขียนภาษี ส่วนลด และ modifier ของรายการหนึ่งลงตารางลูก
+// แถวเดิมถูกลบไปพร้อม receipt_line_items แล้วผ่าน ON DELETE CASCADE
+func saveLineAdjustments(tx *sql.Tx, merchantID, receiptNumber string, lineNo int, line models.LineItem) error {
+	for i, tax := range line.LineTaxes {
+		_, err := tx.Exec(`
+			INSERT INTO receipt_line_taxes (merchant_id, receipt_number, line_no, position, tax_id, type, name, rate, money_amount)
+			VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, $9)`,
+			merchantID, receiptNumber, lineNo, i+1, tax.TaxID, tax.Type, tax.Name, tax.Rate, tax.MoneyAmount)
+		if err != nil {
+			return err
+		}
+	}
+	for i, discount := range line.LineDiscounts {
+		_, err := tx.Exec(`
+			INSERT INTO receipt_line_discounts (merchant_id, receipt_number, line_no, position, discount_id, type, name, percentage, money_amount)
+			VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, $9)`,
+			merchantID, receiptNumber, lineNo, i+1, discount.DiscountID, discount.Type, discount.Name, discount.Percentage, discount.MoneyAmount)
+		if err != nil {
+			return err
+		}
+	}
+	for i, modifier := range line.LineModifiers {
+		_, err := tx.Exec(`
+			INSERT INTO receipt_line_modifiers (merchant_id, receipt_number, line_no, position, modifier_id, modifier_option_id, name, option, price, money_amount)
+			VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7, $8, $9, $10)`,
+			merchantID, receiptNumber, lineNo, i+1, modifier.ModifierID, modifier.ModifierOptionID, modifier.Name, modifier.Option, modifier.Price, modifier.MoneyAmount)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
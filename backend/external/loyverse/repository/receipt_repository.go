@@ -10,8 +10,9 @@ import (
 
 const batchSize = 250 // Define batch size
 
-// SaveReceipts saves receipts data into the database with timezone-aware timestamps
-func SaveReceipts(db *sql.DB, receipts []models.LoyReceipt) error {
+// SaveReceipts saves receipts data of one merchant into the database with timezone-aware timestamps
+// Line items and payments are also written to receipt_line_items and receipt_payments in the same transaction
+func SaveReceipts(db *sql.DB, merchantID string, receipts []models.LoyReceipt) error {
 	for i := 0; i < len(receipts); i += batchSize {
 		batchStart := i + 1
 		batchEnd := i + batchSize
@@ -45,6 +46,10 @@ func SaveReceipts(db *sql.DB, receipts []models.LoyReceipt) error {
 			createdAt := receipt.CreatedAt.In(time.UTC)
 			receiptDate := receipt.ReceiptDate.In(time.UTC)
 			updatedAt := receipt.UpdatedAt.In(time.UTC)
+			receiptType := receipt.ReceiptType
+			if receiptType == "" {
+				receiptType = models.ReceiptTypeSale
+			}
 			var cancelledAt sql.NullTime
 			if receipt.CancelledAt != nil {
 				cancelledAt = sql.NullTime{Time: receipt.CancelledAt.In(time.UTC), Valid: true}
@@ -66,9 +71,13 @@ func SaveReceipts(db *sql.DB, receipts []models.LoyReceipt) error {
                     line_items,
                     payments,
                     store_id,
-                    pos_device_id
-                ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
-                ON CONFLICT (receipt_number) DO UPDATE SET
+                    pos_device_id,
+                    merchant_id,
+                    receipt_type,
+                    refund_for,
+                    employee_id
+                ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, NULLIF($19, ''))
+                ON CONFLICT (merchant_id, receipt_number) DO UPDATE SET
                     note = $2,
                     created_at = $3,
                     receipt_date = $4,
@@ -82,7 +91,10 @@ func SaveReceipts(db *sql.DB, receipts []models.LoyReceipt) error {
                     line_items = $12,
                     payments = $13,
                     store_id = $14,
-                    pos_device_id = $15`,
+                    pos_device_id = $15,
+                    receipt_type = $17,
+                    refund_for = $18,
+                    employee_id = NULLIF($19, '')`,
 				receipt.ReceiptNumber,
 				receipt.Note,
 				createdAt,
@@ -98,12 +110,22 @@ func SaveReceipts(db *sql.DB, receipts []models.LoyReceipt) error {
 				paymentsJSON,
 				receipt.StoreID,
 				receipt.PosDeviceId,
+				merchantID,
+				receiptType,
+				receipt.RefundFor,
+				receipt.EmployeeID,
 			)
 			if err != nil {
 				tx.Rollback()
 				log.Println("Error saving receipt:", err)
 				return err
 			}
+
+			if err := saveReceiptLines(tx, merchantID, receipt); err != nil {
+				tx.Rollback()
+				log.Printf("Error saving line items and payments of receipt %s: %v", receipt.ReceiptNumber, err)
+				return err
+			}
 		}
 
 		// Commit transaction after saving the batch
@@ -117,14 +139,3 @@ func SaveReceipts(db *sql.DB, receipts []models.LoyReceipt) error {
 	log.Println("All receipts saved successfully.")
 	return nil
 }
-
-// ClearOldData เคลียร์ข้อมูลเก่าในตารางที่เกี่ยวข้อง
-func ClearOldReceiptsData(db *sql.DB) error {
-	_, err := db.Exec("TRUNCATE TABLE loyreceipts RESTART IDENTITY")
-	if err != nil {
-		log.Println("Error clearing old data:", err)
-		return err
-	}
-	log.Println("Old loyreceipts data cleared successfully.")
-	return nil
-}
@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"backend/external/loyverse/models"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+const reconciliationFindingColumns = `id, merchant_id, kind, finding_key, COALESCE(to_char(business_date, 'YYYY-MM-DD'), ''), store_id, item_id, variant_id,
+	local_count, remote_count, local_value, remote_value, local_total, remote_total, detail, status, sync_run_id, detected_at, last_seen_at, resolved_at`
+
+// DailyReceiptTotals นับจำนวนและยอดรวมใบเสร็จของ merchant ที่สร้างในช่วง [from, to] แยกตามวัน (ตาม timezone) และสาขา
+// ใบคืนเงินนับเป็นยอดติดลบเหมือนรายงานยอดขาย
+func DailyReceiptTotals(db *sql.DB, merchantID string, from, to time.Time, timezone string) ([]models.DailyReceiptTotal, error) {
+	rows, err := db.Query(`
+		SELECT to_char((created_at AT TIME ZONE $4)::date, 'YYYY-MM-DD') AS business_date,
+			COALESCE(store_id, ''),
+			COUNT(*),
+			COALESCE(SUM(total_money * CASE WHEN receipt_type = 'REFUND' THEN -1 ELSE 1 END), 0)
+		FROM loyreceipts
+		WHERE merchant_id = $1 AND created_at BETWEEN $2 AND $3
+		GROUP BY 1, 2
+		ORDER BY 1, 2`,
+		merchantID, from, to, timezone)
+	if err != nil {
+		log.Println("Error summarizing receipts per day:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := []models.DailyReceiptTotal{}
+	for rows.Next() {
+		var total models.DailyReceiptTotal
+		if err := rows.Scan(&total.BusinessDate, &total.StoreID, &total.Count, &total.Total); err != nil {
+			return nil, err
+		}
+		totals = append(totals, total)
+	}
+	return totals, rows.Err()
+}
+
+// ItemDeletionStates คืนค่าสินค้าทั้งหมดของ merchant ในฐานข้อมูล (item_id -> ถูกลบใน Loyverse แล้วหรือไม่)
+func ItemDeletionStates(db *sql.DB, merchantID string) (map[string]bool, error) {
+	rows, err := db.Query(`SELECT item_id, deleted_at IS NOT NULL FROM loyitems WHERE merchant_id = $1`, merchantID)
+	if err != nil {
+		log.Println("Error reading item states:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	states := make(map[string]bool)
+	for rows.Next() {
+		var itemID string
+		var deleted bool
+		if err := rows.Scan(&itemID, &deleted); err != nil {
+			return nil, err
+		}
+		states[itemID] = deleted
+	}
+	return states, rows.Err()
+}
+
+// InventoryLevelsOf คืนค่าสต็อกล่าสุดทั้งหมดของ merchant ในฐานข้อมูล
+func InventoryLevelsOf(db *sql.DB, merchantID string) ([]models.LoyInventoryLevel, error) {
+	rows, err := db.Query(`SELECT variant_id, store_id, in_stock, updated_at FROM loyinventorylevels WHERE merchant_id = $1`, merchantID)
+	if err != nil {
+		log.Println("Error reading inventory levels:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	levels := []models.LoyInventoryLevel{}
+	for rows.Next() {
+		var level models.LoyInventoryLevel
+		var updatedAt sql.NullTime
+		if err := rows.Scan(&level.VariantID, &level.StoreID, &level.InStock, &updatedAt); err != nil {
+			return nil, err
+		}
+		level.UpdatedAt = updatedAt.Time
+		levels = append(levels, level)
+	}
+	return levels, rows.Err()
+}
+
+// DeleteInventoryLevel ลบสต็อกของ variant/สาขาที่ไม่มีใน Loyverse แล้ว
+func DeleteInventoryLevel(db *sql.DB, merchantID, variantID, storeID string) error {
+	_, err := db.Exec(`DELETE FROM loyinventorylevels WHERE merchant_id = $1 AND variant_id = $2 AND store_id = $3`, merchantID, variantID, storeID)
+	if err != nil {
+		log.Println("Error deleting inventory level:", err)
+	}
+	return err
+}
+
+// SoftDeleteItem ทำเครื่องหมายว่าสินค้าและ variant ของมันถูกลบ (ใช้เมื่อ Loyverse ไม่พบสินค้านั้นแล้ว)
+func SoftDeleteItem(db *sql.DB, merchantID, itemID string) error {
+	if _, err := db.Exec(`UPDATE loyitems SET deleted_at = COALESCE(deleted_at, NOW()) WHERE merchant_id = $1 AND item_id = $2`, merchantID, itemID); err != nil {
+		log.Println("Error soft-deleting item:", err)
+		return err
+	}
+	if _, err := db.Exec(`UPDATE loyvariants SET deleted_at = COALESCE(deleted_at, NOW()) WHERE merchant_id = $1 AND item_id = $2`, merchantID, itemID); err != nil {
+		log.Println("Error soft-deleting variants:", err)
+		return err
+	}
+	return nil
+}
+
+// SaveReconciliationFindings บันทึก findings ของ reconciliation run หนึ่งครั้ง
+// finding ที่ยังเปิดอยู่ด้วย key เดิมจะถูกอัปเดตค่าแทนการสร้างใหม่
+// finding ที่เปิดอยู่แต่ไม่พบแล้วในรอบนี้จะถูกปิดเป็น resolved (ใบเสร็จปิดเฉพาะวันในช่วง fromDate-toDate ที่ตรวจ)
+// คืนค่าจำนวน finding ที่ถูกปิด
+func SaveReconciliationFindings(db *sql.DB, merchantID string, syncRunID int64, findings []models.ReconciliationFinding, fromDate, toDate string) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		log.Println("Error starting transaction:", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	for i := range findings {
+		finding := &findings[i]
+		row := tx.QueryRow(`
+			INSERT INTO reconciliation_findings (
+				merchant_id, kind, finding_key, business_date, store_id, item_id, variant_id,
+				local_count, remote_count, local_value, remote_value, local_total, remote_total, detail, status, sync_run_id
+			) VALUES ($1, $2, $3, NULLIF($4, '')::date, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+			ON CONFLICT (merchant_id, finding_key) WHERE status = 'open' DO UPDATE SET
+				local_count = EXCLUDED.local_count,
+				remote_count = EXCLUDED.remote_count,
+				local_value = EXCLUDED.local_value,
+				remote_value = EXCLUDED.remote_value,
+				local_total = EXCLUDED.local_total,
+				remote_total = EXCLUDED.remote_total,
+				detail = EXCLUDED.detail,
+				sync_run_id = EXCLUDED.sync_run_id,
+				last_seen_at = NOW()
+			RETURNING `+reconciliationFindingColumns,
+			merchantID, finding.Kind, finding.Key, finding.BusinessDate, finding.StoreID, finding.ItemID, finding.VariantID,
+			finding.LocalCount, finding.RemoteCount, finding.LocalValue, finding.RemoteValue,
+			finding.LocalTotal, finding.RemoteTotal, finding.Detail,
+			models.FindingStatusOpen, syncRunID)
+		saved, err := scanReconciliationFinding(row)
+		if err != nil {
+			log.Printf("Error saving reconciliation finding %s: %v", finding.Key, err)
+			return 0, err
+		}
+		*finding = *saved
+	}
+
+	result, err := tx.Exec(`
+		UPDATE reconciliation_findings SET status = $3, resolved_at = NOW()
+		WHERE merchant_id = $1 AND status = $4 AND sync_run_id IS DISTINCT FROM $2
+			AND (kind <> $5 OR business_date BETWEEN $6::date AND $7::date)`,
+		merchantID, syncRunID, models.FindingStatusResolved, models.FindingStatusOpen,
+		models.FindingKindReceiptsDay, fromDate, toDate)
+	if err != nil {
+		log.Println("Error resolving reconciliation findings:", err)
+		return 0, err
+	}
+	resolved, _ := result.RowsAffected()
+
+	if err := tx.Commit(); err != nil {
+		log.Println("Error committing transaction:", err)
+		return 0, err
+	}
+	return resolved, nil
+}
+
+// ListReconciliationFindings คืนค่า findings ล่าสุดของ merchant (status ว่าง = ทุกสถานะ)
+func ListReconciliationFindings(db *sql.DB, merchantID, status string, limit int) ([]models.ReconciliationFinding, error) {
+	rows, err := db.Query(`
+		SELECT `+reconciliationFindingColumns+`
+		FROM reconciliation_findings
+		WHERE merchant_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY detected_at DESC, id DESC
+		LIMIT $3`,
+		merchantID, status, limit)
+	if err != nil {
+		log.Println("Error listing reconciliation findings:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	findings := []models.ReconciliationFinding{}
+	for rows.Next() {
+		finding, err := scanReconciliationFinding(rows)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, *finding)
+	}
+	return findings, rows.Err()
+}
+
+// GetReconciliationFinding อ่าน finding หนึ่งรายการของ merchant
+func GetReconciliationFinding(db *sql.DB, merchantID string, id int64) (*models.ReconciliationFinding, error) {
+	finding, err := scanReconciliationFinding(db.QueryRow(`
+		SELECT `+reconciliationFindingColumns+`
+		FROM reconciliation_findings
+		WHERE merchant_id = $1 AND id = $2`,
+		merchantID, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("reconciliation finding %d not found: %w", id, sql.ErrNoRows)
+	}
+	return finding, err
+}
+
+// ResolveReconciliationFinding ปิด finding หลัง resync ขอบเขตนั้นสำเร็จ
+func ResolveReconciliationFinding(db *sql.DB, finding *models.ReconciliationFinding) error {
+	var resolvedAt time.Time
+	err := db.QueryRow(`
+		UPDATE reconciliation_findings SET status = $2, resolved_at = COALESCE(resolved_at, NOW())
+		WHERE id = $1
+		RETURNING resolved_at`,
+		finding.ID, models.FindingStatusResolved).Scan(&resolvedAt)
+	if err != nil {
+		log.Println("Error resolving reconciliation finding:", err)
+		return err
+	}
+	finding.Status = models.FindingStatusResolved
+	finding.ResolvedAt = &resolvedAt
+	return nil
+}
+
+func scanReconciliationFinding(row rowScanner) (*models.ReconciliationFinding, error) {
+	var finding models.ReconciliationFinding
+	var syncRunID sql.NullInt64
+	var resolvedAt sql.NullTime
+	err := row.Scan(
+		&finding.ID, &finding.MerchantID, &finding.Kind, &finding.Key, &finding.BusinessDate,
+		&finding.StoreID, &finding.ItemID, &finding.VariantID,
+		&finding.LocalCount, &finding.RemoteCount, &finding.LocalValue, &finding.RemoteValue,
+		&finding.LocalTotal, &finding.RemoteTotal,
+		&finding.Detail, &finding.Status, &syncRunID, &finding.DetectedAt, &finding.LastSeenAt, &resolvedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if syncRunID.Valid {
+		finding.SyncRunID = &syncRunID.Int64
+	}
+	if resolvedAt.Valid {
+		finding.ResolvedAt = &resolvedAt.Time
+	}
+	return &finding, nil
+}
@@ -0,0 +1,587 @@
+package repository
+
+import (
+	"database/sql"
+	"log"
+)
+
+// schemaStatements คือตารางที่ loyverse-connect สร้างและดูแลเอง
+// ใช้ IF NOT EXISTS ทั้งหมดเพื่อให้รันซ้ำได้ทุกครั้งที่ service เริ่มทำงาน
+var schemaStatements = []string{
+	`CREATE TABLE IF NOT EXISTS webhook_events (
+		delivery_id  TEXT PRIMARY KEY,
+		event_type   TEXT NOT NULL,
+		received_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		processed_at TIMESTAMPTZ
+	)`,
+	`ALTER TABLE webhook_events ADD COLUMN IF NOT EXISTS payload JSONB`,
+	`ALTER TABLE webhook_events ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'pending'`,
+	`ALTER TABLE webhook_events ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE webhook_events ADD COLUMN IF NOT EXISTS last_error TEXT`,
+	`ALTER TABLE webhook_events ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMPTZ DEFAULT NOW()`,
+	`UPDATE webhook_events SET status = 'processed' WHERE processed_at IS NOT NULL AND status = 'pending'`,
+	`UPDATE webhook_events SET status = 'dead', last_error = 'payload was not stored' WHERE payload IS NULL AND status = 'pending'`,
+	`CREATE INDEX IF NOT EXISTS webhook_events_due_idx ON webhook_events (status, next_attempt_at)`,
+
+	// master data ใช้ soft-delete แทนการ TRUNCATE
+	`ALTER TABLE loycategories ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ`,
+	`ALTER TABLE loyitems ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ`,
+	`ALTER TABLE loypaymenttypes ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ`,
+	`ALTER TABLE loystores ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ`,
+	`ALTER TABLE loysuppliers ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ`,
+
+	`CREATE TABLE IF NOT EXISTS sync_runs (
+		id            BIGSERIAL PRIMARY KEY,
+		entity_type   TEXT NOT NULL,
+		trigger       TEXT NOT NULL,
+		status        TEXT NOT NULL,
+		started_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		finished_at   TIMESTAMPTZ,
+		pages_fetched INTEGER NOT NULL DEFAULT 0,
+		rows_upserted BIGINT NOT NULL DEFAULT 0,
+		rows_deleted  BIGINT NOT NULL DEFAULT 0,
+		error         TEXT
+	)`,
+	`CREATE INDEX IF NOT EXISTS sync_runs_entity_started_idx ON sync_runs (entity_type, started_at DESC)`,
+
+	// ข้อมูลลูกค้าและ loyalty จาก /customers
+	`CREATE TABLE IF NOT EXISTS loycustomers (
+		customer_id  TEXT PRIMARY KEY,
+		name         TEXT,
+		email        TEXT,
+		phone_number TEXT
+	)`,
+	`ALTER TABLE loycustomers ADD COLUMN IF NOT EXISTS address TEXT`,
+	`ALTER TABLE loycustomers ADD COLUMN IF NOT EXISTS city TEXT`,
+	`ALTER TABLE loycustomers ADD COLUMN IF NOT EXISTS region TEXT`,
+	`ALTER TABLE loycustomers ADD COLUMN IF NOT EXISTS postal_code TEXT`,
+	`ALTER TABLE loycustomers ADD COLUMN IF NOT EXISTS country_code TEXT`,
+	`ALTER TABLE loycustomers ADD COLUMN IF NOT EXISTS note TEXT`,
+	`ALTER TABLE loycustomers ADD COLUMN IF NOT EXISTS customer_code TEXT`,
+	`ALTER TABLE loycustomers ADD COLUMN IF NOT EXISTS first_visit TIMESTAMPTZ`,
+	`ALTER TABLE loycustomers ADD COLUMN IF NOT EXISTS last_visit TIMESTAMPTZ`,
+	`ALTER TABLE loycustomers ADD COLUMN IF NOT EXISTS total_visits INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE loycustomers ADD COLUMN IF NOT EXISTS total_spent NUMERIC NOT NULL DEFAULT 0`,
+	`ALTER TABLE loycustomers ADD COLUMN IF NOT EXISTS total_points NUMERIC NOT NULL DEFAULT 0`,
+	`ALTER TABLE loycustomers ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ`,
+	`ALTER TABLE loycustomers ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ`,
+	`ALTER TABLE loycustomers ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ`,
+	`CREATE INDEX IF NOT EXISTS loyreceipts_customer_id_idx ON loyreceipts (customer_id)`,
+
+	// คิวและ audit log ของการเปลี่ยนแปลงที่ส่งกลับไปยัง Loyverse
+	`CREATE TABLE IF NOT EXISTS outbound_changes (
+		id              BIGSERIAL PRIMARY KEY,
+		change_type     TEXT NOT NULL,
+		entity_id       TEXT NOT NULL,
+		payload         JSONB NOT NULL,
+		reason          TEXT,
+		status          TEXT NOT NULL DEFAULT 'pending',
+		attempts        INTEGER NOT NULL DEFAULT 0,
+		last_error      TEXT,
+		request_body    JSONB,
+		response_body   JSONB,
+		created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		next_attempt_at TIMESTAMPTZ DEFAULT NOW(),
+		sent_at         TIMESTAMPTZ
+	)`,
+	`CREATE INDEX IF NOT EXISTS outbound_changes_due_idx ON outbound_changes (status, next_attempt_at)`,
+	`CREATE INDEX IF NOT EXISTS outbound_changes_entity_idx ON outbound_changes (change_type, entity_id, id)`,
+
+	// บัญชี Loyverse ที่ sync อยู่ (หลาย merchant) และ merchant_id ของทุกแถวที่ sync มา
+	`CREATE TABLE IF NOT EXISTS loyverse_connections (
+		merchant_id    TEXT PRIMARY KEY,
+		name           TEXT NOT NULL DEFAULT '',
+		api_token      TEXT NOT NULL,
+		webhook_secret TEXT NOT NULL DEFAULT '',
+		base_url       TEXT NOT NULL DEFAULT '',
+		enabled        BOOLEAN NOT NULL DEFAULT TRUE,
+		created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`ALTER TABLE loycategories ADD COLUMN IF NOT EXISTS merchant_id TEXT NOT NULL DEFAULT 'default'`,
+	`ALTER TABLE loyitems ADD COLUMN IF NOT EXISTS merchant_id TEXT NOT NULL DEFAULT 'default'`,
+	`ALTER TABLE loypaymenttypes ADD COLUMN IF NOT EXISTS merchant_id TEXT NOT NULL DEFAULT 'default'`,
+	`ALTER TABLE loystores ADD COLUMN IF NOT EXISTS merchant_id TEXT NOT NULL DEFAULT 'default'`,
+	`ALTER TABLE loysuppliers ADD COLUMN IF NOT EXISTS merchant_id TEXT NOT NULL DEFAULT 'default'`,
+	`ALTER TABLE loycustomers ADD COLUMN IF NOT EXISTS merchant_id TEXT NOT NULL DEFAULT 'default'`,
+	`ALTER TABLE loyinventorylevels ADD COLUMN IF NOT EXISTS merchant_id TEXT NOT NULL DEFAULT 'default'`,
+	`ALTER TABLE loyreceipts ADD COLUMN IF NOT EXISTS merchant_id TEXT NOT NULL DEFAULT 'default'`,
+	`ALTER TABLE sync_runs ADD COLUMN IF NOT EXISTS merchant_id TEXT NOT NULL DEFAULT 'default'`,
+	`ALTER TABLE webhook_events ADD COLUMN IF NOT EXISTS merchant_id TEXT NOT NULL DEFAULT 'default'`,
+	`ALTER TABLE outbound_changes ADD COLUMN IF NOT EXISTS merchant_id TEXT NOT NULL DEFAULT 'default'`,
+	// delivery id (หรือ hash ของ body เมื่อไม่มี header) ซ้ำกันได้ข้าม merchant จึงต้องใช้ merchant_id ร่วมใน key
+	`DO $$
+	BEGIN
+		IF EXISTS (
+			SELECT 1 FROM pg_constraint
+			WHERE conname = 'webhook_events_pkey' AND conrelid = 'webhook_events'::regclass AND array_length(conkey, 1) = 1
+		) THEN
+			ALTER TABLE webhook_events DROP CONSTRAINT webhook_events_pkey;
+			ALTER TABLE webhook_events ADD PRIMARY KEY (merchant_id, delivery_id);
+		END IF;
+	END $$`,
+	// id ของ Loyverse เป็น UUID ที่ไม่ซ้ำข้ามบัญชี แต่เลขที่ใบเสร็จ (เช่น 1-1001) ซ้ำกันได้ จึงต้องใช้ merchant_id ร่วมใน key
+	`DO $$
+	BEGIN
+		IF EXISTS (
+			SELECT 1 FROM pg_constraint
+			WHERE conname = 'loyreceipts_pkey' AND conrelid = 'loyreceipts'::regclass AND array_length(conkey, 1) = 1
+		) THEN
+			ALTER TABLE loyreceipts DROP CONSTRAINT loyreceipts_pkey;
+			ALTER TABLE loyreceipts ADD PRIMARY KEY (merchant_id, receipt_number);
+		END IF;
+	END $$`,
+	`CREATE INDEX IF NOT EXISTS loyreceipts_merchant_date_idx ON loyreceipts (merchant_id, receipt_date)`,
+
+	// ใบคืนเงินและใบขายเดิมที่ถูกคืน (ใบเก่าที่ sync ก่อนมีคอลัมน์นี้ถือเป็น SALE)
+	`ALTER TABLE loyreceipts ADD COLUMN IF NOT EXISTS receipt_type TEXT NOT NULL DEFAULT 'SALE'`,
+	`ALTER TABLE loyreceipts ADD COLUMN IF NOT EXISTS refund_for TEXT`,
+	`CREATE INDEX IF NOT EXISTS loyreceipts_refund_for_idx ON loyreceipts (merchant_id, refund_for) WHERE refund_for IS NOT NULL`,
+
+	// รายการสินค้าและการชำระเงินของใบเสร็จในรูปตาราง (JSONB ใน loyreceipts ยังเก็บไว้เหมือนเดิม)
+	// receipt_date และ store_id ถูกคัดลอกมาจากใบเสร็จเพื่อให้รายงานกรองด้วย index ได้โดยไม่ต้อง join
+	`CREATE TABLE IF NOT EXISTS receipt_line_items (
+		merchant_id       TEXT NOT NULL,
+		receipt_number    TEXT NOT NULL,
+		line_no           INTEGER NOT NULL,
+		line_item_id      TEXT,
+		receipt_date      TIMESTAMPTZ NOT NULL,
+		store_id          TEXT,
+		item_id           TEXT REFERENCES loyitems (item_id),
+		variant_id        TEXT,
+		item_name         TEXT NOT NULL DEFAULT '',
+		variant_name      TEXT,
+		sku               TEXT,
+		quantity          NUMERIC NOT NULL DEFAULT 0,
+		price             NUMERIC NOT NULL DEFAULT 0,
+		gross_total_money NUMERIC NOT NULL DEFAULT 0,
+		total_money       NUMERIC NOT NULL DEFAULT 0,
+		total_discount    NUMERIC NOT NULL DEFAULT 0,
+		cost              NUMERIC NOT NULL DEFAULT 0,
+		cost_total        NUMERIC NOT NULL DEFAULT 0,
+		line_note         TEXT,
+		line_taxes        JSONB,
+		line_discounts    JSONB,
+		line_modifiers    JSONB,
+		PRIMARY KEY (merchant_id, receipt_number, line_no),
+		FOREIGN KEY (merchant_id, receipt_number) REFERENCES loyreceipts (merchant_id, receipt_number) ON DELETE CASCADE
+	)`,
+	`CREATE INDEX IF NOT EXISTS receipt_line_items_date_idx ON receipt_line_items (merchant_id, receipt_date)`,
+	`CREATE INDEX IF NOT EXISTS receipt_line_items_store_idx ON receipt_line_items (store_id, receipt_date)`,
+	`CREATE INDEX IF NOT EXISTS receipt_line_items_item_idx ON receipt_line_items (item_id, receipt_date)`,
+	`CREATE INDEX IF NOT EXISTS receipt_line_items_variant_idx ON receipt_line_items (variant_id)`,
+	`CREATE TABLE IF NOT EXISTS receipt_payments (
+		merchant_id     TEXT NOT NULL,
+		receipt_number  TEXT NOT NULL,
+		line_no         INTEGER NOT NULL,
+		receipt_date    TIMESTAMPTZ NOT NULL,
+		store_id        TEXT,
+		payment_type_id TEXT REFERENCES loypaymenttypes (payment_type_id),
+		name            TEXT NOT NULL DEFAULT '',
+		type            TEXT NOT NULL DEFAULT '',
+		money_amount    NUMERIC NOT NULL DEFAULT 0,
+		PRIMARY KEY (merchant_id, receipt_number, line_no),
+		FOREIGN KEY (merchant_id, receipt_number) REFERENCES loyreceipts (merchant_id, receipt_number) ON DELETE CASCADE
+	)`,
+	`CREATE INDEX IF NOT EXISTS receipt_payments_date_idx ON receipt_payments (merchant_id, receipt_date)`,
+	`CREATE INDEX IF NOT EXISTS receipt_payments_store_idx ON receipt_payments (store_id, receipt_date)`,
+	`CREATE INDEX IF NOT EXISTS receipt_payments_type_idx ON receipt_payments (payment_type_id)`,
+	// เติมตารางจาก JSONB ของใบเสร็จที่ sync ไว้ก่อนมีตารางเหล่านี้ (ใบเสร็จที่มีแถวแล้วจะถูกข้าม)
+	// สินค้าหรือประเภทการชำระเงินที่ยังไม่เคย sync จะถูกสร้างเป็นแถวชั่วคราว แล้ว master sync ครั้งถัดไปจะเติมข้อมูลให้
+	// ต้อง scan JSONB ของใบเสร็จทุกใบ จึงรันครั้งเดียวแล้วบันทึกไว้ใน schema_migrations ไม่รันซ้ำทุกครั้งที่ service เริ่ม
+	// lock ตารางไว้เพื่อให้ process ที่เริ่มพร้อมกันรอจนอีกตัวเติมเสร็จแล้วข้ามไป
+	`CREATE TABLE IF NOT EXISTS schema_migrations (
+		name       TEXT PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`DO $$
+	BEGIN
+		LOCK TABLE schema_migrations IN SHARE ROW EXCLUSIVE MODE;
+		IF EXISTS (SELECT 1 FROM schema_migrations WHERE name = 'backfill_receipt_children') THEN
+			RETURN;
+		END IF;
+		INSERT INTO loyitems (item_id, item_name, merchant_id)
+		SELECT DISTINCT ON (li->>'item_id') li->>'item_id', li->>'item_name', r.merchant_id
+		FROM loyreceipts r
+		CROSS JOIN jsonb_array_elements(CASE WHEN jsonb_typeof(r.line_items) = 'array' THEN r.line_items ELSE '[]' END) AS li
+		WHERE COALESCE(li->>'item_id', '') <> ''
+		AND NOT EXISTS (SELECT 1 FROM receipt_line_items l WHERE l.merchant_id = r.merchant_id AND l.receipt_number = r.receipt_number)
+		ON CONFLICT (item_id) DO NOTHING;
+		INSERT INTO receipt_line_items (
+			merchant_id, receipt_number, line_no, line_item_id, receipt_date, store_id, item_id, variant_id, item_name, variant_name, sku,
+			quantity, price, gross_total_money, total_money, total_discount, cost, cost_total, line_note, line_taxes, line_discounts, line_modifiers
+		)
+		SELECT r.merchant_id, r.receipt_number, li.ordinality::int, li.value->>'id', r.receipt_date, r.store_id,
+			NULLIF(li.value->>'item_id', ''), NULLIF(li.value->>'variant_id', ''), COALESCE(li.value->>'item_name', ''),
+			li.value->>'variant_name', li.value->>'sku',
+			COALESCE((li.value->>'quantity')::numeric, 0), COALESCE((li.value->>'price')::numeric, 0),
+			COALESCE((li.value->>'gross_total_money')::numeric, 0), COALESCE((li.value->>'total_money')::numeric, 0),
+			COALESCE((li.value->>'total_discount')::numeric, 0), COALESCE((li.value->>'cost')::numeric, 0),
+			COALESCE((li.value->>'cost_total')::numeric, 0), li.value->>'line_note',
+			li.value->'line_taxes', li.value->'line_discounts', li.value->'line_modifiers'
+		FROM loyreceipts r
+		CROSS JOIN jsonb_array_elements(CASE WHEN jsonb_typeof(r.line_items) = 'array' THEN r.line_items ELSE '[]' END) WITH ORDINALITY AS li
+		WHERE r.receipt_date IS NOT NULL
+		AND NOT EXISTS (SELECT 1 FROM receipt_line_items l WHERE l.merchant_id = r.merchant_id AND l.receipt_number = r.receipt_number);
+		INSERT INTO loypaymenttypes (payment_type_id, name, merchant_id)
+		SELECT DISTINCT ON (p->>'payment_type_id') p->>'payment_type_id', p->>'name', r.merchant_id
+		FROM loyreceipts r
+		CROSS JOIN jsonb_array_elements(CASE WHEN jsonb_typeof(r.payments) = 'array' THEN r.payments ELSE '[]' END) AS p
+		WHERE COALESCE(p->>'payment_type_id', '') <> ''
+		AND NOT EXISTS (SELECT 1 FROM receipt_payments rp WHERE rp.merchant_id = r.merchant_id AND rp.receipt_number = r.receipt_number)
+		ON CONFLICT (payment_type_id) DO NOTHING;
+		INSERT INTO receipt_payments (merchant_id, receipt_number, line_no, receipt_date, store_id, payment_type_id, name, type, money_amount)
+		SELECT r.merchant_id, r.receipt_number, p.ordinality::int, r.receipt_date, r.store_id,
+			NULLIF(p.value->>'payment_type_id', ''), COALESCE(p.value->>'name', ''), COALESCE(p.value->>'type', ''),
+			COALESCE((p.value->>'money_amount')::numeric, 0)
+		FROM loyreceipts r
+		CROSS JOIN jsonb_array_elements(CASE WHEN jsonb_typeof(r.payments) = 'array' THEN r.payments ELSE '[]' END) WITH ORDINALITY AS p
+		WHERE r.receipt_date IS NOT NULL
+		AND NOT EXISTS (SELECT 1 FROM receipt_payments rp WHERE rp.merchant_id = r.merchant_id AND rp.receipt_number = r.receipt_number);
+		INSERT INTO schema_migrations (name) VALUES ('backfill_receipt_children');
+	END $$`,
+
+	// variant ของสินค้า (ขนาดแพ็ค ฯลฯ) และราคา/การขายรายสาขา จาก items endpoint
+	`ALTER TABLE loyitems ADD COLUMN IF NOT EXISTS option1_name TEXT`,
+	`ALTER TABLE loyitems ADD COLUMN IF NOT EXISTS option2_name TEXT`,
+	`ALTER TABLE loyitems ADD COLUMN IF NOT EXISTS option3_name TEXT`,
+	`CREATE TABLE IF NOT EXISTS loyvariants (
+		variant_id           TEXT PRIMARY KEY,
+		item_id              TEXT NOT NULL REFERENCES loyitems (item_id),
+		merchant_id          TEXT NOT NULL DEFAULT 'default',
+		sku                  TEXT,
+		barcode              TEXT,
+		reference_variant_id TEXT,
+		option1_value        TEXT,
+		option2_value        TEXT,
+		option3_value        TEXT,
+		cost                 NUMERIC NOT NULL DEFAULT 0,
+		purchase_cost        NUMERIC NOT NULL DEFAULT 0,
+		default_pricing_type TEXT,
+		default_price        NUMERIC,
+		created_at           TIMESTAMPTZ,
+		updated_at           TIMESTAMPTZ,
+		deleted_at           TIMESTAMPTZ
+	)`,
+	`CREATE INDEX IF NOT EXISTS loyvariants_item_idx ON loyvariants (item_id)`,
+	`CREATE INDEX IF NOT EXISTS loyvariants_sku_idx ON loyvariants (merchant_id, sku)`,
+	`CREATE INDEX IF NOT EXISTS loyvariants_barcode_idx ON loyvariants (merchant_id, barcode)`,
+	`CREATE TABLE IF NOT EXISTS loyvariant_stores (
+		variant_id         TEXT NOT NULL REFERENCES loyvariants (variant_id) ON DELETE CASCADE,
+		store_id           TEXT NOT NULL,
+		pricing_type       TEXT,
+		price              NUMERIC,
+		available_for_sale BOOLEAN NOT NULL DEFAULT TRUE,
+		optimal_stock      NUMERIC,
+		low_stock          NUMERIC,
+		PRIMARY KEY (variant_id, store_id)
+	)`,
+	// variant ที่เคยเก็บไว้แค่ใน loyitems.variants (master sync ครั้งถัดไปจะเติม SKU ราคา ฯลฯ ให้ครบ)
+	`INSERT INTO loyvariants (variant_id, item_id, merchant_id, cost, purchase_cost, default_price)
+	SELECT DISTINCT ON (v->>'variant_id') v->>'variant_id', i.item_id, i.merchant_id,
+		COALESCE((v->>'cost')::numeric, 0), COALESCE((v->>'purchase_cost')::numeric, 0), (v->>'default_price')::numeric
+	FROM loyitems i
+	CROSS JOIN jsonb_array_elements(CASE WHEN jsonb_typeof(i.variants) = 'array' THEN i.variants ELSE '[]' END) AS v
+	WHERE COALESCE(v->>'variant_id', '') <> ''
+	ON CONFLICT (variant_id) DO NOTHING`,
+	// foreign key จากรายการในใบเสร็จไปยัง variant (variant ที่ยังไม่เคย sync จะถูกสร้างเป็นแถวชั่วคราวก่อน)
+	`DO $$
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'receipt_line_items_variant_fkey') THEN
+			INSERT INTO loyvariants (variant_id, item_id, merchant_id, sku)
+			SELECT DISTINCT ON (variant_id) variant_id, item_id, merchant_id, sku
+			FROM receipt_line_items
+			WHERE variant_id IS NOT NULL AND item_id IS NOT NULL
+			ON CONFLICT (variant_id) DO NOTHING;
+			UPDATE receipt_line_items l SET variant_id = NULL
+			WHERE variant_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM loyvariants v WHERE v.variant_id = l.variant_id);
+			ALTER TABLE receipt_line_items ADD CONSTRAINT receipt_line_items_variant_fkey
+				FOREIGN KEY (variant_id) REFERENCES loyvariants (variant_id);
+		END IF;
+	END $$`,
+
+	// แคตตาล็อกภาษี ส่วนลด และ modifier (stores คือ array ของ store_id ที่ใช้ได้)
+	`CREATE TABLE IF NOT EXISTS loytaxes (
+		tax_id      TEXT PRIMARY KEY,
+		merchant_id TEXT NOT NULL DEFAULT 'default',
+		type        TEXT,
+		name        TEXT,
+		rate        NUMERIC NOT NULL DEFAULT 0,
+		stores      JSONB,
+		created_at  TIMESTAMPTZ,
+		updated_at  TIMESTAMPTZ,
+		deleted_at  TIMESTAMPTZ
+	)`,
+	`CREATE TABLE IF NOT EXISTS loydiscounts (
+		discount_id       TEXT PRIMARY KEY,
+		merchant_id       TEXT NOT NULL DEFAULT 'default',
+		type              TEXT,
+		name              TEXT,
+		discount_amount   NUMERIC,
+		discount_percent  NUMERIC,
+		stores            JSONB,
+		restricted_access BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at        TIMESTAMPTZ,
+		updated_at        TIMESTAMPTZ,
+		deleted_at        TIMESTAMPTZ
+	)`,
+	`CREATE TABLE IF NOT EXISTS loymodifiers (
+		modifier_id TEXT PRIMARY KEY,
+		merchant_id TEXT NOT NULL DEFAULT 'default',
+		name        TEXT,
+		position    INTEGER NOT NULL DEFAULT 0,
+		stores      JSONB,
+		created_at  TIMESTAMPTZ,
+		updated_at  TIMESTAMPTZ,
+		deleted_at  TIMESTAMPTZ
+	)`,
+	`CREATE TABLE IF NOT EXISTS loymodifier_options (
+		modifier_option_id TEXT PRIMARY KEY,
+		modifier_id        TEXT NOT NULL REFERENCES loymodifiers (modifier_id) ON DELETE CASCADE,
+		name               TEXT,
+		price              NUMERIC NOT NULL DEFAULT 0,
+		position           INTEGER NOT NULL DEFAULT 0
+	)`,
+	`CREATE INDEX IF NOT EXISTS loymodifier_options_modifier_idx ON loymodifier_options (modifier_id)`,
+
+	// ภาษี ส่วนลด และ modifier ของแต่ละรายการในใบเสร็จ (ชื่อและอัตราเป็นค่า ณ เวลาที่ขาย)
+	`CREATE TABLE IF NOT EXISTS receipt_line_taxes (
+		merchant_id    TEXT NOT NULL,
+		receipt_number TEXT NOT NULL,
+		line_no        INTEGER NOT NULL,
+		position       INTEGER NOT NULL,
+		tax_id         TEXT,
+		type           TEXT NOT NULL DEFAULT '',
+		name           TEXT NOT NULL DEFAULT '',
+		rate           NUMERIC NOT NULL DEFAULT 0,
+		money_amount   NUMERIC NOT NULL DEFAULT 0,
+		PRIMARY KEY (merchant_id, receipt_number, line_no, position),
+		FOREIGN KEY (merchant_id, receipt_number, line_no) REFERENCES receipt_line_items (merchant_id, receipt_number, line_no) ON DELETE CASCADE
+	)`,
+	`CREATE INDEX IF NOT EXISTS receipt_line_taxes_tax_idx ON receipt_line_taxes (tax_id)`,
+	`CREATE TABLE IF NOT EXISTS receipt_line_discounts (
+		merchant_id    TEXT NOT NULL,
+		receipt_number TEXT NOT NULL,
+		line_no        INTEGER NOT NULL,
+		position       INTEGER NOT NULL,
+		discount_id    TEXT,
+		type           TEXT NOT NULL DEFAULT '',
+		name           TEXT NOT NULL DEFAULT '',
+		percentage     NUMERIC,
+		money_amount   NUMERIC NOT NULL DEFAULT 0,
+		PRIMARY KEY (merchant_id, receipt_number, line_no, position),
+		FOREIGN KEY (merchant_id, receipt_number, line_no) REFERENCES receipt_line_items (merchant_id, receipt_number, line_no) ON DELETE CASCADE
+	)`,
+	`CREATE INDEX IF NOT EXISTS receipt_line_discounts_discount_idx ON receipt_line_discounts (discount_id)`,
+	`CREATE TABLE IF NOT EXISTS receipt_line_modifiers (
+		merchant_id        TEXT NOT NULL,
+		receipt_number     TEXT NOT NULL,
+		line_no            INTEGER NOT NULL,
+		position           INTEGER NOT NULL,
+		modifier_id        TEXT,
+		modifier_option_id TEXT,
+		name               TEXT NOT NULL DEFAULT '',
+		option             TEXT NOT NULL DEFAULT '',
+		price              NUMERIC NOT NULL DEFAULT 0,
+		money_amount       NUMERIC NOT NULL DEFAULT 0,
+		PRIMARY KEY (merchant_id, receipt_number, line_no, position),
+		FOREIGN KEY (merchant_id, receipt_number, line_no) REFERENCES receipt_line_items (merchant_id, receipt_number, line_no) ON DELETE CASCADE
+	)`,
+	`CREATE INDEX IF NOT EXISTS receipt_line_modifiers_option_idx ON receipt_line_modifiers (modifier_option_id)`,
+	// แยก JSONB ของรายการที่ sync ไว้ก่อนมีตารางเหล่านี้ (รายการที่มีแถวแล้วจะถูกข้าม)
+	`INSERT INTO receipt_line_taxes (merchant_id, receipt_number, line_no, position, tax_id, type, name, rate, money_amount)
+	SELECT l.merchant_id, l.receipt_number, l.line_no, t.ordinality::int, NULLIF(t.value->>'id', ''),
+		COALESCE(t.value->>'type', ''), COALESCE(t.value->>'name', ''),
+		COALESCE((t.value->>'rate')::numeric, 0), COALESCE((t.value->>'money_amount')::numeric, 0)
+	FROM receipt_line_items l
+	CROSS JOIN jsonb_array_elements(CASE WHEN jsonb_typeof(l.line_taxes) = 'array' THEN l.line_taxes ELSE '[]' END) WITH ORDINALITY AS t
+	WHERE NOT EXISTS (SELECT 1 FROM receipt_line_taxes x WHERE x.merchant_id = l.merchant_id AND x.receipt_number = l.receipt_number AND x.line_no = l.line_no)`,
+	`INSERT INTO receipt_line_discounts (merchant_id, receipt_number, line_no, position, discount_id, type, name, percentage, money_amount)
+	SELECT l.merchant_id, l.receipt_number, l.line_no, d.ordinality::int, NULLIF(d.value->>'id', ''),
+		COALESCE(d.value->>'type', ''), COALESCE(d.value->>'name', ''),
+		(d.value->>'percentage')::numeric, COALESCE((d.value->>'money_amount')::numeric, 0)
+	FROM receipt_line_items l
+	CROSS JOIN jsonb_array_elements(CASE WHEN jsonb_typeof(l.line_discounts) = 'array' THEN l.line_discounts ELSE '[]' END) WITH ORDINALITY AS d
+	WHERE NOT EXISTS (SELECT 1 FROM receipt_line_discounts x WHERE x.merchant_id = l.merchant_id AND x.receipt_number = l.receipt_number AND x.line_no = l.line_no)`,
+	`INSERT INTO receipt_line_modifiers (merchant_id, receipt_number, line_no, position, modifier_id, modifier_option_id, name, option, price, money_amount)
+	SELECT l.merchant_id, l.receipt_number, l.line_no, m.ordinality::int, NULLIF(m.value->>'id', ''), NULLIF(m.value->>'modifier_option_id', ''),
+		COALESCE(m.value->>'name', ''), COALESCE(m.value->>'option', ''),
+		COALESCE((m.value->>'price')::numeric, 0), COALESCE((m.value->>'money_amount')::numeric, 0)
+	FROM receipt_line_items l
+	CROSS JOIN jsonb_array_elements(CASE WHEN jsonb_typeof(l.line_modifiers) = 'array' THEN l.line_modifiers ELSE '[]' END) WITH ORDINALITY AS m
+	WHERE NOT EXISTS (SELECT 1 FROM receipt_line_modifiers x WHERE x.merchant_id = l.merchant_id AND x.receipt_number = l.receipt_number AND x.line_no = l.line_no)`,
+
+	// พนักงาน เครื่อง POS และกะการขาย (ใครขาย ขายจากเครื่องไหน และเงินสดในลิ้นชักตรงหรือไม่)
+	`CREATE TABLE IF NOT EXISTS loyemployees (
+		employee_id  TEXT PRIMARY KEY,
+		merchant_id  TEXT NOT NULL DEFAULT 'default',
+		name         TEXT,
+		email        TEXT,
+		phone_number TEXT,
+		stores       JSONB,
+		is_owner     BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at   TIMESTAMPTZ,
+		updated_at   TIMESTAMPTZ,
+		deleted_at   TIMESTAMPTZ
+	)`,
+	`CREATE TABLE IF NOT EXISTS loyposdevices (
+		pos_device_id TEXT PRIMARY KEY,
+		merchant_id   TEXT NOT NULL DEFAULT 'default',
+		name          TEXT,
+		store_id      TEXT,
+		activated     BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at    TIMESTAMPTZ,
+		updated_at    TIMESTAMPTZ,
+		deleted_at    TIMESTAMPTZ
+	)`,
+	`ALTER TABLE loyreceipts ADD COLUMN IF NOT EXISTS employee_id TEXT`,
+	`CREATE INDEX IF NOT EXISTS loyreceipts_employee_idx ON loyreceipts (merchant_id, employee_id)`,
+	`CREATE INDEX IF NOT EXISTS loyreceipts_pos_device_idx ON loyreceipts (merchant_id, pos_device_id)`,
+	`CREATE TABLE IF NOT EXISTS loyshifts (
+		shift_id           TEXT PRIMARY KEY,
+		merchant_id        TEXT NOT NULL DEFAULT 'default',
+		store_id           TEXT,
+		pos_device_id      TEXT,
+		opened_at          TIMESTAMPTZ NOT NULL,
+		closed_at          TIMESTAMPTZ,
+		opened_by_employee TEXT,
+		closed_by_employee TEXT,
+		starting_cash      NUMERIC NOT NULL DEFAULT 0,
+		cash_payments      NUMERIC NOT NULL DEFAULT 0,
+		cash_refunds       NUMERIC NOT NULL DEFAULT 0,
+		paid_in            NUMERIC NOT NULL DEFAULT 0,
+		paid_out           NUMERIC NOT NULL DEFAULT 0,
+		expected_cash      NUMERIC NOT NULL DEFAULT 0,
+		actual_cash        NUMERIC,
+		gross_sales        NUMERIC NOT NULL DEFAULT 0,
+		refunds            NUMERIC NOT NULL DEFAULT 0,
+		discounts          NUMERIC NOT NULL DEFAULT 0,
+		net_sales          NUMERIC NOT NULL DEFAULT 0,
+		tip                NUMERIC NOT NULL DEFAULT 0,
+		surcharge          NUMERIC NOT NULL DEFAULT 0,
+		created_at         TIMESTAMPTZ,
+		updated_at         TIMESTAMPTZ
+	)`,
+	`CREATE INDEX IF NOT EXISTS loyshifts_opened_idx ON loyshifts (merchant_id, opened_at)`,
+	`CREATE INDEX IF NOT EXISTS loyshifts_open_idx ON loyshifts (merchant_id) WHERE closed_at IS NULL`,
+	`CREATE TABLE IF NOT EXISTS loyshift_cash_movements (
+		shift_id     TEXT NOT NULL REFERENCES loyshifts (shift_id) ON DELETE CASCADE,
+		position     INTEGER NOT NULL,
+		type         TEXT NOT NULL,
+		money_amount NUMERIC NOT NULL DEFAULT 0,
+		comment      TEXT,
+		employee_id  TEXT,
+		created_at   TIMESTAMPTZ,
+		PRIMARY KEY (shift_id, position)
+	)`,
+
+	// การโหลดใบเสร็จย้อนหลังตามช่วงวันที่ (cursor ของหน้าถัดไปใช้ทำงานต่อเมื่อถูกขัดจังหวะ)
+	`CREATE TABLE IF NOT EXISTS receipt_backfills (
+		id             BIGSERIAL PRIMARY KEY,
+		merchant_id    TEXT NOT NULL,
+		store_id       TEXT NOT NULL DEFAULT '',
+		from_at        TIMESTAMPTZ NOT NULL,
+		to_at          TIMESTAMPTZ NOT NULL,
+		cursor         TEXT NOT NULL DEFAULT '',
+		status         TEXT NOT NULL DEFAULT 'running',
+		pages_fetched  INTEGER NOT NULL DEFAULT 0,
+		receipts_saved BIGINT NOT NULL DEFAULT 0,
+		sync_run_id    BIGINT,
+		error          TEXT,
+		created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		updated_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		finished_at    TIMESTAMPTZ
+	)`,
+	`CREATE INDEX IF NOT EXISTS receipt_backfills_range_idx ON receipt_backfills (merchant_id, store_id, from_at, to_at)`,
+	// ช่วงเดียวกันมีรายการ running ได้รายการเดียว (รายการซ้ำจากก่อนมี index นี้ถูกปิดเป็น failed ให้ resume ต่อได้)
+	`UPDATE receipt_backfills b SET status = 'failed', error = 'superseded by a newer run of the same range'
+	WHERE b.status = 'running' AND EXISTS (
+		SELECT 1 FROM receipt_backfills newer
+		WHERE newer.merchant_id = b.merchant_id AND newer.store_id = b.store_id AND newer.from_at = b.from_at
+		AND newer.to_at = b.to_at AND newer.status = 'running' AND newer.id > b.id
+	)`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS receipt_backfills_running_idx ON receipt_backfills (merchant_id, store_id, from_at, to_at) WHERE status = 'running'`,
+
+	// ประวัติสต็อกของแต่ละ variant/สาขา ต่อท้ายทุกครั้งที่ sync, รับ webhook หรือส่งการปรับสต็อกไป Loyverse
+	// (loyinventorylevels เก็บแค่ค่าล่าสุดและถูกล้างทุกครั้งที่ sync)
+	`CREATE TABLE IF NOT EXISTS loyinventory_history (
+		id          BIGSERIAL PRIMARY KEY,
+		merchant_id TEXT NOT NULL,
+		variant_id  TEXT NOT NULL,
+		store_id    TEXT NOT NULL,
+		in_stock    NUMERIC NOT NULL,
+		updated_at  TIMESTAMPTZ,
+		source      TEXT NOT NULL,
+		recorded_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE INDEX IF NOT EXISTS loyinventory_history_variant_idx ON loyinventory_history (merchant_id, variant_id, store_id, recorded_at)`,
+
+	// ผลการเทียบข้อมูลในฐานข้อมูลกับ Loyverse (finding ที่ยังเปิดอยู่มีได้หนึ่งรายการต่อ key)
+	`CREATE TABLE IF NOT EXISTS reconciliation_findings (
+		id            BIGSERIAL PRIMARY KEY,
+		merchant_id   TEXT NOT NULL,
+		kind          TEXT NOT NULL,
+		finding_key   TEXT NOT NULL,
+		business_date DATE,
+		store_id      TEXT NOT NULL DEFAULT '',
+		item_id       TEXT NOT NULL DEFAULT '',
+		variant_id    TEXT NOT NULL DEFAULT '',
+		local_count   BIGINT NOT NULL DEFAULT 0,
+		remote_count  BIGINT NOT NULL DEFAULT 0,
+		local_value   NUMERIC NOT NULL DEFAULT 0,
+		remote_value  NUMERIC NOT NULL DEFAULT 0,
+		detail        TEXT NOT NULL DEFAULT '',
+		status        TEXT NOT NULL DEFAULT 'open',
+		sync_run_id   BIGINT,
+		detected_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		last_seen_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		resolved_at   TIMESTAMPTZ
+	)`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS reconciliation_findings_open_key ON reconciliation_findings (merchant_id, finding_key) WHERE status = 'open'`,
+	`CREATE INDEX IF NOT EXISTS reconciliation_findings_status_idx ON reconciliation_findings (merchant_id, status, detected_at DESC)`,
+	// ยอดเงินของ finding ใบเสร็จเก็บแยกจากจำนวนสต็อก เขียนผ่าน Money เป็น NUMERIC โดยไม่ผ่าน float
+	`ALTER TABLE reconciliation_findings ADD COLUMN IF NOT EXISTS local_total NUMERIC NOT NULL DEFAULT 0`,
+	`ALTER TABLE reconciliation_findings ADD COLUMN IF NOT EXISTS remote_total NUMERIC NOT NULL DEFAULT 0`,
+	`UPDATE reconciliation_findings
+	SET local_total = local_value, remote_total = remote_value, local_value = 0, remote_value = 0
+	WHERE kind = 'receipts_day' AND (local_value <> 0 OR remote_value <> 0)`,
+
+	// ผล dry-run ของ master data/inventory sync พร้อมข้อมูลที่ดึงมา (payload) สำหรับ apply ภายหลัง
+	`CREATE TABLE IF NOT EXISTS sync_previews (
+		id          BIGSERIAL PRIMARY KEY,
+		merchant_id TEXT NOT NULL,
+		entity_type TEXT NOT NULL,
+		status      TEXT NOT NULL DEFAULT 'pending',
+		summary     JSONB NOT NULL DEFAULT '[]',
+		diff        JSONB NOT NULL DEFAULT '[]',
+		payload     JSONB NOT NULL,
+		sync_run_id BIGINT,
+		created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		applied_at  TIMESTAMPTZ
+	)`,
+	`CREATE INDEX IF NOT EXISTS sync_previews_merchant_idx ON sync_previews (merchant_id, created_at DESC)`,
+
+	// lock ของ sync ต่อ merchant และ entity ใช้ร่วมกันทั้ง cron, route เดิม, sync job และทุก process
+	`CREATE TABLE IF NOT EXISTS sync_locks (
+		merchant_id  TEXT NOT NULL,
+		entity_type  TEXT NOT NULL,
+		owner        TEXT NOT NULL,
+		acquired_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		heartbeat_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (merchant_id, entity_type)
+	)`,
+
+	// run จาก webhook ใช้ชื่อ entity แยกจาก sync เต็มรูปแบบ (เช่น receipts_webhook) ย้ายประวัติเดิมให้ตรงกัน
+	`UPDATE sync_runs SET entity_type = entity_type || '_webhook'
+	WHERE trigger = 'webhook' AND entity_type NOT LIKE '%\_webhook'`,
+}
+
+// EnsureSchema สร้างตารางที่จำเป็นถ้ายังไม่มี
+func EnsureSchema(db *sql.DB) error {
+	for _, stmt := range schemaStatements {
+		if _, err := db.Exec(stmt); err != nil {
+			log.Println("Error ensuring schema:", err)
+			return err
+		}
+	}
+	return nil
+}
@@ -2,22 +2,33 @@
 package repository
 
 import (
+	"backend/external/loyverse/models"
 	"database/sql"
 	"fmt"
 )
 
-// GetSetting รับค่าจากตาราง settings โดยใช้ key
-func GetSetting(db *sql.DB, key string) (string, error) {
+// settingKey คืนค่า key ในตาราง settings ของ merchant
+// merchant เริ่มต้นใช้ key เดิมเพื่อให้ค่าที่ตั้งไว้ก่อนรองรับหลายบัญชียังใช้ได้
+// merchant อื่นใช้ key ที่มี merchant_id นำหน้า เช่น "shop-2/receipts_sync_time"
+func settingKey(merchantID, key string) string {
+	if merchantID == "" || merchantID == models.DefaultMerchantID {
+		return key
+	}
+	return merchantID + "/" + key
+}
+
+// GetSetting รับค่าจากตาราง settings โดยใช้ key ของ merchant
+func GetSetting(db *sql.DB, merchantID, key string) (string, error) {
 	var value string
-	err := db.QueryRow("SELECT value FROM settings WHERE key = $1", key).Scan(&value)
+	err := db.QueryRow("SELECT value FROM settings WHERE key = $1", settingKey(merchantID, key)).Scan(&value)
 	if err != nil {
-		return "", fmt.Errorf("could not get setting: %v", err)
+		return "", fmt.Errorf("could not get setting: %w", err)
 	}
 	return value, nil
 }
 
-// UpdateSetting อัปเดตค่าจากตาราง settings โดยใช้ key
-func UpdateSetting(db *sql.DB, key, value string) error {
-	_, err := db.Exec("INSERT INTO settings (key, value) VALUES ($1, $2) ON CONFLICT (key) DO UPDATE SET value = $2", key, value)
+// UpdateSetting อัปเดตค่าจากตาราง settings โดยใช้ key ของ merchant
+func UpdateSetting(db *sql.DB, merchantID, key, value string) error {
+	_, err := db.Exec("INSERT INTO settings (key, value) VALUES ($1, $2) ON CONFLICT (key) DO UPDATE SET value = $2", settingKey(merchantID, key), value)
 	return err
 }
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"backend/external/loyverse/models"
+	"database/sql"
+	"log"
+	"time"
+)
+
+// SaveShifts บันทึกกะการขายและการนำเงินเข้า/ออกของแต่ละกะภายใน transaction เดียว
+// กะที่มีอยู่แล้ว (เช่นกะที่เพิ่งปิด) จะถูกอัปเดตทั้งแถวและการเคลื่อนไหวของเงินสดจะถูกแทนที่ทั้งชุด
+func SaveShifts(db *sql.DB, merchantID string, shifts []models.LoyShift) error {
+	tx, err := db.Begin()
+	if err != nil {
+		log.Println("Failed to begin transaction:", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, shift := range shifts {
+		if shift.ShiftID == "" {
+			log.Println("Skipping shift with empty id")
+			continue
+		}
+		_, err := tx.Exec(`
+			INSERT INTO loyshifts (
+				shift_id, merchant_id, store_id, pos_device_id, opened_at, closed_at, opened_by_employee, closed_by_employee,
+				starting_cash, cash_payments, cash_refunds, paid_in, paid_out, expected_cash, actual_cash,
+				gross_sales, refunds, discounts, net_sales, tip, surcharge, created_at, updated_at
+			) VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23)
+			ON CONFLICT (shift_id) DO UPDATE SET
+				merchant_id = $2, store_id = NULLIF($3, ''), pos_device_id = NULLIF($4, ''), opened_at = $5, closed_at = $6,
+				opened_by_employee = $7, closed_by_employee = $8, starting_cash = $9, cash_payments = $10, cash_refunds = $11,
+				paid_in = $12, paid_out = $13, expected_cash = $14, actual_cash = $15, gross_sales = $16, refunds = $17,
+				discounts = $18, net_sales = $19, tip = $20, surcharge = $21, created_at = $22, updated_at = $23`,
+			shift.ShiftID, merchantID, shift.StoreID, shift.PosDeviceID, shift.OpenedAt.In(time.UTC), shift.ClosedAt,
+			shift.OpenedByEmployee, shift.ClosedByEmployee, shift.StartingCash, shift.CashPayments, shift.CashRefunds,
+			shift.PaidIn, shift.PaidOut, shift.ExpectedCash, shift.ActualCash, shift.GrossSales, shift.Refunds,
+			shift.Discounts, shift.NetSales, shift.Tip, shift.Surcharge, nullTime(shift.CreatedAt), nullTime(shift.UpdatedAt),
+		)
+		if err != nil {
+			log.Printf("Error saving shift %s: %v", shift.ShiftID, err)
+			return err
+		}
+
+		if _, err := tx.Exec(`DELETE FROM loyshift_cash_movements WHERE shift_id = $1`, shift.ShiftID); err != nil {
+			return err
+		}
+		for i, movement := range shift.CashMovements {
+			_, err := tx.Exec(`
+				INSERT INTO loyshift_cash_movements (shift_id, position, type, money_amount, comment, employee_id, created_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+				shift.ShiftID, i+1, movement.Type, movement.MoneyAmount, movement.Comment, movement.EmployeeID, nullTime(movement.CreatedAt))
+			if err != nil {
+				log.Printf("Error saving cash movements of shift %s: %v", shift.ShiftID, err)
+				return err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("Error committing shifts:", err)
+		return err
+	}
+	return nil
+}
+
+// OldestOpenShift คืนเวลาเปิดของกะที่ยังไม่ปิดที่เก่าที่สุดของ merchant
+// ใช้ขยายช่วงการ sync ให้ครอบคลุมกะที่จะถูกปิดภายหลัง
+func OldestOpenShift(db *sql.DB, merchantID string) (time.Time, bool, error) {
+	var openedAt sql.NullTime
+	err := db.QueryRow(`SELECT MIN(opened_at) FROM loyshifts WHERE merchant_id = $1 AND closed_at IS NULL`, merchantID).Scan(&openedAt)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return openedAt.Time, openedAt.Valid, nil
+}
+
+// nullTime แปลงเวลาที่ไม่ได้ส่งมา (ค่า zero) เป็น NULL
+func nullTime(t time.Time) sql.NullTime {
+	if t.IsZero() {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.In(time.UTC), Valid: true}
+}
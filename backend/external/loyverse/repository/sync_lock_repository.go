@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// SyncLockLease คือเวลาที่ lock ยังถือว่ามีเจ้าของอยู่นับจาก heartbeat ล่าสุด
+// ถ้า process ที่ถือ lock หยุดไปโดยไม่ปลด lock ผู้อื่นจะได้ lock ต่อหลังเวลานี้
+const SyncLockLease = 5 * time.Minute
+
+// ErrSyncLocked คือ sync ของ merchant และ entity เดียวกันที่กำลังทำงานอยู่ที่อื่น (cron, route เดิม, job หรือ process อื่น)
+var ErrSyncLocked = errors.New("a sync of this entity is already running")
+
+// AcquireSyncLock จอง lock ของ merchant และ entity ให้ owner
+// คืนค่า ErrSyncLocked ถ้ามีผู้อื่นถือ lock อยู่และยังส่ง heartbeat ภายใน SyncLockLease
+func AcquireSyncLock(db *sql.DB, merchantID, entityType, owner string) error {
+	var locked string
+	err := db.QueryRow(`
+		INSERT INTO sync_locks (merchant_id, entity_type, owner)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (merchant_id, entity_type) DO UPDATE
+		SET owner = EXCLUDED.owner, acquired_at = NOW(), heartbeat_at = NOW()
+		WHERE sync_locks.heartbeat_at < NOW() - $4 * INTERVAL '1 second'
+		RETURNING owner`,
+		merchantID, entityType, owner, SyncLockLease.Seconds(),
+	).Scan(&locked)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s sync of %s: %w", entityType, merchantID, ErrSyncLocked)
+	}
+	if err != nil {
+		log.Println("Error acquiring sync lock:", err)
+	}
+	return err
+}
+
+// RenewSyncLock ต่ออายุ lock ที่ owner ถืออยู่
+func RenewSyncLock(db *sql.DB, merchantID, entityType, owner string) error {
+	_, err := db.Exec(`
+		UPDATE sync_locks SET heartbeat_at = NOW()
+		WHERE merchant_id = $1 AND entity_type = $2 AND owner = $3`,
+		merchantID, entityType, owner)
+	if err != nil {
+		log.Println("Error renewing sync lock:", err)
+	}
+	return err
+}
+
+// ReleaseSyncLock ปลด lock ถ้า owner ยังเป็นเจ้าของอยู่
+func ReleaseSyncLock(db *sql.DB, merchantID, entityType, owner string) error {
+	_, err := db.Exec(`DELETE FROM sync_locks WHERE merchant_id = $1 AND entity_type = $2 AND owner = $3`,
+		merchantID, entityType, owner)
+	if err != nil {
+		log.Println("Error releasing sync lock:", err)
+	}
+	return err
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"backend/external/loyverse/models"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+const syncPreviewColumns = `id, merchant_id, entity_type, status, summary, diff, sync_run_id, created_at, applied_at`
+
+// SaveSyncPreview บันทึกผล dry-run พร้อมข้อมูลที่ดึงมา (payload) และเติม ID, Status และ CreatedAt ให้ preview
+func SaveSyncPreview(db *sql.DB, preview *models.SyncPreview, payload []byte) error {
+	summaryJSON, err := json.Marshal(preview.Summary)
+	if err != nil {
+		return err
+	}
+	diffJSON, err := json.Marshal(preview.Diff)
+	if err != nil {
+		return err
+	}
+
+	preview.Status = models.SyncPreviewPending
+	err = db.QueryRow(`
+		INSERT INTO sync_previews (merchant_id, entity_type, status, summary, diff, payload)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`,
+		preview.MerchantID, preview.EntityType, preview.Status, summaryJSON, diffJSON, payload,
+	).Scan(&preview.ID, &preview.CreatedAt)
+	if err != nil {
+		log.Println("Error saving sync preview:", err)
+	}
+	return err
+}
+
+// GetSyncPreview อ่าน preview ของ merchant พร้อม payload ที่ใช้ apply
+func GetSyncPreview(db *sql.DB, merchantID string, id int64) (*models.SyncPreview, []byte, error) {
+	preview, payload, err := scanSyncPreview(db.QueryRow(`
+		SELECT `+syncPreviewColumns+`, payload
+		FROM sync_previews
+		WHERE merchant_id = $1 AND id = $2`,
+		merchantID, id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil, fmt.Errorf("sync preview %d not found: %w", id, sql.ErrNoRows)
+	}
+	if err != nil {
+		log.Println("Error getting sync preview:", err)
+	}
+	return preview, payload, err
+}
+
+// ClaimSyncPreview จอง preview ที่ยัง pending ไว้ apply (สถานะเป็น applying) ในคำสั่งเดียว
+// คำขอ apply ที่มาพร้อมกันจึงมีเพียงรายการเดียวที่จองได้ ที่เหลือได้ sql.ErrNoRows
+func ClaimSyncPreview(db *sql.DB, merchantID string, id int64) (*models.SyncPreview, []byte, error) {
+	preview, payload, err := scanSyncPreview(db.QueryRow(`
+		UPDATE sync_previews SET status = $4
+		WHERE merchant_id = $1 AND id = $2 AND status = $3
+		RETURNING `+syncPreviewColumns+`, payload`,
+		merchantID, id, models.SyncPreviewPending, models.SyncPreviewApplying,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil, fmt.Errorf("pending sync preview %d not found: %w", id, sql.ErrNoRows)
+	}
+	if err != nil {
+		log.Println("Error claiming sync preview:", err)
+	}
+	return preview, payload, err
+}
+
+// ReleaseSyncPreview คืน preview ที่จองไว้กลับเป็น pending เมื่อ apply ไม่สำเร็จ
+func ReleaseSyncPreview(db *sql.DB, preview *models.SyncPreview) error {
+	_, err := db.Exec(`UPDATE sync_previews SET status = $3 WHERE id = $1 AND status = $2`,
+		preview.ID, models.SyncPreviewApplying, models.SyncPreviewPending)
+	if err != nil {
+		log.Println("Error releasing sync preview:", err)
+		return err
+	}
+	preview.Status = models.SyncPreviewPending
+	return nil
+}
+
+// MarkSyncPreviewApplied บันทึกว่า preview ที่จองไว้ถูก apply แล้วโดย sync run ใด
+func MarkSyncPreviewApplied(db *sql.DB, preview *models.SyncPreview, syncRunID int64) error {
+	var appliedAt time.Time
+	err := db.QueryRow(`
+		UPDATE sync_previews SET status = $2, sync_run_id = $3, applied_at = NOW()
+		WHERE id = $1 AND status = $4
+		RETURNING applied_at`,
+		preview.ID, models.SyncPreviewApplied, syncRunID, models.SyncPreviewApplying,
+	).Scan(&appliedAt)
+	if err != nil {
+		log.Println("Error marking sync preview applied:", err)
+		return err
+	}
+	preview.Status = models.SyncPreviewApplied
+	preview.SyncRunID = &syncRunID
+	preview.AppliedAt = &appliedAt
+	return nil
+}
+
+// scanSyncPreview อ่าน syncPreviewColumns ตามด้วย payload
+func scanSyncPreview(row rowScanner) (*models.SyncPreview, []byte, error) {
+	var preview models.SyncPreview
+	var summaryJSON, diffJSON, payload []byte
+	var syncRunID sql.NullInt64
+	var appliedAt sql.NullTime
+	err := row.Scan(
+		&preview.ID, &preview.MerchantID, &preview.EntityType, &preview.Status, &summaryJSON, &diffJSON,
+		&syncRunID, &preview.CreatedAt, &appliedAt, &payload,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := json.Unmarshal(summaryJSON, &preview.Summary); err != nil {
+		return nil, nil, err
+	}
+	if err := json.Unmarshal(diffJSON, &preview.Diff); err != nil {
+		return nil, nil, err
+	}
+	if syncRunID.Valid {
+		preview.SyncRunID = &syncRunID.Int64
+	}
+	if appliedAt.Valid {
+		preview.AppliedAt = &appliedAt.Time
+	}
+	return &preview, payload, nil
+}
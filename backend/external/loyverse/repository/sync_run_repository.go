@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"backend/external/loyverse/models"
+	"database/sql"
+	"log"
+)
+
+const syncRunColumns = `id, merchant_id, entity_type, trigger, status, started_at, finished_at, pages_fetched, rows_upserted, rows_deleted, error`
+
+// StartSyncRun บันทึกการเริ่ม sync ของ merchant และคืนค่า run ที่ใช้สะสม counters ระหว่างทำงาน
+func StartSyncRun(db *sql.DB, merchantID, entityType, trigger string) (*models.SyncRun, error) {
+	run := &models.SyncRun{MerchantID: merchantID, EntityType: entityType, Trigger: trigger, Status: models.SyncStatusRunning}
+	err := db.QueryRow(`
+		INSERT INTO sync_runs (merchant_id, entity_type, trigger, status, started_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		RETURNING id, started_at`,
+		merchantID, entityType, trigger, run.Status,
+	).Scan(&run.ID, &run.StartedAt)
+	if err != nil {
+		log.Println("Error starting sync run:", err)
+		return nil, err
+	}
+	return run, nil
+}
+
+// FinishSyncRun บันทึกผลลัพธ์และ counters ของ run เมื่อ sync จบ
+func FinishSyncRun(db *sql.DB, run *models.SyncRun, syncErr error) error {
+	run.Status = models.SyncStatusSucceeded
+	var errorText sql.NullString
+	if syncErr != nil {
+		run.Status = models.SyncStatusFailed
+		errorText = sql.NullString{String: syncErr.Error(), Valid: true}
+	}
+
+	var finishedAt sql.NullTime
+	err := db.QueryRow(`
+		UPDATE sync_runs
+		SET status = $2, finished_at = NOW(), pages_fetched = $3, rows_upserted = $4, rows_deleted = $5, error = $6
+		WHERE id = $1
+		RETURNING finished_at`,
+		run.ID, run.Status, run.PagesFetched, run.RowsUpserted, run.RowsDeleted, errorText,
+	).Scan(&finishedAt)
+	if err != nil {
+		log.Println("Error finishing sync run:", err)
+		return err
+	}
+	run.FinishedAt = &finishedAt.Time
+	if errorText.Valid {
+		run.Error = &errorText.String
+	}
+	return nil
+}
+
+// UpdateSyncRunProgress บันทึก counters ของ run ที่ยังทำงานอยู่ เพื่อให้ /api/sync/runs แสดงความคืบหน้าได้
+func UpdateSyncRunProgress(db *sql.DB, run *models.SyncRun) error {
+	_, err := db.Exec(`
+		UPDATE sync_runs SET pages_fetched = $2, rows_upserted = $3, rows_deleted = $4
+		WHERE id = $1 AND status = $5`,
+		run.ID, run.PagesFetched, run.RowsUpserted, run.RowsDeleted, models.SyncStatusRunning,
+	)
+	if err != nil {
+		log.Println("Error updating sync run progress:", err)
+	}
+	return err
+}
+
+// ListSyncRuns แสดงประวัติการ sync ล่าสุดของ merchant กรองตาม entity ได้ (ว่าง = ทั้งหมด)
+func ListSyncRuns(db *sql.DB, merchantID, entityType string, limit int) ([]models.SyncRun, error) {
+	rows, err := db.Query(`
+		SELECT `+syncRunColumns+`
+		FROM sync_runs
+		WHERE merchant_id = $1 AND ($2 = '' OR entity_type = $2)
+		ORDER BY started_at DESC
+		LIMIT $3`,
+		merchantID, entityType, limit,
+	)
+	if err != nil {
+		log.Println("Error listing sync runs:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []models.SyncRun{}
+	for rows.Next() {
+		var run models.SyncRun
+		if err := scanSyncRun(rows, &run); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// GetSyncStatuses คืนค่า run ล่าสุดและ run ที่สำเร็จล่าสุดของแต่ละ entity ของ merchant
+func GetSyncStatuses(db *sql.DB, merchantID string) ([]models.SyncStatus, error) {
+	rows, err := db.Query(`
+		SELECT DISTINCT ON (entity_type, succeeded) `+syncRunColumns+`
+		FROM (
+			SELECT *, status = 'succeeded' AS succeeded FROM sync_runs WHERE merchant_id = $1
+		) runs
+		ORDER BY entity_type, succeeded, started_at DESC`, merchantID)
+	if err != nil {
+		log.Println("Error getting sync status:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	statuses := []models.SyncStatus{}
+	index := map[string]int{}
+	for rows.Next() {
+		var run models.SyncRun
+		if err := scanSyncRun(rows, &run); err != nil {
+			return nil, err
+		}
+
+		i, ok := index[run.EntityType]
+		if !ok {
+			i = len(statuses)
+			index[run.EntityType] = i
+			statuses = append(statuses, models.SyncStatus{EntityType: run.EntityType})
+		}
+		status := &statuses[i]
+		if run.Status == models.SyncStatusSucceeded {
+			status.LastSuccess = &run
+		}
+		if status.LastRun == nil || run.StartedAt.After(status.LastRun.StartedAt) {
+			status.LastRun = &run
+		}
+	}
+	return statuses, rows.Err()
+}
+
+func scanSyncRun(row rowScanner, run *models.SyncRun) error {
+	var finishedAt sql.NullTime
+	var errorText sql.NullString
+	if err := row.Scan(
+		&run.ID, &run.MerchantID, &run.EntityType, &run.Trigger, &run.Status, &run.StartedAt, &finishedAt,
+		&run.PagesFetched, &run.RowsUpserted, &run.RowsDeleted, &errorText,
+	); err != nil {
+		return err
+	}
+	if finishedAt.Valid {
+		run.FinishedAt = &finishedAt.Time
+	}
+	if errorText.Valid {
+		run.Error = &errorText.String
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// watermarkKey สร้าง key ในตาราง settings สำหรับเก็บ high-water mark ของแต่ละ entity
+func watermarkKey(entity string) string {
+	return entity + "_sync_watermark"
+}
+
+// GetSyncWatermark อ่านค่า updated_at ล่าสุดที่ sync สำเร็จของ entity นั้น ๆ ของ merchant
+// คืนค่า ok = false ถ้ายังไม่เคย sync มาก่อน
+func GetSyncWatermark(db *sql.DB, merchantID, entity string) (time.Time, bool, error) {
+	value, err := GetSetting(db, merchantID, watermarkKey(entity))
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && value == "") {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("could not get watermark for %s: %v", entity, err)
+	}
+
+	watermark, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid watermark for %s: %v", entity, err)
+	}
+	return watermark, true, nil
+}
+
+// SaveSyncWatermark บันทึก high-water mark ของ entity ของ merchant ลงในตาราง settings
+func SaveSyncWatermark(db *sql.DB, merchantID, entity string, watermark time.Time) error {
+	return UpdateSetting(db, merchantID, watermarkKey(entity), watermark.UTC().Format(time.RFC3339Nano))
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"backend/external/loyverse/models"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// execer คือส่วนที่ใช้ร่วมกันของ *sql.DB และ *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// variantColumns คือคอลัมน์ของ loyvariants ที่ข้อมูลมาจาก Loyverse (variant_id เป็น key)
+var variantColumns = []string{
+	"variant_id", "item_id", "sku", "barcode", "reference_variant_id", "option1_value", "option2_value", "option3_value",
+	"cost", "purchase_cost", "default_pricing_type", "default_price", "created_at", "updated_at",
+}
+
+var variantColumnTypes = []string{
+	"TEXT", "TEXT", "TEXT", "TEXT", "TEXT", "TEXT", "TEXT", "TEXT",
+	"NUMERIC", "NUMERIC", "TEXT", "NUMERIC", "TIMESTAMPTZ", "TIMESTAMPTZ",
+}
+
+// variantRow เรียงค่าของ variant ตามลำดับ variantColumns
+// item_id มาจากสินค้าที่ variant อยู่ เผื่อกรณี API ไม่ได้ส่ง item_id มาใน variant
+func variantRow(item models.LoyItem, variant models.Variant) []interface{} {
+	return []interface{}{
+		variant.VariantID, item.ID, variant.SKU, variant.Barcode, variant.ReferenceVariantID,
+		variant.Option1Value, variant.Option2Value, variant.Option3Value,
+		variant.Cost, variant.PurchaseCost, variant.DefaultPricingType, variant.DefaultPrice,
+		variant.CreatedAt, variant.UpdatedAt,
+	}
+}
+
+// variantDeletedAt คือเวลาที่ variant ถูกลบ (variant ของสินค้าที่ถูกลบถือว่าถูกลบไปด้วย)
+func variantDeletedAt(item models.LoyItem, variant models.Variant) interface{} {
+	if variant.DeletedAt != nil {
+		return variant.DeletedAt
+	}
+	return item.DeletedAt
+}
+
+// saveVariants บันทึก variant และราคารายสาขาของสินค้าที่ได้จาก webhook หรือการแก้ไขสินค้า
+func saveVariants(db execer, merchantID string, item models.LoyItem) error {
+	placeholders := make([]string, len(variantColumns)+2)
+	updates := make([]string, 0, len(variantColumns)+1)
+	for i, column := range variantColumns {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		if i > 0 {
+			updates = append(updates, column+" = EXCLUDED."+column)
+		}
+	}
+	placeholders[len(variantColumns)] = fmt.Sprintf("$%d", len(variantColumns)+1)
+	placeholders[len(variantColumns)+1] = fmt.Sprintf("$%d", len(variantColumns)+2)
+	updates = append(updates, "deleted_at = EXCLUDED.deleted_at", "merchant_id = EXCLUDED.merchant_id")
+
+	query := fmt.Sprintf(`
+		INSERT INTO loyvariants (%s, deleted_at, merchant_id)
+		VALUES (%s)
+		ON CONFLICT (variant_id) DO UPDATE SET %s`,
+		strings.Join(variantColumns, ", "), strings.Join(placeholders, ", "), strings.Join(updates, ", "))
+
+	for _, variant := range item.Variants {
+		if variant.VariantID == "" {
+			continue
+		}
+		args := append(variantRow(item, variant), variantDeletedAt(item, variant), merchantID)
+		if _, err := db.Exec(query, args...); err != nil {
+			return err
+		}
+	}
+	return saveVariantStores(db, []models.LoyItem{item})
+}
+
+// saveVariantStores แทนที่ราคาและสถานะการขายรายสาขาของทุก variant ในสินค้าที่ระบุ
+func saveVariantStores(db execer, items []models.LoyItem) error {
+	for _, item := range items {
+		for _, variant := range item.Variants {
+			if variant.VariantID == "" {
+				continue
+			}
+			if _, err := db.Exec(`DELETE FROM loyvariant_stores WHERE variant_id = $1`, variant.VariantID); err != nil {
+				return err
+			}
+			for _, store := range variant.Stores {
+				_, err := db.Exec(`
+					INSERT INTO loyvariant_stores (variant_id, store_id, pricing_type, price, available_for_sale, optimal_stock, low_stock)
+					VALUES ($1, $2, $3, $4, $5, $6, $7)
+					ON CONFLICT (variant_id, store_id) DO UPDATE
+					SET pricing_type = $3, price = $4, available_for_sale = $5, optimal_stock = $6, low_stock = $7`,
+					variant.VariantID, store.StoreID, store.PricingType, store.Price, store.AvailableForSale, store.OptimalStock, store.LowStock)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
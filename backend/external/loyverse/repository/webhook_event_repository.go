@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"backend/external/loyverse/models"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// webhookEventColumns คอลัมน์ที่ใช้ scan ลง models.WebhookEvent (ไม่รวม payload)
+const webhookEventColumns = `delivery_id, merchant_id, event_type, status, attempts, last_error, received_at, processed_at, next_attempt_at`
+
+// EnqueueWebhookEvent บันทึก webhook ของ merchant ลง inbox เพื่อรอ worker ประมวลผล
+// คืนค่า inserted = false ถ้า delivery id นี้ของ merchant เคยถูกบันทึกไว้แล้ว (delivery ซ้ำ)
+func EnqueueWebhookEvent(db *sql.DB, merchantID, deliveryID, eventType string, payload []byte) (inserted bool, err error) {
+	result, err := db.Exec(`
+		INSERT INTO webhook_events (delivery_id, merchant_id, event_type, payload, status, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (merchant_id, delivery_id) DO NOTHING`,
+		deliveryID, merchantID, eventType, payload, models.WebhookStatusPending,
+	)
+	if err != nil {
+		log.Println("Error enqueueing webhook event:", err)
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// ClaimDueWebhookEvents จองรายการที่ถึงเวลาประมวลผลเพื่อให้ worker หนึ่งตัวทำงาน
+// รายการที่ถูกจองจะมีสถานะ processing และ next_attempt_at ถูกเลื่อนออกไปเท่ากับ lease
+// ถ้า worker ตายระหว่างทาง รายการจะถูกจองใหม่ได้หลัง lease หมดอายุ
+func ClaimDueWebhookEvents(db *sql.DB, limit int, lease time.Duration) ([]models.WebhookEvent, error) {
+	rows, err := db.Query(`
+		UPDATE webhook_events
+		SET status = $1, attempts = attempts + 1, next_attempt_at = NOW() + $2 * INTERVAL '1 second'
+		WHERE (merchant_id, delivery_id) IN (
+			SELECT merchant_id, delivery_id FROM webhook_events
+			WHERE status IN ($3, $4, $1) AND next_attempt_at <= NOW()
+			ORDER BY received_at
+			LIMIT $5
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+webhookEventColumns+`, payload`,
+		models.WebhookStatusProcessing, lease.Seconds(), models.WebhookStatusPending, models.WebhookStatusFailed, limit,
+	)
+	if err != nil {
+		log.Println("Error claiming webhook events:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.WebhookEvent
+	for rows.Next() {
+		var event models.WebhookEvent
+		if err := scanWebhookEvent(rows, &event, true); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// MarkWebhookEventProcessed ตั้งสถานะ processed หลังจากประมวลผล webhook สำเร็จ
+func MarkWebhookEventProcessed(db *sql.DB, merchantID, deliveryID string) error {
+	_, err := db.Exec(`
+		UPDATE webhook_events
+		SET status = $3, processed_at = NOW(), last_error = NULL
+		WHERE merchant_id = $1 AND delivery_id = $2`,
+		merchantID, deliveryID, models.WebhookStatusProcessed,
+	)
+	if err != nil {
+		log.Println("Error marking webhook event processed:", err)
+	}
+	return err
+}
+
+// MarkWebhookEventFailed บันทึก error และกำหนดเวลาลองใหม่
+// ถ้า dead เป็น true รายการจะถูกย้ายไปสถานะ dead และจะไม่ถูกลองใหม่อัตโนมัติ
+func MarkWebhookEventFailed(db *sql.DB, merchantID, deliveryID string, cause error, retryAfter time.Duration, dead bool) error {
+	status := models.WebhookStatusFailed
+	if dead {
+		status = models.WebhookStatusDead
+	}
+	_, err := db.Exec(`
+		UPDATE webhook_events
+		SET status = $3, last_error = $4, next_attempt_at = NOW() + $5 * INTERVAL '1 second'
+		WHERE merchant_id = $1 AND delivery_id = $2`,
+		merchantID, deliveryID, status, cause.Error(), retryAfter.Seconds(),
+	)
+	if err != nil {
+		log.Println("Error marking webhook event failed:", err)
+	}
+	return err
+}
+
+// ListWebhookEvents แสดงรายการ webhook ล่าสุดของ merchant กรองตามสถานะได้ (ว่าง = ทุกสถานะ)
+func ListWebhookEvents(db *sql.DB, merchantID, status string, limit int) ([]models.WebhookEvent, error) {
+	rows, err := db.Query(`
+		SELECT `+webhookEventColumns+`
+		FROM webhook_events
+		WHERE merchant_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY received_at DESC
+		LIMIT $3`,
+		merchantID, status, limit,
+	)
+	if err != nil {
+		log.Println("Error listing webhook events:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.WebhookEvent{}
+	for rows.Next() {
+		var event models.WebhookEvent
+		if err := scanWebhookEvent(rows, &event, false); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// GetWebhookEvent อ่าน webhook หนึ่งรายการของ merchant พร้อม payload
+func GetWebhookEvent(db *sql.DB, merchantID, deliveryID string) (models.WebhookEvent, error) {
+	var event models.WebhookEvent
+	row := db.QueryRow(`SELECT `+webhookEventColumns+`, payload FROM webhook_events WHERE merchant_id = $1 AND delivery_id = $2`, merchantID, deliveryID)
+	if err := scanWebhookEvent(row, &event, true); err != nil {
+		return event, err
+	}
+	return event, nil
+}
+
+// ReplayWebhookEvent ส่ง webhook ของ merchant กลับเข้าคิวเพื่อประมวลผลใหม่ทันที
+func ReplayWebhookEvent(db *sql.DB, merchantID, deliveryID string) error {
+	result, err := db.Exec(`
+		UPDATE webhook_events
+		SET status = $3, attempts = 0, last_error = NULL, processed_at = NULL, next_attempt_at = NOW()
+		WHERE merchant_id = $1 AND delivery_id = $2`,
+		merchantID, deliveryID, models.WebhookStatusPending,
+	)
+	if err != nil {
+		log.Println("Error replaying webhook event:", err)
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("webhook event %s not found: %w", deliveryID, sql.ErrNoRows)
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanWebhookEvent อ่าน webhookEventColumns (และ payload ถ้า withPayload เป็น true) ลงใน event
+func scanWebhookEvent(row rowScanner, event *models.WebhookEvent, withPayload bool) error {
+	var payload []byte
+	var lastError sql.NullString
+	var processedAt, nextAttemptAt sql.NullTime
+	dest := []interface{}{
+		&event.DeliveryID, &event.MerchantID, &event.EventType, &event.Status, &event.Attempts,
+		&lastError, &event.ReceivedAt, &processedAt, &nextAttemptAt,
+	}
+	if withPayload {
+		dest = append(dest, &payload)
+	}
+	if err := row.Scan(dest...); err != nil {
+		return err
+	}
+	event.Payload = payload
+	if lastError.Valid {
+		event.LastError = &lastError.String
+	}
+	if processedAt.Valid {
+		event.ProcessedAt = &processedAt.Time
+	}
+	if nextAttemptAt.Valid {
+		event.NextAttemptAt = &nextAttemptAt.Time
+	}
+	return nil
+}
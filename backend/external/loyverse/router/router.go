@@ -2,28 +2,68 @@
 package router
 
 import (
+	"backend/external/loyverse/config"
 	"backend/external/loyverse/handlers"
+	"backend/external/loyverse/services"
 	"database/sql"
 	"net/http"
 )
 
 // RegisterRoutes ตั้งค่า routes สำหรับ loyverse API
-func RegisterRoutes(mux *http.ServeMux, db *sql.DB) {
+func RegisterRoutes(mux *http.ServeMux, db *sql.DB, scheduler handlers.Scheduler, jobs *services.SyncJobManager) {
 	// API endpoints สำหรับการซิงค์ข้อมูล
 	mux.HandleFunc("/api/sync-master-data", handlers.SyncMasterDataHandler)
 	mux.HandleFunc("/api/sync-receipts", handlers.SyncReceiptsHandler)
 	mux.HandleFunc("/api/sync-inventory-levels", handlers.SyncInventoryLevelsHandler)
+	mux.HandleFunc("/api/sync-shifts", handlers.SyncShiftsHandler)
 
-	// Webhook endpoint สำหรับรับข้อมูลจาก Loyverse โดยใช้ closure เพื่อส่ง db
-	mux.HandleFunc("/webhook/loyverse", func(w http.ResponseWriter, r *http.Request) {
-		handlers.LoyverseWebhookHandler(db, w, r)
-	})
+	// sync แบบ job เบื้องหลัง ตอบกลับด้วย job id ทันทีและติดตามความคืบหน้าผ่าน SSE ที่ /api/sync/jobs/{id}/events
+	mux.HandleFunc("/api/sync/jobs", handlers.SyncJobsHandler(db, jobs))
+	mux.HandleFunc("/api/sync/jobs/", handlers.SyncJobHandler(jobs))
+
+	// ประวัติและสถานะการซิงค์
+	mux.HandleFunc("/api/sync/runs", handlers.ListSyncRunsHandler(db))
+	mux.HandleFunc("/api/sync/status", handlers.GetSyncStatusHandler(db))
+
+	// โหลดใบเสร็จย้อนหลังตามช่วงวันที่ (ทำต่อจาก cursor ล่าสุดได้ถ้าถูกขัดจังหวะ)
+	mux.HandleFunc("/api/sync/receipts/backfill", handlers.ReceiptBackfillHandler(db))
+
+	// เทียบข้อมูลในฐานข้อมูลกับ Loyverse และ resync เฉพาะส่วนที่ไม่ตรงกัน
+	mux.HandleFunc("/api/reconciliation/run", handlers.ReconciliationRunHandler(db))
+	mux.HandleFunc("/api/reconciliation/findings", handlers.ListReconciliationFindingsHandler(db))
+	mux.HandleFunc("/api/reconciliation/findings/resync", handlers.ResyncReconciliationFindingHandler(db))
+
+	// diff จาก ?dry_run=true ของ sync-master-data และ sync-inventory-levels และการ apply diff นั้น
+	mux.HandleFunc("/api/sync/previews", handlers.GetSyncPreviewHandler(db))
+	mux.HandleFunc("/api/sync/previews/apply", handlers.ApplySyncPreviewHandler(db))
+
+	// บัญชี Loyverse ที่เชื่อมต่อ (token และ webhook secret ของแต่ละ merchant)
+	mux.HandleFunc("/api/connections", handlers.ConnectionsHandler(db))
+
+	// Webhook endpoint สำหรับรับข้อมูลจาก Loyverse (?merchant_id= ตรวจสอบลายเซ็นด้วย secret ของ connection
+	// หรือ LOYVERSE_WEBHOOK_SECRET สำหรับ merchant default)
+	mux.HandleFunc("/webhook/loyverse", handlers.LoyverseWebhookHandler(db, handlers.ConnectionWebhookSecrets(db, config.GetWebhookSecret())))
+
+	// Admin endpoints สำหรับดูและ replay webhook ที่ล้มเหลว
+	mux.HandleFunc("/api/webhook-events", handlers.ListWebhookEventsHandler(db))
+	mux.HandleFunc("/api/webhook-events/detail", handlers.GetWebhookEventHandler(db))
+	mux.HandleFunc("/api/webhook-events/replay", handlers.ReplayWebhookEventHandler(db))
+
+	// ส่งการปรับสต็อกและการแก้ไขสินค้า/ซัพพลายเออร์กลับไปยัง Loyverse ผ่านคิว outbound_changes
+	mux.HandleFunc("/api/outbound/inventory", handlers.EnqueueInventoryAdjustmentsHandler(db))
+	mux.HandleFunc("/api/outbound/items", handlers.EnqueueItemUpdateHandler(db))
+	mux.HandleFunc("/api/outbound/suppliers", handlers.EnqueueSupplierUpdateHandler(db))
+	mux.HandleFunc("/api/outbound/changes", handlers.ListOutboundChangesHandler(db))
+	mux.HandleFunc("/api/outbound/changes/retry", handlers.RetryOutboundChangeHandler(db))
 
 	mux.HandleFunc("/api/update-settings", func(w http.ResponseWriter, r *http.Request) {
-		handlers.UpdateSettingsHandler(db).ServeHTTP(w, r)
+		handlers.UpdateSettingsHandler(db, scheduler).ServeHTTP(w, r)
 	})
 
 	mux.HandleFunc("/api/get-settings", func(w http.ResponseWriter, r *http.Request) {
 		handlers.GetSettingsHandler(db).ServeHTTP(w, r)
 	})
+
+	// ตารางเวลาของ background jobs (เวลา Asia/Bangkok)
+	mux.HandleFunc("/api/schedule", handlers.GetScheduleHandler(scheduler))
 }
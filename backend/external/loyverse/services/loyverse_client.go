@@ -0,0 +1,26 @@
+package services
+
+import (
+	"backend/external/loyverse/api"
+	"backend/external/loyverse/config"
+	"backend/external/loyverse/models"
+	"log"
+)
+
+// NewLoyverseClient สร้าง client สำหรับเรียก Loyverse API ด้วย token ของ connection
+// ถ้า connection ไม่ได้กำหนด base URL แต่ตั้ง LOYVERSE_API_BASE_URL ไว้จะเรียกไปยัง URL นั้นแทน API จริง
+// ถ้าตั้ง LOYVERSE_API_MODE=record|replay จะบันทึกหรือเล่นซ้ำ response จาก LOYVERSE_FIXTURES_DIR
+func NewLoyverseClient(conn models.Connection) *api.Client {
+	client := api.NewClient(conn.APIToken)
+	if conn.BaseURL != "" {
+		client.BaseURL = conn.BaseURL
+	} else if baseURL := config.GetLoyverseBaseURL(); baseURL != "" {
+		client.BaseURL = baseURL
+	}
+	if mode, dir := config.GetLoyverseRecorder(); mode != "" {
+		if err := client.HTTP.UseRecorder(mode, dir); err != nil {
+			log.Fatalf("Invalid LOYVERSE_API_MODE: %v", err)
+		}
+	}
+	return client
+}
@@ -1,124 +1,120 @@
 package services
 
 import (
+	"backend/external/loyverse/api"
 	"backend/external/loyverse/models"
-	"backend/external/loyverse/utils"
-	"encoding/json"
+	"context"
 	"log"
-	"os"
 )
 
-// API Endpoints สำหรับ Master Data
-const (
-	CategoriesAPIEndpoint   = "https://api.loyverse.com/v1.0/categories?limit=250"
-	ItemsAPIEndpoint        = "https://api.loyverse.com/v1.0/items?limit=250"
-	PaymentTypesAPIEndpoint = "https://api.loyverse.com/v1.0/payment_types?limit=250"
-	StoresAPIEndpoint       = "https://api.loyverse.com/v1.0/stores?limit=250"
-	SuppliersAPIEndpoint    = "https://api.loyverse.com/v1.0/suppliers?limit=250"
-)
-
-// FetchMasterData ดึงข้อมูล master data ทั้งหมดจาก Loyverse API
-func FetchMasterData() (models.LoyMasterData, error) {
-	token := os.Getenv("LOYVERSE_API_TOKEN")
+// FetchMasterData ดึงข้อมูล master data ทั้งหมดของ connection จาก Loyverse API (ทุกหน้าตาม cursor)
+func FetchMasterData(ctx context.Context, conn models.Connection) (models.LoyMasterData, error) {
+	client := NewLoyverseClient(conn)
 	var masterData models.LoyMasterData
+	var err error
+
+	// ขอรายการที่ถูกลบด้วย เพื่อให้ deleted_at ในฐานข้อมูลตรงกับ Loyverse
+	opts := api.ListOptions{ShowDeleted: true}
 
 	// Fetch Categories
-	if err := fetchData(CategoriesAPIEndpoint, token, &masterData.Categories); err != nil {
+	itCategories := client.Categories(opts)
+	masterData.Categories, err = itCategories.All(ctx)
+	masterData.PagesFetched += itCategories.Pages()
+	if err != nil {
 		log.Println("Error fetching categories:", err)
 		return masterData, err
 	}
 
 	// Fetch Items
-	if err := fetchData(ItemsAPIEndpoint, token, &masterData.Items); err != nil {
+	itItems := client.Items(opts)
+	masterData.Items, err = itItems.All(ctx)
+	masterData.PagesFetched += itItems.Pages()
+	if err != nil {
 		log.Println("Error fetching items:", err)
 		return masterData, err
 	}
 
 	// Fetch Payment Types
-	if err := fetchData(PaymentTypesAPIEndpoint, token, &masterData.PaymentTypes); err != nil {
+	itPaymentTypes := client.PaymentTypes(opts)
+	masterData.PaymentTypes, err = itPaymentTypes.All(ctx)
+	masterData.PagesFetched += itPaymentTypes.Pages()
+	if err != nil {
 		log.Println("Error fetching payment types:", err)
 		return masterData, err
 	}
 
-	// Fetch Stores
-	if err := fetchData(StoresAPIEndpoint, token, &masterData.Stores); err != nil {
-		log.Println("Error fetching stores:", err)
+	// Fetch Taxes
+	itTaxes := client.Taxes(opts)
+	masterData.Taxes, err = itTaxes.All(ctx)
+	masterData.PagesFetched += itTaxes.Pages()
+	if err != nil {
+		log.Println("Error fetching taxes:", err)
 		return masterData, err
 	}
 
-	// Fetch Suppliers
-	if err := fetchData(SuppliersAPIEndpoint, token, &masterData.Suppliers); err != nil {
-		log.Println("Error fetching suppliers:", err)
+	// Fetch Discounts
+	itDiscounts := client.Discounts(opts)
+	masterData.Discounts, err = itDiscounts.All(ctx)
+	masterData.PagesFetched += itDiscounts.Pages()
+	if err != nil {
+		log.Println("Error fetching discounts:", err)
 		return masterData, err
 	}
 
-	log.Println("Fetched master data from API successfully.")
-	return masterData, nil
-}
-
-// Helper function สำหรับ fetch data จาก API
-func fetchData(endpoint, token string, result interface{}) error {
-	body, err := utils.MakeGetRequest(endpoint, token)
+	// Fetch Modifiers
+	itModifiers := client.Modifiers(opts)
+	masterData.Modifiers, err = itModifiers.All(ctx)
+	masterData.PagesFetched += itModifiers.Pages()
 	if err != nil {
-		return err
+		log.Println("Error fetching modifiers:", err)
+		return masterData, err
 	}
 
-	// เลือกโครงสร้าง JSON ที่เหมาะสมตาม Endpoint
-	switch endpoint {
-	case CategoriesAPIEndpoint:
-		var categoriesResponse struct {
-			Categories []models.LoyCategory `json:"categories"`
-		}
-		if err := json.Unmarshal(body, &categoriesResponse); err != nil {
-			log.Println("Error parsing categories response:", err)
-			return err
-		}
-		*result.(*[]models.LoyCategory) = categoriesResponse.Categories
-
-	case ItemsAPIEndpoint:
-		var itemsResponse struct {
-			Items []models.LoyItem `json:"items"`
-		}
-		if err := json.Unmarshal(body, &itemsResponse); err != nil {
-			log.Println("Error parsing items response:", err)
-			return err
-		}
-		*result.(*[]models.LoyItem) = itemsResponse.Items
+	// Fetch Stores
+	itStores := client.Stores(opts)
+	masterData.Stores, err = itStores.All(ctx)
+	masterData.PagesFetched += itStores.Pages()
+	if err != nil {
+		log.Println("Error fetching stores:", err)
+		return masterData, err
+	}
 
-	case PaymentTypesAPIEndpoint:
-		var paymentTypesResponse struct {
-			PaymentTypes []models.LoyPaymentType `json:"payment_types"`
-		}
-		if err := json.Unmarshal(body, &paymentTypesResponse); err != nil {
-			log.Println("Error parsing payment types response:", err)
-			return err
-		}
-		*result.(*[]models.LoyPaymentType) = paymentTypesResponse.PaymentTypes
+	// Fetch Employees
+	itEmployees := client.Employees(opts)
+	masterData.Employees, err = itEmployees.All(ctx)
+	masterData.PagesFetched += itEmployees.Pages()
+	if err != nil {
+		log.Println("Error fetching employees:", err)
+		return masterData, err
+	}
 
-	case StoresAPIEndpoint:
-		var storesResponse struct {
-			Stores []models.LoyStore `json:"stores"`
-		}
-		if err := json.Unmarshal(body, &storesResponse); err != nil {
-			log.Println("Error parsing stores response:", err)
-			return err
-		}
-		*result.(*[]models.LoyStore) = storesResponse.Stores
+	// Fetch POS Devices
+	itPosDevices := client.PosDevices(opts)
+	masterData.PosDevices, err = itPosDevices.All(ctx)
+	masterData.PagesFetched += itPosDevices.Pages()
+	if err != nil {
+		log.Println("Error fetching POS devices:", err)
+		return masterData, err
+	}
 
-	case SuppliersAPIEndpoint:
-		var suppliersResponse struct {
-			Suppliers []models.LoySupplier `json:"suppliers"`
-		}
-		if err := json.Unmarshal(body, &suppliersResponse); err != nil {
-			log.Println("Error parsing suppliers response:", err)
-			return err
-		}
-		*result.(*[]models.LoySupplier) = suppliersResponse.Suppliers
+	// Fetch Suppliers
+	itSuppliers := client.Suppliers(opts)
+	masterData.Suppliers, err = itSuppliers.All(ctx)
+	masterData.PagesFetched += itSuppliers.Pages()
+	if err != nil {
+		log.Println("Error fetching suppliers:", err)
+		return masterData, err
+	}
 
-	default:
-		log.Println("Unknown endpoint:", endpoint)
-		return nil
+	// Fetch Customers
+	itCustomers := client.Customers(opts)
+	masterData.Customers, err = itCustomers.All(ctx)
+	masterData.PagesFetched += itCustomers.Pages()
+	if err != nil {
+		log.Println("Error fetching customers:", err)
+		return masterData, err
 	}
 
-	return nil
+	log.Println("Fetched master data from API successfully.")
+	return masterData, nil
 }
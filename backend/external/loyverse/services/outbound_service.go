@@ -0,0 +1,238 @@
+package services
+
+import (
+	"backend/external/loyverse/api"
+	"backend/external/loyverse/models"
+	"backend/external/loyverse/repository"
+	"backend/external/loyverse/utils"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OutboundEchoWindow คือช่วงเวลาหลังส่งการปรับสต็อกที่ webhook inventory_levels.update
+// ซึ่งมีค่าตรงกับที่เราส่งไปจะถือว่าเป็น echo ของเราเองและไม่ถูกบันทึกซ้ำ
+const OutboundEchoWindow = 15 * time.Minute
+
+// ErrInvalidOutboundChange คือคำขอที่ไม่ถูกต้อง ส่งกี่ครั้งก็ไม่สำเร็จ
+var ErrInvalidOutboundChange = errors.New("invalid outbound change")
+
+// ฟิลด์ที่อนุญาตให้แก้ไขจากฝั่งเรา ฟิลด์อื่น (เช่น id, variants) ต้องแก้ใน Loyverse back office
+var (
+	editableItemFields = map[string]bool{
+		"item_name": true, "description": true, "reference_id": true, "category_id": true,
+		"primary_supplier_id": true, "track_stock": true, "sold_by_weight": true, "color": true, "form": true,
+	}
+	editableSupplierFields = map[string]bool{
+		"name": true, "contact": true, "email": true, "phone_number": true, "website": true,
+		"address_1": true, "address_2": true, "city": true, "region": true, "postal_code": true,
+		"country_code": true, "note": true,
+	}
+)
+
+// IsPermanentOutboundError คืนค่า true ถ้า error นี้ไม่ควรลองใหม่
+// ได้แก่คำขอที่ไม่ถูกต้องและ 4xx จาก Loyverse (ยกเว้น 429)
+func IsPermanentOutboundError(err error) bool {
+	if errors.Is(err, ErrInvalidOutboundChange) {
+		return true
+	}
+	var apiErr *utils.APIError
+	if errors.As(err, &apiErr) {
+		return !apiErr.Retryable() && apiErr.StatusCode >= http.StatusBadRequest
+	}
+	return false
+}
+
+// EnqueueInventoryAdjustments ตรวจสอบคำขอปรับสต็อกของ merchant แล้วเพิ่มลงคิว outbound_changes
+func EnqueueInventoryAdjustments(db *sql.DB, merchantID string, adjustments []models.InventoryAdjustment, reason string) ([]models.OutboundChange, error) {
+	if len(adjustments) == 0 {
+		return nil, fmt.Errorf("%w: no adjustments", ErrInvalidOutboundChange)
+	}
+	for _, adjustment := range adjustments {
+		if adjustment.VariantID == "" || adjustment.StoreID == "" {
+			return nil, fmt.Errorf("%w: variant_id and store_id are required", ErrInvalidOutboundChange)
+		}
+		if (adjustment.StockAfter == nil) == (adjustment.Adjustment == nil) {
+			return nil, fmt.Errorf("%w: exactly one of stock_after or adjustment is required for variant %s", ErrInvalidOutboundChange, adjustment.VariantID)
+		}
+	}
+
+	changes := make([]models.OutboundChange, 0, len(adjustments))
+	for _, adjustment := range adjustments {
+		payload, err := json.Marshal(adjustment)
+		if err != nil {
+			return changes, err
+		}
+		entityID := repository.InventoryEntityID(adjustment.VariantID, adjustment.StoreID)
+		change, err := repository.EnqueueOutboundChange(db, merchantID, models.OutboundTypeInventory, entityID, payload, reason)
+		if err != nil {
+			return changes, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// EnqueueItemUpdate ตรวจสอบคำขอแก้ไขสินค้าแล้วเพิ่มลงคิว
+func EnqueueItemUpdate(db *sql.DB, merchantID string, update models.ResourceUpdate) (models.OutboundChange, error) {
+	return enqueueResourceUpdate(db, merchantID, models.OutboundTypeItem, update, editableItemFields)
+}
+
+// EnqueueSupplierUpdate ตรวจสอบคำขอแก้ไขซัพพลายเออร์แล้วเพิ่มลงคิว
+func EnqueueSupplierUpdate(db *sql.DB, merchantID string, update models.ResourceUpdate) (models.OutboundChange, error) {
+	return enqueueResourceUpdate(db, merchantID, models.OutboundTypeSupplier, update, editableSupplierFields)
+}
+
+func enqueueResourceUpdate(db *sql.DB, merchantID, changeType string, update models.ResourceUpdate, editable map[string]bool) (models.OutboundChange, error) {
+	if update.ID == "" {
+		return models.OutboundChange{}, fmt.Errorf("%w: id is required", ErrInvalidOutboundChange)
+	}
+	if len(update.Changes) == 0 {
+		return models.OutboundChange{}, fmt.Errorf("%w: no changes", ErrInvalidOutboundChange)
+	}
+	var rejected []string
+	for field := range update.Changes {
+		if !editable[field] {
+			rejected = append(rejected, field)
+		}
+	}
+	if len(rejected) > 0 {
+		sort.Strings(rejected)
+		return models.OutboundChange{}, fmt.Errorf("%w: %s fields cannot be changed: %s", ErrInvalidOutboundChange, changeType, strings.Join(rejected, ", "))
+	}
+
+	payload, err := json.Marshal(update.Changes)
+	if err != nil {
+		return models.OutboundChange{}, err
+	}
+	return repository.EnqueueOutboundChange(db, merchantID, changeType, update.ID, payload, update.Reason)
+}
+
+// PushOutboundChange ส่งการเปลี่ยนแปลงหนึ่งรายการไปยัง Loyverse และบันทึกผลลัพธ์ลงฐานข้อมูลของเรา
+// คืนค่า body ที่ส่งและ response เพื่อเก็บเป็น audit log
+// หลังส่งสำเร็จข้อมูลในฐานข้อมูลจะถูกอัปเดตทันที จึงไม่ต้องรอ webhook ที่ Loyverse ส่งกลับมา
+func PushOutboundChange(ctx context.Context, db *sql.DB, client *api.Client, change models.OutboundChange) (request, response []byte, err error) {
+	switch change.ChangeType {
+	case models.OutboundTypeInventory:
+		return pushInventoryAdjustment(ctx, db, client, change)
+	case models.OutboundTypeItem:
+		return pushResourceUpdate(ctx, change, client.GetItem, client.SaveItem, func(saved []byte) error {
+			var item models.LoyItem
+			if err := json.Unmarshal(saved, &item); err != nil {
+				return err
+			}
+			return repository.SaveItems(db, change.MerchantID, []models.LoyItem{item})
+		})
+	case models.OutboundTypeSupplier:
+		return pushResourceUpdate(ctx, change, client.GetSupplier, client.SaveSupplier, func(saved []byte) error {
+			var supplier struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			}
+			if err := json.Unmarshal(saved, &supplier); err != nil {
+				return err
+			}
+			return repository.SaveSuppliers(db, change.MerchantID, []models.LoySupplier{{SupplierID: supplier.ID, SupplierName: supplier.Name}})
+		})
+	default:
+		return nil, nil, fmt.Errorf("%w: unknown change type %q", ErrInvalidOutboundChange, change.ChangeType)
+	}
+}
+
+func pushInventoryAdjustment(ctx context.Context, db *sql.DB, client *api.Client, change models.OutboundChange) ([]byte, []byte, error) {
+	var adjustment models.InventoryAdjustment
+	if err := json.Unmarshal(change.Payload, &adjustment); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidOutboundChange, err)
+	}
+
+	if adjustment.StockAfter == nil {
+		// Loyverse รับเฉพาะ stock_after จึงต้องอ่านสต็อกปัจจุบันก่อนแล้วบวกค่าที่ปรับ
+		// ค่าที่ได้ถูกบันทึกลง payload ก่อนส่งครั้งแรก การ retry จึงส่งค่าเดิมซ้ำแทนการบวกซ้ำกับสต็อกที่อาจถูกปรับไปแล้ว
+		current, err := currentStock(ctx, client, adjustment.VariantID, adjustment.StoreID)
+		if err != nil {
+			return nil, nil, err
+		}
+		stockAfter := current + *adjustment.Adjustment
+		adjustment.StockAfter = &stockAfter
+		payload, err := json.Marshal(adjustment)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := repository.FreezeOutboundPayload(db, change, payload); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	update := models.LoyInventoryUpdate{VariantID: adjustment.VariantID, StoreID: adjustment.StoreID, StockAfter: *adjustment.StockAfter}
+
+	request, _ := json.Marshal(map[string]interface{}{"inventory_levels": []models.LoyInventoryUpdate{update}})
+	levels, err := client.UpdateInventory(ctx, []models.LoyInventoryUpdate{update})
+	if err != nil {
+		return request, nil, err
+	}
+	response, _ := json.Marshal(map[string]interface{}{"inventory_levels": levels})
+
+	if err := repository.SaveInventoryLevels(db, change.MerchantID, models.InventoryHistorySourcePush, levels); err != nil {
+		return request, response, err
+	}
+	log.Printf("Pushed stock %s at store %s: %.2f", update.VariantID, update.StoreID, update.StockAfter)
+	return request, response, nil
+}
+
+// currentStock อ่านจำนวนสต็อกปัจจุบันของ variant ใน store จาก Loyverse (0 ถ้ายังไม่มี record)
+func currentStock(ctx context.Context, client *api.Client, variantID, storeID string) (float64, error) {
+	levels, err := client.Inventory(api.ListOptions{StoreID: storeID, VariantIDs: []string{variantID}}).All(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, level := range levels {
+		if level.VariantID == variantID && level.StoreID == storeID {
+			return level.InStock, nil
+		}
+	}
+	return 0, nil
+}
+
+// pushResourceUpdate อ่าน resource ปัจจุบันจาก Loyverse, แทนค่าฟิลด์ที่แก้ไข แล้วส่งกลับทั้งตัว
+// เพราะ POST ของ Loyverse แทนที่ resource ทั้งตัว การอ่านก่อนส่งทำให้ฟิลด์อื่นไม่หายไป
+func pushResourceUpdate(
+	ctx context.Context,
+	change models.OutboundChange,
+	get func(context.Context, string) (api.Object, error),
+	save func(context.Context, api.Object) (api.Object, error),
+	store func(saved []byte) error,
+) ([]byte, []byte, error) {
+	var changes map[string]interface{}
+	if err := json.Unmarshal(change.Payload, &changes); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidOutboundChange, err)
+	}
+
+	current, err := get(ctx, change.EntityID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for field, value := range changes {
+		current[field] = value
+	}
+	current["id"] = change.EntityID
+
+	request, _ := json.Marshal(current)
+	saved, err := save(ctx, current)
+	if err != nil {
+		return request, nil, err
+	}
+	response, _ := json.Marshal(saved)
+
+	if err := store(response); err != nil {
+		return request, response, err
+	}
+	log.Printf("Pushed %s %s to Loyverse", change.ChangeType, change.EntityID)
+	return request, response, nil
+}
@@ -1,40 +1,29 @@
 package services
 
 import (
+	"backend/external/loyverse/api"
 	"backend/external/loyverse/models"
 	"backend/external/loyverse/repository"
-	"backend/external/loyverse/utils"
+	"context"
 	"database/sql"
-	"encoding/json"
-	"fmt"
 	"log"
-	"os"
+	"time"
 )
 
-const ReceiptsAPIEndpoint = "https://api.loyverse.com/v1.0/receipts"
+func SyncReceiptsContinuously(ctx context.Context, db *sql.DB, conn models.Connection) error {
+	client := NewLoyverseClient(conn)
+	it := client.Receipts(api.ListOptions{})
 
-func SyncReceiptsContinuously(db *sql.DB) error {
-	limit := 250
-	cursor := ""
-
-	for {
-		// ดึงข้อมูลใบเสร็จทีละ batch
-		receipts, nextCursor, err := FetchReceiptsBatch(cursor, limit)
-		if err != nil {
-			return err
-		}
-
-		// บันทึกข้อมูลใบเสร็จใน batch นี้
-		if err := repository.SaveReceipts(db, receipts); err != nil {
+	// ดึงข้อมูลใบเสร็จทีละ batch และบันทึกทันที
+	for it.Next(ctx) {
+		receipts := it.Page()
+		if err := repository.SaveReceipts(db, conn.MerchantID, receipts); err != nil {
 			return err
 		}
 		log.Printf("Saved %d receipts to database", len(receipts))
-
-		// ตรวจสอบว่ามีข้อมูลเพิ่มเติมหรือไม่
-		if nextCursor == "" {
-			break // ถ้าไม่มี cursor ถัดไป หมายความว่าข้อมูลหมดแล้ว
-		}
-		cursor = nextCursor // อัปเดต cursor สำหรับ batch ถัดไป
+	}
+	if err := it.Err(); err != nil {
+		return err
 	}
 
 	log.Println("All receipts synced successfully.")
@@ -42,79 +31,62 @@ func SyncReceiptsContinuously(db *sql.DB) error {
 }
 
 // FetchReceiptsBatch ดึงข้อมูลใบเสร็จทีละ batch โดยใช้ cursor
-func FetchReceiptsBatch(cursor string, limit int) ([]models.LoyReceipt, string, error) {
-	token := os.Getenv("LOYVERSE_API_TOKEN")
-	endpoint := fmt.Sprintf("%s?limit=%d", ReceiptsAPIEndpoint, limit)
-	if cursor != "" {
-		endpoint += "&cursor=" + cursor
-	}
-
-	body, err := utils.MakeGetRequest(endpoint, token)
+// ถ้า updatedSince ไม่ใช่ค่า zero จะดึงเฉพาะใบเสร็จที่ถูกแก้ไขตั้งแต่เวลานั้น (updated_at_min)
+func FetchReceiptsBatch(ctx context.Context, conn models.Connection, cursor string, limit int, updatedSince time.Time) ([]models.LoyReceipt, string, error) {
+	client := NewLoyverseClient(conn)
+
+	page, err := client.ListReceipts(ctx, api.ListOptions{
+		Limit:        limit,
+		Cursor:       cursor,
+		UpdatedAtMin: updatedSince,
+	})
 	if err != nil {
 		log.Println("Error fetching receipts:", err)
 		return nil, "", err
 	}
 
-	var receiptsResponse struct {
-		Receipts []models.LoyReceipt `json:"receipts"`
-		Cursor   string              `json:"cursor"`
-	}
-	if err := json.Unmarshal(body, &receiptsResponse); err != nil {
-		log.Println("Error parsing receipts response:", err)
+	return page.Receipts, page.Cursor, nil
+}
+
+// FetchReceiptsWindow ดึงใบเสร็จหนึ่งหน้าที่สร้างในช่วง [from, to] (created_at_min/max) ของสาขาที่ระบุ (ว่าง = ทุกสาขา)
+func FetchReceiptsWindow(ctx context.Context, conn models.Connection, cursor string, limit int, from, to time.Time, storeID string) ([]models.LoyReceipt, string, error) {
+	client := NewLoyverseClient(conn)
+
+	page, err := client.ListReceipts(ctx, api.ListOptions{
+		Limit:        limit,
+		Cursor:       cursor,
+		CreatedAtMin: from,
+		CreatedAtMax: to,
+		StoreID:      storeID,
+	})
+	if err != nil {
+		log.Println("Error fetching receipts:", err)
 		return nil, "", err
 	}
 
-	return receiptsResponse.Receipts, receiptsResponse.Cursor, nil
+	return page.Receipts, page.Cursor, nil
 }
 
-func FetchReceipts() ([]models.LoyReceipt, error) {
-	token := os.Getenv("LOYVERSE_API_TOKEN")
-	var allReceipts []models.LoyReceipt
-	limit := 250
-	cursor := ""
-
-	for {
-		endpoint := ReceiptsAPIEndpoint + "?limit=" + fmt.Sprint(limit)
-		if cursor != "" {
-			endpoint += "&cursor=" + cursor
-		}
+func FetchReceipts(ctx context.Context, conn models.Connection) ([]models.LoyReceipt, error) {
+	client := NewLoyverseClient(conn)
 
-		body, err := utils.MakeGetRequest(endpoint, token)
-		if err != nil {
-			log.Println("Error fetching receipts:", err)
-			return nil, err
-		}
-
-		// ตรวจสอบการตอบกลับ HTTP
-		var receiptsResponse struct {
-			Receipts []models.LoyReceipt `json:"receipts"`
-			Cursor   string              `json:"cursor"`
-		}
-		if err := json.Unmarshal(body, &receiptsResponse); err != nil {
-			log.Println("Error parsing receipts response:", err)
-			log.Printf("Response body: %s", body) // แสดงเนื้อหาที่ตอบกลับ
-			return nil, err
-		}
-
-		allReceipts = append(allReceipts, receiptsResponse.Receipts...)
-
-		if receiptsResponse.Cursor == "" {
-			break
-		}
-		cursor = receiptsResponse.Cursor
+	allReceipts, err := client.Receipts(api.ListOptions{}).All(ctx)
+	if err != nil {
+		log.Println("Error fetching receipts:", err)
+		return nil, err
 	}
 
 	log.Printf("Fetched %d receipts from API", len(allReceipts))
 	return allReceipts, nil
 }
 
-// CancelReceipt ทำการอัปเดตสถานะใบเสร็จให้เป็นยกเลิก
-func CancelReceipt(db *sql.DB, receiptNumber string) error {
+// CancelReceipt ทำการอัปเดตสถานะใบเสร็จของ merchant ให้เป็นยกเลิก
+func CancelReceipt(db *sql.DB, merchantID, receiptNumber string) error {
 	_, err := db.Exec(`
         UPDATE loyreceipts
         SET cancelled_at = NOW()  -- อัปเดตให้เป็นเวลาปัจจุบัน
-        WHERE receipt_number = $1`,
-		receiptNumber,
+        WHERE merchant_id = $1 AND receipt_number = $2`,
+		merchantID, receiptNumber,
 	)
 	if err != nil {
 		log.Println("Error canceling receipt:", err)
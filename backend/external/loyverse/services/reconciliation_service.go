@@ -0,0 +1,251 @@
+package services
+
+import (
+	"backend/external/loyverse/api"
+	"backend/external/loyverse/models"
+	"backend/external/loyverse/repository"
+	"backend/external/loyverse/utils"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// reconciliationTolerance คือส่วนต่างของจำนวนสต็อกที่ยังถือว่าตรงกัน (เศษจากการปัดทศนิยม)
+// ยอดเงินเป็น money.Money จึงเทียบกันแบบตรงทุกสตางค์
+const reconciliationTolerance = 0.005
+
+// FindReceiptMismatches เทียบจำนวนและยอดรวมใบเสร็จรายวัน/สาขาที่สร้างในช่วง [from, to] ระหว่างฐานข้อมูลกับ Loyverse
+// วันนับตาม loc และคืนค่าจำนวนคู่ วัน/สาขา ที่ตรวจด้วย
+func FindReceiptMismatches(ctx context.Context, db *sql.DB, conn models.Connection, from, to time.Time, loc *time.Location) ([]models.ReconciliationFinding, int, error) {
+	local, err := repository.DailyReceiptTotals(db, conn.MerchantID, from, to, loc.String())
+	if err != nil {
+		return nil, 0, err
+	}
+
+	client := NewLoyverseClient(conn)
+	receipts, err := client.Receipts(api.ListOptions{CreatedAtMin: from, CreatedAtMax: to}).All(ctx)
+	if err != nil {
+		log.Println("Error fetching receipts for reconciliation:", err)
+		return nil, 0, err
+	}
+
+	totals := make(map[[2]string]*[2]models.DailyReceiptTotal)
+	totalOf := func(date, storeID string) *[2]models.DailyReceiptTotal {
+		key := [2]string{date, storeID}
+		if totals[key] == nil {
+			totals[key] = &[2]models.DailyReceiptTotal{
+				{BusinessDate: date, StoreID: storeID},
+				{BusinessDate: date, StoreID: storeID},
+			}
+		}
+		return totals[key]
+	}
+	for _, total := range local {
+		totalOf(total.BusinessDate, total.StoreID)[0] = total
+	}
+	for _, receipt := range receipts {
+		remote := &totalOf(receipt.CreatedAt.In(loc).Format("2006-01-02"), receipt.StoreID)[1]
+		remote.Count++
+		if receipt.ReceiptType == models.ReceiptTypeRefund {
+			remote.Total = remote.Total.Sub(receipt.TotalMoney)
+		} else {
+			remote.Total = remote.Total.Add(receipt.TotalMoney)
+		}
+	}
+
+	var findings []models.ReconciliationFinding
+	for key, pair := range totals {
+		local, remote := pair[0], pair[1]
+		if local.Count == remote.Count && local.Total.Equal(remote.Total) {
+			continue
+		}
+		findings = append(findings, models.ReconciliationFinding{
+			Kind:         models.FindingKindReceiptsDay,
+			Key:          fmt.Sprintf("%s:%s:%s", models.FindingKindReceiptsDay, key[0], key[1]),
+			BusinessDate: key[0],
+			StoreID:      key[1],
+			LocalCount:   local.Count,
+			RemoteCount:  remote.Count,
+			LocalTotal:   local.Total,
+			RemoteTotal:  remote.Total,
+			Detail:       fmt.Sprintf("%d receipts (%s) locally, %d (%s) in Loyverse", local.Count, local.Total, remote.Count, remote.Total),
+		})
+	}
+	sortFindings(findings)
+	return findings, len(totals), nil
+}
+
+// FindItemMismatches เทียบรายการสินค้าที่ยังไม่ถูกลบระหว่างฐานข้อมูลกับ Loyverse
+// คืนค่าจำนวนสินค้าที่ยังไม่ถูกลบของทั้งสองฝั่งด้วย
+func FindItemMismatches(ctx context.Context, db *sql.DB, conn models.Connection) ([]models.ReconciliationFinding, int, int, error) {
+	local, err := repository.ItemDeletionStates(db, conn.MerchantID)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	client := NewLoyverseClient(conn)
+	items, err := client.Items(api.ListOptions{ShowDeleted: true}).All(ctx)
+	if err != nil {
+		log.Println("Error fetching items for reconciliation:", err)
+		return nil, 0, 0, err
+	}
+
+	var findings []models.ReconciliationFinding
+	item := func(itemID, detail string, localActive, remoteActive bool) {
+		finding := models.ReconciliationFinding{
+			Kind:   models.FindingKindItem,
+			Key:    models.FindingKindItem + ":" + itemID,
+			ItemID: itemID,
+			Detail: detail,
+		}
+		if localActive {
+			finding.LocalCount = 1
+		}
+		if remoteActive {
+			finding.RemoteCount = 1
+		}
+		findings = append(findings, finding)
+	}
+
+	localActive := 0
+	for _, deleted := range local {
+		if !deleted {
+			localActive++
+		}
+	}
+	remoteActive := 0
+	seen := make(map[string]bool, len(items))
+	for _, remote := range items {
+		seen[remote.ID] = true
+		remoteDeleted := remote.DeletedAt != nil
+		if !remoteDeleted {
+			remoteActive++
+		}
+		localDeleted, exists := local[remote.ID]
+		switch {
+		case !exists && !remoteDeleted:
+			item(remote.ID, "missing locally", false, true)
+		case exists && localDeleted && !remoteDeleted:
+			item(remote.ID, "deleted locally but active in Loyverse", false, true)
+		case exists && !localDeleted && remoteDeleted:
+			item(remote.ID, "deleted in Loyverse but active locally", true, false)
+		}
+	}
+	for itemID, deleted := range local {
+		if !seen[itemID] && !deleted {
+			item(itemID, "not found in Loyverse", true, false)
+		}
+	}
+	sortFindings(findings)
+	return findings, localActive, remoteActive, nil
+}
+
+// FindInventoryMismatches เทียบสต็อกทุก variant/สาขาระหว่างฐานข้อมูลกับ Loyverse
+// คืนค่าจำนวนคู่ variant/สาขา ที่ตรวจด้วย
+func FindInventoryMismatches(ctx context.Context, db *sql.DB, conn models.Connection) ([]models.ReconciliationFinding, int, error) {
+	local, err := repository.InventoryLevelsOf(db, conn.MerchantID)
+	if err != nil {
+		return nil, 0, err
+	}
+	remote, _, err := FetchInventoryLevels(ctx, conn)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	type pair struct {
+		variantID, storeID string
+		local, remote      *float64
+	}
+	levels := make(map[string]*pair)
+	levelOf := func(variantID, storeID string) *pair {
+		key := variantID + ":" + storeID
+		if levels[key] == nil {
+			levels[key] = &pair{variantID: variantID, storeID: storeID}
+		}
+		return levels[key]
+	}
+	for i := range local {
+		levelOf(local[i].VariantID, local[i].StoreID).local = &local[i].InStock
+	}
+	for i := range remote {
+		levelOf(remote[i].VariantID, remote[i].StoreID).remote = &remote[i].InStock
+	}
+
+	var findings []models.ReconciliationFinding
+	for key, level := range levels {
+		finding := models.ReconciliationFinding{
+			Kind:      models.FindingKindInventoryLevel,
+			Key:       models.FindingKindInventoryLevel + ":" + key,
+			VariantID: level.variantID,
+			StoreID:   level.storeID,
+		}
+		switch {
+		case level.local == nil:
+			finding.RemoteCount, finding.RemoteValue = 1, *level.remote
+			finding.Detail = "missing locally"
+		case level.remote == nil:
+			finding.LocalCount, finding.LocalValue = 1, *level.local
+			finding.Detail = "not found in Loyverse"
+		case math.Abs(*level.local-*level.remote) >= reconciliationTolerance:
+			finding.LocalCount, finding.LocalValue = 1, *level.local
+			finding.RemoteCount, finding.RemoteValue = 1, *level.remote
+			finding.Detail = fmt.Sprintf("in stock %.2f locally, %.2f in Loyverse", *level.local, *level.remote)
+		default:
+			continue
+		}
+		findings = append(findings, finding)
+	}
+	sortFindings(findings)
+	return findings, len(levels), nil
+}
+
+func sortFindings(findings []models.ReconciliationFinding) {
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Key < findings[j].Key })
+}
+
+// ResyncItem โหลดสินค้าหนึ่งรายการจาก Loyverse มาบันทึกทับ (พร้อม variants)
+// ถ้า Loyverse ไม่พบสินค้านั้นแล้วจะทำเครื่องหมายว่าถูกลบ
+func ResyncItem(ctx context.Context, db *sql.DB, conn models.Connection, itemID string) error {
+	object, err := NewLoyverseClient(conn).GetItem(ctx, itemID)
+	var apiErr *utils.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return repository.SoftDeleteItem(db, conn.MerchantID, itemID)
+	}
+	if err != nil {
+		log.Println("Error fetching item:", err)
+		return err
+	}
+
+	// api.Object เป็น JSON object ดิบ แปลงเป็น models.LoyItem ผ่าน JSON เพื่อใช้ SaveItems เดิม
+	raw, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+	var item models.LoyItem
+	if err := json.Unmarshal(raw, &item); err != nil {
+		log.Println("Error decoding item:", err)
+		return err
+	}
+	return repository.SaveItems(db, conn.MerchantID, []models.LoyItem{item})
+}
+
+// ResyncInventoryLevel โหลดสต็อกของ variant/สาขาหนึ่งคู่จาก Loyverse มาบันทึกทับ
+// ถ้า Loyverse ไม่มีสต็อกของคู่นั้นจะลบออกจากฐานข้อมูล
+func ResyncInventoryLevel(ctx context.Context, db *sql.DB, conn models.Connection, variantID, storeID string) error {
+	levels, err := NewLoyverseClient(conn).Inventory(api.ListOptions{StoreID: storeID, VariantIDs: []string{variantID}}).All(ctx)
+	if err != nil {
+		log.Println("Error fetching inventory level:", err)
+		return err
+	}
+	if len(levels) == 0 {
+		return repository.DeleteInventoryLevel(db, conn.MerchantID, variantID, storeID)
+	}
+	return repository.SaveInventoryLevels(db, conn.MerchantID, models.InventoryHistorySourceSync, levels)
+}
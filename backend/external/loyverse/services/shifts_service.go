@@ -0,0 +1,24 @@
+package services
+
+import (
+	"backend/external/loyverse/api"
+	"backend/external/loyverse/models"
+	"context"
+	"log"
+	"time"
+)
+
+// FetchShifts ดึงกะการขายของ connection ที่เปิดตั้งแต่ openedSince (ค่า zero = ทั้งหมด)
+// และคืนจำนวนหน้าที่ดึงมาด้วย
+func FetchShifts(ctx context.Context, conn models.Connection, openedSince time.Time) ([]models.LoyShift, int, error) {
+	client := NewLoyverseClient(conn)
+
+	it := client.Shifts(api.ListOptions{CreatedAtMin: openedSince})
+	shifts, err := it.All(ctx)
+	if err != nil {
+		log.Println("Error fetching shifts:", err)
+		return nil, it.Pages(), err
+	}
+
+	return shifts, it.Pages(), nil
+}
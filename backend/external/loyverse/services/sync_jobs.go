@@ -0,0 +1,202 @@
+package services
+
+import (
+	"backend/external/loyverse/models"
+	"backend/external/loyverse/utils"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SyncJobRetention คือเวลาที่เก็บ job ที่จบแล้วไว้ในหน่วยความจำเพื่อให้ยังเปิดดู event ย้อนหลังได้
+const SyncJobRetention = time.Hour
+
+// SyncJobMaxEvents คือจำนวน event สูงสุดที่เก็บต่อ job (เกินแล้วจะทิ้ง event เก่าที่สุด)
+const SyncJobMaxEvents = 5000
+
+// SyncJobManager รัน sync เป็น job เบื้องหลังและเก็บ event ความคืบหน้าของแต่ละ job ไว้ในหน่วยความจำ
+// merchant และ entity เดียวกันมี job ที่ทำงานอยู่ได้ครั้งละหนึ่ง job การสั่งซ้ำระหว่างนั้นจะได้ job เดิมกลับไป
+// การรวม job ทำได้เฉพาะใน process นี้ sync ที่มาจาก cron, route เดิมหรือ process อื่นถูกกันด้วย lock ใน sync_locks
+// (job ที่ชนกับ lock นั้นจะจบด้วย repository.ErrSyncLocked)
+type SyncJobManager struct {
+	mu     sync.Mutex
+	jobs   map[string]*syncJob
+	active map[string]*syncJob // job ที่ยังทำงานอยู่ แยกตาม merchant และ entity
+}
+
+type syncJob struct {
+	job     models.SyncJob
+	events  []models.SyncJobEvent
+	nextSeq int64
+	changed chan struct{} // ถูกปิดและสร้างใหม่ทุกครั้งที่มี event ใหม่
+}
+
+// NewSyncJobManager สร้าง SyncJobManager ที่ยังไม่มี job
+func NewSyncJobManager() *SyncJobManager {
+	return &SyncJobManager{
+		jobs:   make(map[string]*syncJob),
+		active: make(map[string]*syncJob),
+	}
+}
+
+// Start เริ่ม run เป็น job เบื้องหลังและคืนค่า job ทันที
+// ถ้า merchant นี้มี job ของ entity เดียวกันทำงานอยู่แล้ว จะไม่เริ่มใหม่แต่คืน job เดิมพร้อม Merged = true
+// run ทำงานด้วย context ของ job เอง (ไม่ถูกยกเลิกเมื่อ HTTP request ที่สั่งจบลง)
+func (m *SyncJobManager) Start(merchantID, entity, trigger string, run func(ctx context.Context) error) (models.SyncJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := merchantID + "/" + entity
+	if existing, ok := m.active[key]; ok {
+		job := existing.job
+		job.Merged = true
+		return job, nil
+	}
+
+	id, err := newSyncJobID()
+	if err != nil {
+		return models.SyncJob{}, err
+	}
+	m.prune()
+
+	job := &syncJob{
+		job: models.SyncJob{
+			ID:         id,
+			MerchantID: merchantID,
+			Entity:     entity,
+			Trigger:    trigger,
+			Status:     models.SyncStatusRunning,
+			StartedAt:  time.Now(),
+		},
+		changed: make(chan struct{}),
+	}
+	m.jobs[id] = job
+	m.active[key] = job
+	m.publish(job, models.SyncJobEvent{Type: models.SyncJobEventStarted})
+
+	ctx := utils.WithProgress(context.Background(), func(event models.SyncJobEvent) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.publish(job, event)
+	})
+	go func() {
+		runErr := run(ctx)
+		if runErr != nil {
+			log.Printf("Sync job %s (%s) failed: %v", id, key, runErr)
+		}
+		m.finish(key, job, runErr)
+	}()
+
+	return job.job, nil
+}
+
+// Get คืนค่าสถานะล่าสุดของ job
+func (m *SyncJobManager) Get(id string) (models.SyncJob, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return models.SyncJob{}, false
+	}
+	return job.job, true
+}
+
+// List คืนค่า job ทั้งหมดที่ยังเก็บไว้ของ merchant เรียงจากเริ่มล่าสุด
+func (m *SyncJobManager) List(merchantID string) []models.SyncJob {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	jobs := []models.SyncJob{}
+	for _, job := range m.jobs {
+		if job.job.MerchantID == merchantID {
+			jobs = append(jobs, job.job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].StartedAt.After(jobs[j].StartedAt) })
+	return jobs
+}
+
+// Events คืนค่า event ของ job ที่มี seq มากกว่า afterSeq
+// changed ถูกปิดเมื่อมี event ใหม่ และ finished เป็น true เมื่อ job จบแล้ว (ไม่มี event ตามมาอีก)
+func (m *SyncJobManager) Events(id string, afterSeq int64) (events []models.SyncJobEvent, changed <-chan struct{}, finished bool, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, nil, false, false
+	}
+	for _, event := range job.events {
+		if event.Seq > afterSeq {
+			events = append(events, event)
+		}
+	}
+	return events, job.changed, job.job.Status != models.SyncStatusRunning, true
+}
+
+// finish บันทึกผลของ job ส่ง event completed และปลด job ออกจาก active
+func (m *SyncJobManager) finish(key string, job *syncJob, runErr error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	job.job.FinishedAt = &now
+	job.job.Status = models.SyncStatusSucceeded
+	event := models.SyncJobEvent{Type: models.SyncJobEventCompleted, Status: models.SyncStatusSucceeded}
+	if runErr != nil {
+		message := runErr.Error()
+		job.job.Status = models.SyncStatusFailed
+		job.job.Error = &message
+		event.Status = models.SyncStatusFailed
+		event.Message = message
+	}
+	delete(m.active, key)
+	m.publish(job, event)
+}
+
+// publish ต่อ event เข้ากับ job และปลุกผู้ที่รออยู่ ต้องถือ m.mu ก่อนเรียก
+func (m *SyncJobManager) publish(job *syncJob, event models.SyncJobEvent) {
+	job.nextSeq++
+	event.Seq = job.nextSeq
+	event.Entity = job.job.Entity
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	switch event.Type {
+	case models.SyncJobEventPage:
+		job.job.PagesFetched++
+	case models.SyncJobEventRows:
+		job.job.RowsSaved += event.Rows
+	}
+
+	job.events = append(job.events, event)
+	if len(job.events) > SyncJobMaxEvents {
+		job.events = job.events[len(job.events)-SyncJobMaxEvents:]
+	}
+	close(job.changed)
+	job.changed = make(chan struct{})
+}
+
+// prune ลบ job ที่จบไปนานกว่า SyncJobRetention ต้องถือ m.mu ก่อนเรียก
+func (m *SyncJobManager) prune() {
+	cutoff := time.Now().Add(-SyncJobRetention)
+	for id, job := range m.jobs {
+		if job.job.FinishedAt != nil && job.job.FinishedAt.Before(cutoff) {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+func newSyncJobID() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		log.Println("Error generating sync job id:", err)
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"backend/external/loyverse/models"
+	"backend/external/loyverse/repository"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// renameColumns คือคอลัมน์ชื่อที่สรุปเป็น "renamed" ใน SummarizeDiff
+var renameColumns = map[string]bool{
+	"name": true, "item_name": true, "store_name": true, "supplier_name": true,
+}
+
+// DiffInventoryLevels เทียบสต็อกในฐานข้อมูลกับสต็อกที่ดึงมา ตามสิ่งที่ SyncInventoryLevels จะทำ
+// (ล้างสต็อกเดิมของ merchant แล้วบันทึกชุดใหม่ทั้งหมด คู่ variant/สาขาที่ไม่มีแล้วจึงถูกลบ)
+func DiffInventoryLevels(local, remote []models.LoyInventoryLevel) models.TableDiff {
+	diff := models.TableDiff{
+		Table:         "loyinventorylevels",
+		ColumnChanges: map[string]int{},
+		Inserts:       []string{},
+		Deletes:       []string{},
+		Updates:       []models.RowChange{},
+	}
+
+	current := make(map[string]float64, len(local))
+	for _, level := range local {
+		current[level.VariantID+":"+level.StoreID] = level.InStock
+	}
+	seen := make(map[string]bool, len(remote))
+	for _, level := range remote {
+		key := level.VariantID + ":" + level.StoreID
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		before, exists := current[key]
+		switch {
+		case !exists:
+			diff.Inserted++
+			if len(diff.Inserts) < repository.DiffDetailLimit {
+				diff.Inserts = append(diff.Inserts, key)
+			}
+		case before != level.InStock:
+			diff.Updated++
+			diff.ColumnChanges["in_stock"]++
+			if len(diff.Updates) < repository.DiffDetailLimit {
+				from := strconv.FormatFloat(before, 'f', -1, 64)
+				to := strconv.FormatFloat(level.InStock, 'f', -1, 64)
+				diff.Updates = append(diff.Updates, models.RowChange{
+					Key:     key,
+					Changes: map[string]models.ValueChange{"in_stock": {From: &from, To: &to}},
+				})
+			}
+		}
+	}
+
+	var removed []string
+	for key := range current {
+		if !seen[key] {
+			removed = append(removed, key)
+		}
+	}
+	sort.Strings(removed)
+	diff.Deleted = len(removed)
+	if len(removed) > repository.DiffDetailLimit {
+		removed = removed[:repository.DiffDetailLimit]
+	}
+	diff.Deletes = append(diff.Deletes, removed...)
+	sort.Strings(diff.Inserts)
+	sort.Slice(diff.Updates, func(i, j int) bool { return diff.Updates[i].Key < diff.Updates[j].Key })
+	return diff
+}
+
+// SummarizeDiff สรุป diff เป็นข้อความสั้นๆ เช่น "12 items renamed", "3 suppliers removed", "40 variants cost changes"
+func SummarizeDiff(diffs []models.TableDiff) []string {
+	summary := []string{}
+	for _, diff := range diffs {
+		label := strings.TrimPrefix(diff.Table, "loy")
+		if diff.Inserted > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s added", diff.Inserted, label))
+		}
+		if diff.Deleted > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s removed", diff.Deleted, label))
+		}
+
+		columns := make([]string, 0, len(diff.ColumnChanges))
+		for column := range diff.ColumnChanges {
+			columns = append(columns, column)
+		}
+		sort.Strings(columns)
+		for _, column := range columns {
+			count := diff.ColumnChanges[column]
+			switch {
+			case renameColumns[column]:
+				summary = append(summary, fmt.Sprintf("%d %s renamed", count, label))
+			case column == "deleted_at":
+				summary = append(summary, fmt.Sprintf("%d %s restored", count, label))
+			default:
+				summary = append(summary, fmt.Sprintf("%d %s %s changes", count, label, column))
+			}
+		}
+	}
+	return summary
+}
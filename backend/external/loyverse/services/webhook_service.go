@@ -0,0 +1,107 @@
+package services
+
+import (
+	"backend/external/loyverse/models"
+	"backend/external/loyverse/repository"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// error ที่เป็นความล้มเหลวถาวร ลองใหม่กี่ครั้งก็ไม่สำเร็จ
+var (
+	ErrInvalidWebhookPayload = errors.New("invalid webhook payload")
+	ErrUnhandledWebhookEvent = errors.New("unhandled webhook event type")
+)
+
+// IsPermanentWebhookError คืนค่า true ถ้า error นี้ไม่ควรลองใหม่
+func IsPermanentWebhookError(err error) bool {
+	return errors.Is(err, ErrInvalidWebhookPayload) || errors.Is(err, ErrUnhandledWebhookEvent)
+}
+
+// WebhookPayload คือโครงสร้าง body ของ webhook จาก Loyverse
+type WebhookPayload struct {
+	Event         string                     `json:"type"`
+	Receipts      []models.LoyReceipt        `json:"receipts"`
+	InventoryData []models.LoyInventoryLevel `json:"inventory_levels"`
+	Items         []models.LoyItem           `json:"items"`
+	Customers     []models.LoyCustomer       `json:"customers"`
+}
+
+// WebhookEntity แปลง event type (เช่น "receipts.update") เป็นชื่อ entity ที่ใช้ใน sync_runs (เช่น "receipts_webhook")
+// ใช้ชื่อแยกจาก sync เต็มรูปแบบ เพื่อไม่ให้ webhook ที่สำเร็จถูกนับเป็น sync สำเร็จครั้งล่าสุดใน /api/sync/status
+func WebhookEntity(eventType string) string {
+	return strings.TrimSuffix(eventType, ".update") + models.SyncEntityWebhookSuffix
+}
+
+// ProcessWebhookPayload decode payload และบันทึกข้อมูลของ merchant ลงฐานข้อมูลตามประเภทของเหตุการณ์
+// คืนค่าจำนวนแถวที่บันทึก
+func ProcessWebhookPayload(db *sql.DB, merchantID string, payload []byte) (int, error) {
+	var webhookPayload WebhookPayload
+	if err := json.Unmarshal(payload, &webhookPayload); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidWebhookPayload, err)
+	}
+
+	// ตรวจสอบประเภทของเหตุการณ์และจัดการตามประเภทนั้น ๆ
+	switch webhookPayload.Event {
+	case "receipts.update":
+		if err := repository.SaveReceipts(db, merchantID, webhookPayload.Receipts); err != nil {
+			log.Println("Error saving receipts:", err)
+			return 0, err
+		}
+		log.Println("Webhook Receipts updated successfully.")
+
+	case "inventory_levels.update":
+		// ตัด level ที่เป็นผลจากการปรับสต็อกที่เราส่งไปเอง (บันทึกไว้แล้วตอนส่ง) เพื่อกัน echo loop
+		levels, err := dropInventoryEchoes(db, merchantID, webhookPayload.InventoryData)
+		if err != nil {
+			return 0, err
+		}
+		webhookPayload.InventoryData = levels
+		if err := repository.SaveInventoryLevels(db, merchantID, models.InventoryHistorySourceWebhook, webhookPayload.InventoryData); err != nil {
+			log.Println("Error saving inventory levels:", err)
+			return 0, err
+		}
+		log.Println("Webhook Inventory levels updated successfully.")
+
+	case "items.update":
+		if err := repository.SaveItems(db, merchantID, webhookPayload.Items); err != nil {
+			log.Println("Error saving items:", err)
+			return 0, err
+		}
+		log.Println("Items updated successfully.")
+
+	case "customers.update":
+		if err := repository.SaveCustomers(db, merchantID, webhookPayload.Customers); err != nil {
+			log.Println("Error saving customers:", err)
+			return 0, err
+		}
+		log.Println("Customers updated successfully.")
+
+	default:
+		return 0, fmt.Errorf("%w: %s", ErrUnhandledWebhookEvent, webhookPayload.Event)
+	}
+
+	rows := len(webhookPayload.Receipts) + len(webhookPayload.InventoryData) + len(webhookPayload.Items) + len(webhookPayload.Customers)
+	return rows, nil
+}
+
+// dropInventoryEchoes คืนค่าเฉพาะ inventory levels ที่ไม่ใช่ echo ของการเปลี่ยนแปลงที่เราส่งไปเอง
+func dropInventoryEchoes(db *sql.DB, merchantID string, levels []models.LoyInventoryLevel) ([]models.LoyInventoryLevel, error) {
+	kept := levels[:0:0]
+	for _, level := range levels {
+		echo, err := repository.IsInventoryEcho(db, merchantID, level, OutboundEchoWindow)
+		if err != nil {
+			return nil, err
+		}
+		if echo {
+			log.Printf("Skipping echo of our own stock update for variant %s at store %s", level.VariantID, level.StoreID)
+			continue
+		}
+		kept = append(kept, level)
+	}
+	return kept, nil
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// BusinessTimezone คือ timezone ของร้าน ใช้ตีความวันที่และเวลาที่ผู้ใช้ส่งมาโดยไม่ระบุ timezone
+const BusinessTimezone = "Asia/Bangkok"
+
+const dateLayout = "2006-01-02"
+
+// ParseDateRange แปลงช่วงเวลา from/to จาก query หรือ flag
+// รับได้ทั้ง RFC3339 และวันที่ล้วน (YYYY-MM-DD ตาม loc) โดย to ที่เป็นวันที่ล้วนหมายถึงสิ้นวันนั้น
+// ทั้งสองค่าเป็นค่าบังคับและ from ต้องไม่อยู่หลัง to
+func ParseDateRange(from, to string, loc *time.Location) (time.Time, time.Time, error) {
+	start, err := parseRangeBound(from, loc, false)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid from: %v", err)
+	}
+	end, err := parseRangeBound(to, loc, true)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid to: %v", err)
+	}
+	if start.After(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from %s is after to %s", from, to)
+	}
+	return start, end, nil
+}
+
+func parseRangeBound(value string, loc *time.Location, endOfDay bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, fmt.Errorf("value is required")
+	}
+	if t, err := time.ParseInLocation(dateLayout, value, loc); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1).Add(-time.Millisecond)
+		}
+		return t, nil
+	}
+	return time.Parse(time.RFC3339Nano, value)
+}
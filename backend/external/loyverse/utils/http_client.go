@@ -1,20 +1,234 @@
 package utils
 
 import (
-	"io/ioutil"
+	"backend/external/loyverse/models"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
 	"net/http"
+	"strconv"
+	"time"
 )
 
-func MakeGetRequest(url string, token string) ([]byte, error) {
-	client := &http.Client{}
-	req, _ := http.NewRequest("GET", url, nil)
-	req.Header.Add("Authorization", "Bearer "+token)
+// ค่าเริ่มต้นของ Client
+const (
+	DefaultTimeout     = 30 * time.Second
+	DefaultMaxRetries  = 5
+	DefaultBaseBackoff = 500 * time.Millisecond
+	DefaultMaxBackoff  = 30 * time.Second
+	// DefaultMaxRetryAfter คือเวลารอสูงสุดที่ยอมรับจาก header Retry-After
+	// ถ้า API ขอให้รอนานกว่านี้จะไม่ retry แต่คืน error กลับไปให้ผู้เรียกทันที
+	DefaultMaxRetryAfter = 2 * time.Minute
+	maxErrorBodyLength   = 512
+)
+
+// APIError คือ error ที่ได้เมื่อ Loyverse API ตอบกลับด้วย status code ที่ไม่ใช่ 2xx
+type APIError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // มีค่าเมื่อ API ส่ง header Retry-After มา
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("loyverse api: %s %s returned %d: %s", e.Method, e.URL, e.StatusCode, e.Body)
+}
+
+// RateLimited คืนค่า true ถ้าเป็น 429 Too Many Requests
+func (e *APIError) RateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests
+}
+
+// Retryable คืนค่า true ถ้าควรลองส่ง request ใหม่ (429 หรือ 5xx)
+func (e *APIError) Retryable() bool {
+	return e.RateLimited() || e.StatusCode >= 500
+}
+
+// RetriesExhaustedError คือ error ที่ได้เมื่อลองครบจำนวนครั้งแล้วยังไม่สำเร็จ
+// Err เก็บ error ของครั้งสุดท้าย (มักเป็น *APIError หรือ network error)
+type RetriesExhaustedError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *RetriesExhaustedError) Error() string {
+	return fmt.Sprintf("loyverse api: giving up after %d attempts: %v", e.Attempts, e.Err)
+}
+
+func (e *RetriesExhaustedError) Unwrap() error {
+	return e.Err
+}
 
-	resp, err := client.Do(req)
+// Client คือ HTTP client สำหรับเรียก Loyverse API
+// รองรับ timeout, retry 5xx แบบ exponential backoff + jitter และรอตาม Retry-After เมื่อโดน 429
+// (ไม่เกิน MaxRetryAfter)
+type Client struct {
+	HTTPClient    *http.Client
+	Token         string
+	MaxRetries    int
+	BaseBackoff   time.Duration
+	MaxBackoff    time.Duration
+	MaxRetryAfter time.Duration
+}
+
+// NewClient สร้าง Client พร้อมค่าเริ่มต้น
+func NewClient(token string) *Client {
+	return &Client{
+		HTTPClient:    &http.Client{Timeout: DefaultTimeout},
+		Token:         token,
+		MaxRetries:    DefaultMaxRetries,
+		BaseBackoff:   DefaultBaseBackoff,
+		MaxBackoff:    DefaultMaxBackoff,
+		MaxRetryAfter: DefaultMaxRetryAfter,
+	}
+}
+
+// Get ส่ง GET request ไปยัง url และคืนค่า body เมื่อได้ status 2xx เท่านั้น
+func (c *Client) Get(ctx context.Context, url string) ([]byte, error) {
+	return c.send(ctx, http.MethodGet, url, nil)
+}
+
+// Post ส่ง body แบบ JSON ไปยัง url และคืนค่า body ของ response เมื่อได้ status 2xx เท่านั้น
+// Post ใช้นโยบาย retry เดียวกับ Get ผู้เรียกจึงต้องส่งเฉพาะ request ที่ส่งซ้ำได้
+// เช่นการอัปเดตด้วย id หรือการตั้งค่า stock_after แบบค่าสัมบูรณ์
+func (c *Client) Post(ctx context.Context, url string, body []byte) ([]byte, error) {
+	return c.send(ctx, http.MethodPost, url, body)
+}
+
+func (c *Client) send(ctx context.Context, method, url string, payload []byte) ([]byte, error) {
+	var lastErr error
+	for attempt := 0; attempt <= c.MaxRetries; attempt++ {
+		if attempt > 0 {
+			wait := c.backoff(attempt, lastErr)
+			log.Printf("Retrying %s %s in %s (attempt %d/%d): %v", method, url, wait, attempt+1, c.MaxRetries+1, lastErr)
+			ReportProgress(ctx, models.SyncJobEvent{
+				Type:    models.SyncJobEventError,
+				Message: fmt.Sprintf("retrying in %s (attempt %d/%d): %v", wait, attempt+1, c.MaxRetries+1, lastErr),
+			})
+			if err := sleepContext(ctx, wait); err != nil {
+				return nil, err
+			}
+		}
+
+		body, err := c.do(ctx, method, url, payload)
+		if err == nil {
+			return body, nil
+		}
+		if !isRetryable(ctx, err) || c.retryAfterTooLong(err) {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, &RetriesExhaustedError{Attempts: c.MaxRetries + 1, Err: lastErr}
+}
+
+func (c *Client) do(ctx context.Context, method, url string, payload []byte) ([]byte, error) {
+	var reqBody io.Reader
+	if payload != nil {
+		reqBody = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	return ioutil.ReadAll(resp.Body)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if len(body) > maxErrorBodyLength {
+			body = body[:maxErrorBodyLength]
+		}
+		return nil, &APIError{
+			Method:     method,
+			URL:        url,
+			StatusCode: resp.StatusCode,
+			Body:       string(body),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+	return body, nil
+}
+
+// backoff คำนวณเวลารอก่อนลองใหม่ครั้งที่ attempt
+// ถ้า API ส่ง Retry-After มาจะใช้ค่านั้น (ไม่เกิน MaxRetryAfter) มิฉะนั้นใช้ exponential backoff แบบ full jitter
+func (c *Client) backoff(attempt int, lastErr error) time.Duration {
+	var apiErr *APIError
+	if errors.As(lastErr, &apiErr) && apiErr.RetryAfter > 0 {
+		return min(apiErr.RetryAfter, c.MaxRetryAfter)
+	}
+
+	ceiling := c.BaseBackoff << uint(attempt-1)
+	if ceiling <= 0 || ceiling > c.MaxBackoff {
+		ceiling = c.MaxBackoff
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling)))
+}
+
+// retryAfterTooLong คืนค่า true ถ้า API ขอให้รอนานกว่า MaxRetryAfter
+// กรณีนี้ไม่ควรค้าง sync ไว้ จึงคืน error ให้ผู้เรียกตัดสินใจเองแทนการ retry
+func (c *Client) retryAfterTooLong(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.RetryAfter > c.MaxRetryAfter
+}
+
+// isRetryable ตัดสินว่า error นี้ควรลองใหม่หรือไม่
+// network error และ timeout ลองใหม่ได้ ยกเว้นกรณีที่ context ของผู้เรียกถูกยกเลิกแล้ว
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable()
+	}
+	return true
+}
+
+// parseRetryAfter แปลง header Retry-After ซึ่งอาจเป็นจำนวนวินาทีหรือ HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(at); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
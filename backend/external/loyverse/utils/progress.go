@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"backend/external/loyverse/models"
+	"context"
+)
+
+// ProgressFunc รับ event ความคืบหน้าของ sync ที่กำลังทำงานภายใต้ context นั้น
+type ProgressFunc func(event models.SyncJobEvent)
+
+type progressKey struct{}
+
+// WithProgress คืน context ที่ส่ง event ของ ReportProgress ไปยัง fn
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// ReportProgress ส่ง event ไปยัง ProgressFunc ของ ctx ถ้าไม่มีผู้ติดตาม (เช่น sync จาก cron) จะไม่ทำอะไร
+func ReportProgress(ctx context.Context, event models.SyncJobEvent) {
+	if fn, ok := ctx.Value(progressKey{}).(ProgressFunc); ok {
+		fn(event)
+	}
+}
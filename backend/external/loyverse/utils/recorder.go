@@ -0,0 +1,264 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// โหมดของ Recorder
+const (
+	RecorderModeRecord = "record" // ส่ง request จริงและบันทึก request/response ทุกครั้งลง fixtures
+	RecorderModeReplay = "replay" // ตอบกลับจาก fixtures โดยไม่เรียก network
+)
+
+// redacted คือค่าที่ใช้แทน token ใน fixtures
+const redacted = "[REDACTED]"
+
+// Fixture คือ request/response หนึ่งคู่ที่บันทึกไว้เป็นไฟล์ JSON
+type Fixture struct {
+	Method       string            `json:"method"`
+	URL          string            `json:"url"`
+	RequestBody  json.RawMessage   `json:"request_body,omitempty"`
+	Status       int               `json:"status"`
+	Headers      map[string]string `json:"headers,omitempty"`
+	ResponseBody string            `json:"response_body"`
+	RecordedAt   time.Time         `json:"recorded_at"`
+}
+
+// Recorder เป็น http.RoundTripper ที่บันทึกหรือเล่นซ้ำ response ของ Loyverse API
+// header Authorization ไม่ถูกบันทึก และ token ของทุก Client ที่ใช้ Recorder นี้ที่ปรากฏใน URL หรือ body จะถูกแทนด้วย [REDACTED]
+//
+// ไฟล์ถูกตั้งชื่อตาม method, resource และ hash ของ path+query+body (ไม่รวม host)
+// ต่อท้ายด้วยลำดับครั้งที่เรียก request เดิม เช่น GET_receipts_1a2b3c4d_002.json
+// request เดิมที่ถูกเรียกซ้ำ (เช่นการ retry หลังโดน 429) จึงได้ response ตามลำดับเดียวกับตอนบันทึก
+// ควรใช้ไดเรกทอรีว่างสำหรับการบันทึกแต่ละครั้ง เพราะไฟล์ชื่อเดิมจะถูกเขียนทับ
+type Recorder struct {
+	Mode string
+	Dir  string
+	Next http.RoundTripper // transport จริงที่ใช้ในโหมด record
+
+	mu     sync.Mutex
+	counts map[string]int
+	tokens []string // ถูกแทนด้วย [REDACTED] ทุกที่ใน fixtures (เพิ่มด้วย AddToken)
+}
+
+// NewRecorder สร้าง Recorder และตรวจสอบโหมด
+func NewRecorder(mode, dir, token string, next http.RoundTripper) (*Recorder, error) {
+	if mode != RecorderModeRecord && mode != RecorderModeReplay {
+		return nil, fmt.Errorf("unknown recorder mode %q (want %q or %q)", mode, RecorderModeRecord, RecorderModeReplay)
+	}
+	if dir == "" {
+		return nil, fmt.Errorf("recorder fixtures directory is required")
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	recorder := &Recorder{Mode: mode, Dir: dir, Next: next, counts: make(map[string]int)}
+	recorder.AddToken(token)
+	return recorder, nil
+}
+
+// AddToken เพิ่ม token ที่ต้องแทนด้วย [REDACTED] ใน fixtures
+// Recorder ถูกใช้ร่วมกันโดย Client ของทุก connection จึงต้องเพิ่ม token ของแต่ละ Client
+func (r *Recorder) AddToken(token string) {
+	if token == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.tokens {
+		if existing == token {
+			return
+		}
+	}
+	r.tokens = append(r.tokens, token)
+	// แทน token ที่ยาวกว่าก่อน เพื่อไม่ให้ token ที่เป็นส่วนหนึ่งของอีกตัวทำให้เหลือเศษ
+	sort.Slice(r.tokens, func(i, j int) bool { return len(r.tokens[i]) > len(r.tokens[j]) })
+}
+
+// sharedRecorders เก็บ Recorder ของแต่ละไดเรกทอรีไว้ใช้ร่วมกันทั้ง process
+// เพราะ services สร้าง Client ใหม่ทุกครั้ง ลำดับของ request ที่ซ้ำกันจึงต้องนับต่อเนื่องข้าม Client
+var sharedRecorders = struct {
+	sync.Mutex
+	byDir map[string]*Recorder
+}{byDir: make(map[string]*Recorder)}
+
+// UseRecorder ให้ Client ส่ง request ผ่าน Recorder ในโหมดที่กำหนด
+// Client ทุกตัวที่ใช้ไดเรกทอรีเดียวกันจะใช้ Recorder ตัวเดียวกัน และ token ของ Client ถูกเพิ่มให้ Recorder แทนใน fixtures
+// ในโหมด replay จะไม่รอ backoff ระหว่าง retry เพราะ response มาจากไฟล์
+func (c *Client) UseRecorder(mode, dir string) error {
+	sharedRecorders.Lock()
+	defer sharedRecorders.Unlock()
+
+	key := mode + "|" + dir
+	recorder, ok := sharedRecorders.byDir[key]
+	if !ok {
+		var err error
+		recorder, err = NewRecorder(mode, dir, c.Token, c.HTTPClient.Transport)
+		if err != nil {
+			return err
+		}
+		sharedRecorders.byDir[key] = recorder
+	}
+	recorder.AddToken(c.Token)
+	c.HTTPClient.Transport = recorder
+	if mode == RecorderModeReplay {
+		c.BaseBackoff = 0
+		c.MaxBackoff = 0
+	}
+	return nil
+}
+
+// RoundTrip บันทึกหรือเล่นซ้ำ request ตามโหมด
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	path := filepath.Join(r.Dir, r.nextName(req, body))
+
+	if r.Mode == RecorderModeReplay {
+		return r.replay(req, path)
+	}
+	return r.record(req, body, path)
+}
+
+func (r *Recorder) record(req *http.Request, body []byte, path string) (*http.Response, error) {
+	resp, err := r.Next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	fixture := Fixture{
+		Method:       req.Method,
+		URL:          r.redact(req.URL.RequestURI()),
+		Status:       resp.StatusCode,
+		Headers:      map[string]string{},
+		ResponseBody: r.redact(string(respBody)),
+		RecordedAt:   time.Now().UTC(),
+	}
+	if redactedBody := r.redact(string(body)); json.Valid([]byte(redactedBody)) {
+		fixture.RequestBody = json.RawMessage(redactedBody)
+	}
+	for _, name := range []string{"Content-Type", "Retry-After"} {
+		if value := resp.Header.Get(name); value != "" {
+			fixture.Headers[name] = value
+		}
+	}
+
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(r.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return nil, fmt.Errorf("recording %s: %w", path, err)
+	}
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, path string) (*http.Response, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("no recorded response for %s %s: %w", req.Method, req.URL.RequestURI(), err)
+	}
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("reading fixture %s: %w", path, err)
+	}
+
+	// ไม่ส่ง Retry-After กลับไป เพื่อให้การ retry ตอน replay ไม่ต้องรอจริง
+	header := http.Header{}
+	for name, value := range fixture.Headers {
+		if name != "Retry-After" {
+			header.Set(name, value)
+		}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", fixture.Status, http.StatusText(fixture.Status)),
+		StatusCode:    fixture.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(fixture.ResponseBody)),
+		ContentLength: int64(len(fixture.ResponseBody)),
+		Request:       req,
+	}, nil
+}
+
+// nextName คืนชื่อไฟล์ของ request นี้ และนับจำนวนครั้งที่ request เดิมถูกเรียก
+func (r *Recorder) nextName(req *http.Request, body []byte) string {
+	// เรียง query parameter ให้ชื่อไฟล์ไม่ขึ้นกับลำดับ
+	query := req.URL.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var canonical strings.Builder
+	canonical.WriteString(req.Method + " " + req.URL.Path)
+	for _, key := range keys {
+		canonical.WriteString("&" + key + "=" + strings.Join(query[key], ","))
+	}
+	canonical.Write(body)
+	sum := sha256.Sum256([]byte(canonical.String()))
+	hash := hex.EncodeToString(sum[:4])
+
+	// ชื่อ resource คือ segment แรกหลัง version เช่น /v1.0/items/<id> -> items
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if len(segments) > 1 && strings.HasPrefix(segments[0], "v") {
+		segments = segments[1:]
+	}
+	resource := segments[0]
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.counts[hash]++
+	return fmt.Sprintf("%s_%s_%s_%03d.json", req.Method, sanitize(resource), hash, r.counts[hash])
+}
+
+// redact แทน token ทุกตัวที่รู้จักด้วย [REDACTED]
+func (r *Recorder) redact(value string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, token := range r.tokens {
+		value = strings.ReplaceAll(value, token, redacted)
+	}
+	return value
+}
+
+// sanitize ให้ชื่อ resource ใช้เป็นชื่อไฟล์ได้
+func sanitize(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, name)
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MinScheduleInterval คือช่วงเวลาที่สั้นที่สุดที่อนุญาตสำหรับตารางเวลาแบบ interval
+const MinScheduleInterval = time.Minute
+
+// ParseSchedule แปลงค่าตารางเวลาจากตาราง settings เป็น cron spec
+// รองรับรูปแบบ:
+//   - "03:00"               วันละครั้ง
+//   - "03:00,12:00,18:30"   หลายเวลาต่อวัน
+//   - "every 30m"           ทุก ๆ ช่วงเวลา (Go duration เช่น 15m, 2h)
+func ParseSchedule(value string) ([]string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, fmt.Errorf("schedule is empty")
+	}
+
+	if strings.HasPrefix(value, "every ") {
+		interval, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(value, "every ")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval %q: %v", value, err)
+		}
+		if interval < MinScheduleInterval {
+			return nil, fmt.Errorf("interval %s is shorter than %s", interval, MinScheduleInterval)
+		}
+		return []string{"@every " + interval.String()}, nil
+	}
+
+	var specs []string
+	seen := map[string]bool{}
+	for _, part := range strings.Split(value, ",") {
+		hour, minute, err := parseClock(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		spec := fmt.Sprintf("%d %d * * *", minute, hour)
+		if !seen[spec] {
+			seen[spec] = true
+			specs = append(specs, spec)
+		}
+	}
+	return specs, nil
+}
+
+// parseClock ตรวจสอบเวลาในรูปแบบ HH:MM (24 ชั่วโมง)
+func parseClock(value string) (hour, minute int, err error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 2 || len(parts[0]) == 0 || len(parts[0]) > 2 || len(parts[1]) != 2 {
+		return 0, 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	hour, err = strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, 0, fmt.Errorf("invalid hour in %q", value)
+	}
+	minute, err = strconv.Atoi(parts[1])
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, 0, fmt.Errorf("invalid minute in %q", value)
+	}
+	return hour, minute, nil
+}
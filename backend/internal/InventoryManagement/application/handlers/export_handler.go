@@ -15,7 +15,7 @@ func NewExportHandler(exportService *services.ExportService) *ExportHandler {
 }
 
 func (h *ExportHandler) ExportToGoogleSheetHandler(w http.ResponseWriter, r *http.Request) {
-	if err := h.exportService.ExportItemStockDataToGoogleSheet(); err != nil {
+	if err := h.exportService.ExportItemStockDataToGoogleSheet(merchantIDParam(r), includeDeletedParam(r)); err != nil {
 		http.Error(w, "Failed to export data to Google Sheets", http.StatusInternalServerError)
 		return
 	}
@@ -0,0 +1,86 @@
+// backend/internal/InventoryManagement/application/handlers/inventory_history_handler.go
+package handlers
+
+import (
+	"backend/internal/InventoryManagement/application/services"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// historyTimezone is the timezone used to read date-only from/to values (same as the stores).
+const historyTimezone = "Asia/Bangkok"
+
+// defaultHistoryDays is the range returned when from is not given.
+const defaultHistoryDays = 30
+
+type InventoryHistoryHandler struct {
+	historyService *services.InventoryHistoryService
+}
+
+func NewInventoryHistoryHandler(historyService *services.InventoryHistoryService) *InventoryHistoryHandler {
+	return &InventoryHistoryHandler{historyService: historyService}
+}
+
+// GetInventoryHistoryHandler returns the stock snapshots of a variant over time for charting.
+// ?variant_id= is required; store_id narrows to one store; from and to accept YYYY-MM-DD or RFC3339
+// (default: the last 30 days up to now).
+func (h *InventoryHistoryHandler) GetInventoryHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	variantID := query.Get("variant_id")
+	if variantID == "" {
+		http.Error(w, "Missing variant_id parameter", http.StatusBadRequest)
+		return
+	}
+
+	from, to, err := historyRangeParams(query.Get("from"), query.Get("to"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data, err := h.historyService.GetInventoryHistory(merchantIDParam(r), variantID, query.Get("store_id"), from, to)
+	if err != nil {
+		http.Error(w, "Error retrieving inventory history", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(data)
+}
+
+// historyRangeParams อ่านช่วงเวลาของกราฟ วันที่แบบ YYYY-MM-DD ใช้เวลาไทย และ to หมายถึงสิ้นวันนั้น
+func historyRangeParams(fromValue, toValue string) (time.Time, time.Time, error) {
+	loc, err := time.LoadLocation(historyTimezone)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	to := time.Now()
+	if toValue != "" {
+		if to, err = parseHistoryTime(toValue, loc, true); err != nil {
+			return time.Time{}, time.Time{}, errors.New("Invalid to parameter")
+		}
+	}
+	from := to.AddDate(0, 0, -defaultHistoryDays)
+	if fromValue != "" {
+		if from, err = parseHistoryTime(fromValue, loc, false); err != nil {
+			return time.Time{}, time.Time{}, errors.New("Invalid from parameter")
+		}
+	}
+	if from.After(to) {
+		return time.Time{}, time.Time{}, errors.New("from must not be after to")
+	}
+	return from, to, nil
+}
+
+func parseHistoryTime(value string, loc *time.Location, endOfDay bool) (time.Time, error) {
+	if date, err := time.ParseInLocation("2006-01-02", value, loc); err == nil {
+		if endOfDay {
+			return date.AddDate(0, 0, 1).Add(-time.Millisecond), nil
+		}
+		return date, nil
+	}
+	return time.Parse(time.RFC3339Nano, value)
+}
@@ -17,7 +17,7 @@ func NewItemStockHandler(itemStockService *services.ItemService) *ItemStockHandl
 
 // GetItemStockHandler handles requests to retrieve item stock data.
 func (h *ItemStockHandler) GetItemStockHandler(w http.ResponseWriter, r *http.Request) {
-	data, err := h.itemStockService.GetItemStockData() // เรียกใช้ฟังก์ชัน GetItemStockData
+	data, err := h.itemStockService.GetItemStockData(merchantIDParam(r), includeDeletedParam(r)) // เรียกใช้ฟังก์ชัน GetItemStockData
 	if err != nil {
 		http.Error(w, "Error retrieving item stock data", http.StatusInternalServerError)
 		return
@@ -34,7 +34,7 @@ func (h *ItemStockHandler) GetItemStockByStoreHandler(w http.ResponseWriter, r *
 		return
 	}
 
-	data, err := h.itemStockService.GetItemStockByStore(itemID)
+	data, err := h.itemStockService.GetItemStockByStore(merchantIDParam(r), itemID, includeDeletedParam(r))
 	if err != nil {
 		http.Error(w, "Error retrieving store stock data", http.StatusInternalServerError)
 		return
@@ -43,3 +43,21 @@ func (h *ItemStockHandler) GetItemStockByStoreHandler(w http.ResponseWriter, r *
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(data)
 }
+
+// GetItemVariantsHandler returns the variants of an item with SKU, barcode, options and per-store price and stock.
+func (h *ItemStockHandler) GetItemVariantsHandler(w http.ResponseWriter, r *http.Request) {
+	itemID := r.URL.Query().Get("item_id")
+	if itemID == "" {
+		http.Error(w, "Missing item_id parameter", http.StatusBadRequest)
+		return
+	}
+
+	data, err := h.itemStockService.GetVariants(merchantIDParam(r), itemID, includeDeletedParam(r))
+	if err != nil {
+		http.Error(w, "Error retrieving item variants", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(data)
+}
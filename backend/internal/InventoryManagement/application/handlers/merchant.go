@@ -0,0 +1,22 @@
+// backend/internal/InventoryManagement/application/handlers/merchant.go
+package handlers
+
+import (
+	"backend/internal/InventoryManagement/domain/models"
+	"net/http"
+	"strconv"
+)
+
+// merchantIDParam อ่าน merchant จาก ?merchant_id (ไม่ระบุ = models.DefaultMerchantID)
+func merchantIDParam(r *http.Request) string {
+	if merchantID := r.URL.Query().Get("merchant_id"); merchantID != "" {
+		return merchantID
+	}
+	return models.DefaultMerchantID
+}
+
+// includeDeletedParam อ่าน ?include_deleted=true สำหรับรายงานย้อนหลังที่ต้องเห็นรายการที่ถูกลบใน Loyverse แล้ว
+func includeDeletedParam(r *http.Request) bool {
+	includeDeleted, _ := strconv.ParseBool(r.URL.Query().Get("include_deleted"))
+	return includeDeleted
+}
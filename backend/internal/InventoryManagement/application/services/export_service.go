@@ -15,13 +15,13 @@ func NewExportService(itemInterface interfaces.ItemInterface, sheetsClient *exte
 	return &ExportService{itemInterface: itemInterface, sheetsClient: sheetsClient}
 }
 
-func (s *ExportService) ExportItemStockDataToGoogleSheet() error {
+func (s *ExportService) ExportItemStockDataToGoogleSheet(merchantID string, includeDeleted bool) error {
 	// Clear existing data in Google Sheet before writing new data
 	err := s.sheetsClient.ClearSheet()
 	if err != nil {
 		return err
 	}
-	itemStockData, err := s.itemInterface.FetchItemStockData()
+	itemStockData, err := s.itemInterface.FetchItemStockData(merchantID, includeDeleted)
 	if err != nil {
 		return err
 	}
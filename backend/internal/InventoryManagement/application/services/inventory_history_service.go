@@ -0,0 +1,20 @@
+// backend/internal/InventoryManagement/application/services/inventory_history_service.go
+package services
+
+import (
+	"backend/internal/InventoryManagement/domain/interfaces"
+	"backend/internal/InventoryManagement/domain/models"
+	"time"
+)
+
+type InventoryHistoryService struct {
+	historyInterface interfaces.InventoryHistoryInterface
+}
+
+func NewInventoryHistoryService(historyInterface interfaces.InventoryHistoryInterface) *InventoryHistoryService {
+	return &InventoryHistoryService{historyInterface: historyInterface}
+}
+
+func (s *InventoryHistoryService) GetInventoryHistory(merchantID, variantID, storeID string, from, to time.Time) ([]models.InventoryHistoryPoint, error) {
+	return s.historyInterface.FetchInventoryHistory(merchantID, variantID, storeID, from, to)
+}
@@ -14,23 +14,27 @@ func NewItemService(itemInterface interfaces.ItemInterface) *ItemService {
 	return &ItemService{itemInterface: itemInterface}
 }
 
-func (s *ItemService) GetItemStockData() ([]models.ItemStockView, error) {
-	return s.itemInterface.FetchItemStockData()
+func (s *ItemService) GetItemStockData(merchantID string, includeDeleted bool) ([]models.ItemStockView, error) {
+	return s.itemInterface.FetchItemStockData(merchantID, includeDeleted)
 }
 
-func (s *ItemService) GetStockLevels(itemID string) ([]models.InventoryLevel, error) {
-	return s.itemInterface.GetStockLevels(itemID)
+func (s *ItemService) GetStockLevels(merchantID, itemID string) ([]models.InventoryLevel, error) {
+	return s.itemInterface.GetStockLevels(merchantID, itemID)
 }
 
-func (s *ItemService) GetItemByID(itemID string) (models.Item, error) {
-	return s.itemInterface.GetItemByID(itemID)
+func (s *ItemService) GetItemByID(merchantID, itemID string) (models.Item, error) {
+	return s.itemInterface.GetItemByID(merchantID, itemID)
 }
 
-func (s *ItemService) UpdateItemStatus(itemID, status string) error {
-	return s.itemInterface.UpdateItemStatus(itemID, status)
+func (s *ItemService) UpdateItemStatus(merchantID, itemID, status string) error {
+	return s.itemInterface.UpdateItemStatus(merchantID, itemID, status)
 }
 
 // backend/internal/InventoryManagement/application/services/item_service.go
-func (s *ItemService) GetItemStockByStore(itemID string) ([]models.StoreStock, error) {
-	return s.itemInterface.GetItemStockByStore(itemID)
+func (s *ItemService) GetItemStockByStore(merchantID, itemID string, includeDeleted bool) ([]models.StoreStock, error) {
+	return s.itemInterface.GetItemStockByStore(merchantID, itemID, includeDeleted)
+}
+
+func (s *ItemService) GetVariants(merchantID, itemID string, includeDeleted bool) ([]models.Variant, error) {
+	return s.itemInterface.FetchVariants(merchantID, itemID, includeDeleted)
 }
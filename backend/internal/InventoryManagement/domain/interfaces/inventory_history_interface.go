@@ -0,0 +1,11 @@
+// backend/internal/InventoryManagement/domain/interfaces/inventory_history_interface.go
+package interfaces
+
+import (
+	"backend/internal/InventoryManagement/domain/models"
+	"time"
+)
+
+type InventoryHistoryInterface interface {
+	FetchInventoryHistory(merchantID, variantID, storeID string, from, to time.Time) ([]models.InventoryHistoryPoint, error)
+}
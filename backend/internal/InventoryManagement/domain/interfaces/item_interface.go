@@ -4,9 +4,10 @@ package interfaces
 import "backend/internal/InventoryManagement/domain/models"
 
 type ItemInterface interface {
-	FetchItemStockData() ([]models.ItemStockView, error)
-	GetItemByID(itemID string) (models.Item, error)
-	GetStockLevels(itemID string) ([]models.InventoryLevel, error)
-	UpdateItemStatus(itemID, status string) error
-	GetItemStockByStore(itemID string) ([]models.StoreStock, error) // เพิ่มฟังก์ชันนี้
+	FetchItemStockData(merchantID string, includeDeleted bool) ([]models.ItemStockView, error)
+	GetItemByID(merchantID, itemID string) (models.Item, error)
+	GetStockLevels(merchantID, itemID string) ([]models.InventoryLevel, error)
+	UpdateItemStatus(merchantID, itemID, status string) error
+	GetItemStockByStore(merchantID, itemID string, includeDeleted bool) ([]models.StoreStock, error) // เพิ่มฟังก์ชันนี้
+	FetchVariants(merchantID, itemID string, includeDeleted bool) ([]models.Variant, error)
 }
@@ -0,0 +1,17 @@
+// backend/internal/InventoryManagement/domain/models/inventory_history.go
+package models
+
+import "time"
+
+// InventoryHistoryPoint is one stock snapshot of a variant in a store, recorded by every sync,
+// inventory webhook and stock adjustment pushed to Loyverse.
+type InventoryHistoryPoint struct {
+	VariantID  string     `json:"variant_id"`
+	StoreID    string     `json:"store_id"`
+	StoreName  string     `json:"store_name"`
+	InStock    float64    `json:"in_stock"`
+	Change     *float64   `json:"change"`     // difference from the previous snapshot of the same store (nil for the first point in range)
+	Source     string     `json:"source"`     // sync, webhook or push
+	UpdatedAt  *time.Time `json:"updated_at"` // updated_at reported by Loyverse
+	RecordedAt time.Time  `json:"recorded_at"`
+}
@@ -2,6 +2,7 @@
 package models
 
 import (
+	"backend/pkg/money"
 	"database/sql"
 	"time"
 )
@@ -13,8 +14,8 @@ type Item struct {
 	CategoryID      sql.NullString `json:"category_id"`      // Foreign key to Category
 	PrimarySupplier sql.NullString `json:"primary_supplier"` // Foreign key to Supplier
 	ImageURL        sql.NullString `json:"image_url"`        // Image URL for display purposes
-	DefaultPrice    float64        `json:"default_price"`    // Selling price for the item
-	PurchaseCost    float64        `json:"purchase_cost"`    // Purchase cost of the item
+	DefaultPrice    money.Money    `json:"default_price"`    // Selling price for the item
+	PurchaseCost    money.Money    `json:"purchase_cost"`    // Purchase cost of the item
 	CreatedAt       time.Time      `json:"created_at"`       // Creation timestamp
 	UpdatedAt       time.Time      `json:"updated_at"`       // Update timestamp
 }
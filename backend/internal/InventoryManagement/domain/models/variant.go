@@ -0,0 +1,31 @@
+// backend/internal/InventoryManagement/domain/models/variant.go
+package models
+
+import "backend/pkg/money"
+
+// Variant is one sellable variant of an item (e.g. a pack size) with its per-store price and stock.
+type Variant struct {
+	VariantID          string         `json:"variant_id"`
+	ItemID             string         `json:"item_id"`
+	SKU                *string        `json:"sku"`
+	Barcode            *string        `json:"barcode"`
+	ReferenceVariantID *string        `json:"reference_variant_id"`
+	Option1Value       *string        `json:"option1_value"`
+	Option2Value       *string        `json:"option2_value"`
+	Option3Value       *string        `json:"option3_value"`
+	Cost               money.Money    `json:"cost"`
+	PurchaseCost       money.Money    `json:"purchase_cost"`
+	DefaultPricingType *string        `json:"default_pricing_type"`
+	DefaultPrice       *money.Money   `json:"default_price"`
+	Deleted            bool           `json:"deleted"`
+	Stores             []VariantStore `json:"stores"`
+}
+
+// VariantStore is the price, availability and stock of a variant in one store.
+type VariantStore struct {
+	StoreID          string       `json:"store_id"`
+	StoreName        string       `json:"store_name"`
+	Price            *money.Money `json:"price"`
+	AvailableForSale bool         `json:"available_for_sale"`
+	InStock          float64      `json:"in_stock"`
+}
@@ -3,8 +3,10 @@ module backend/internal/InventoryManagement
 go 1.23
 
 require (
+	backend/pkg/money v0.0.0-00010101000000-000000000000
 	github.com/gorilla/websocket v1.5.3
 	github.com/lib/pq v1.10.9
+	github.com/shopspring/decimal v1.4.0 // indirect
 	google.golang.org/api v0.204.0
 )
 
@@ -34,3 +36,5 @@ require (
 	google.golang.org/grpc v1.67.1 // indirect
 	google.golang.org/protobuf v1.35.1 // indirect
 )
+
+replace backend/pkg/money => ../../pkg/money
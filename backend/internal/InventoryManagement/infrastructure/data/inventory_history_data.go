@@ -0,0 +1,72 @@
+// backend/internal/InventoryManagement/infrastructure/data/inventory_history_data.go
+package data
+
+import (
+	"backend/internal/InventoryManagement/domain/models"
+	"database/sql"
+	"log"
+	"time"
+)
+
+// InventoryHistoryRepositoryDB reads stock snapshots from loyinventory_history.
+type InventoryHistoryRepositoryDB struct {
+	db *sql.DB
+}
+
+// NewInventoryHistoryRepository creates a new instance of InventoryHistoryRepositoryDB.
+func NewInventoryHistoryRepository(db *sql.DB) *InventoryHistoryRepositoryDB {
+	return &InventoryHistoryRepositoryDB{db: db}
+}
+
+// FetchInventoryHistory returns the stock snapshots of a variant recorded between from and to, oldest first.
+// An empty storeID returns every store. Change is computed against the previous snapshot of the same store
+// inside the range, so the first point of each store has no change.
+func (repo *InventoryHistoryRepositoryDB) FetchInventoryHistory(merchantID, variantID, storeID string, from, to time.Time) ([]models.InventoryHistoryPoint, error) {
+	query := `
+		SELECT
+			h.variant_id,
+			h.store_id,
+			COALESCE(st.store_name, ''),
+			h.in_stock,
+			h.in_stock - LAG(h.in_stock) OVER (PARTITION BY h.store_id ORDER BY h.recorded_at, h.id),
+			h.source,
+			h.updated_at,
+			h.recorded_at
+		FROM loyinventory_history h
+		LEFT JOIN loystores st ON st.store_id = h.store_id
+		WHERE h.merchant_id = $1 AND h.variant_id = $2 AND ($3 = '' OR h.store_id = $3)
+			AND h.recorded_at BETWEEN $4 AND $5
+		ORDER BY h.recorded_at, h.id`
+	rows, err := repo.db.Query(query, merchantID, variantID, storeID, from, to)
+	if err != nil {
+		log.Println("Error executing FetchInventoryHistory query:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := []models.InventoryHistoryPoint{}
+	for rows.Next() {
+		var point models.InventoryHistoryPoint
+		var change sql.NullFloat64
+		var updatedAt sql.NullTime
+		if err := rows.Scan(
+			&point.VariantID, &point.StoreID, &point.StoreName, &point.InStock,
+			&change, &point.Source, &updatedAt, &point.RecordedAt,
+		); err != nil {
+			log.Println("Error scanning row in FetchInventoryHistory:", err)
+			return nil, err
+		}
+		if change.Valid {
+			point.Change = &change.Float64
+		}
+		if updatedAt.Valid {
+			point.UpdatedAt = &updatedAt.Time
+		}
+		points = append(points, point)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return points, nil
+}
@@ -24,7 +24,11 @@ func nullStringToString(ns sql.NullString) string {
 	}
 	return "ไม่ทราบ" // ค่าที่ต้องการแสดงแทน NULL
 }
-func (repo *ItemRepositoryDB) FetchItemStockData() ([]models.ItemStockView, error) {
+
+// FetchItemStockData summarizes stock of every item of a merchant.
+// item_stock_view has no merchant_id, so rows are filtered through loyitems.
+// Items and stores deleted in Loyverse are left out unless includeDeleted is set.
+func (repo *ItemRepositoryDB) FetchItemStockData(merchantID string, includeDeleted bool) ([]models.ItemStockView, error) {
 	query := `
 		SELECT 
 			item_id, 
@@ -47,7 +51,9 @@ func (repo *ItemRepositoryDB) FetchItemStockData() ([]models.ItemStockView, erro
 		FROM 
 			item_stock_view
 		WHERE 
-			store_name NOT IN ('ลุงรวย รถส่งของ', 'สาขาอื่นๆ')
+			item_id IN (SELECT item_id FROM loyitems WHERE merchant_id = $1 AND ($2 OR deleted_at IS NULL))
+			AND ($2 OR store_name NOT IN (SELECT store_name FROM loystores WHERE merchant_id = $1 AND deleted_at IS NOT NULL))
+			AND store_name NOT IN ('ลุงรวย รถส่งของ', 'สาขาอื่นๆ')
 		GROUP BY 
 			item_id, 
 			item_name, 
@@ -59,7 +65,7 @@ func (repo *ItemRepositoryDB) FetchItemStockData() ([]models.ItemStockView, erro
 		ORDER BY 
 			item_name ASC
 	`
-	rows, err := repo.db.Query(query)
+	rows, err := repo.db.Query(query, merchantID, includeDeleted)
 	if err != nil {
 		log.Println("Error executing FetchItemStockData query:", err)
 		return nil, err
@@ -104,7 +110,7 @@ func (repo *ItemRepositoryDB) FetchItemStockData() ([]models.ItemStockView, erro
 }
 
 // backend/internal/InventoryManagement/infrastructure/repositories/item_repository.go
-func (repo *ItemRepositoryDB) GetItemStockByStore(itemID string) ([]models.StoreStock, error) {
+func (repo *ItemRepositoryDB) GetItemStockByStore(merchantID, itemID string, includeDeleted bool) ([]models.StoreStock, error) {
 	query := `
 		SELECT 
 			store_name, 
@@ -113,6 +119,8 @@ func (repo *ItemRepositoryDB) GetItemStockByStore(itemID string) ([]models.Store
 			item_stock_view
 		WHERE 
 			item_id = $1 AND store_name NOT IN ('ลุงรวย รถส่งของ', 'สาขาอื่นๆ')
+			AND item_id IN (SELECT item_id FROM loyitems WHERE merchant_id = $2 AND ($3 OR deleted_at IS NULL))
+			AND ($3 OR store_name NOT IN (SELECT store_name FROM loystores WHERE merchant_id = $2 AND deleted_at IS NOT NULL))
 		ORDER BY 
 			CASE 
 				WHEN store_name = 'โกดังปทุม' THEN 1
@@ -125,7 +133,7 @@ func (repo *ItemRepositoryDB) GetItemStockByStore(itemID string) ([]models.Store
 				ELSE 8 
 			END
 	`
-	rows, err := repo.db.Query(query, itemID)
+	rows, err := repo.db.Query(query, itemID, merchantID, includeDeleted)
 	if err != nil {
 		log.Println("Error executing GetItemStockByStore query:", err)
 		return nil, err
@@ -145,10 +153,15 @@ func (repo *ItemRepositoryDB) GetItemStockByStore(itemID string) ([]models.Store
 	return storeStockList, nil
 }
 
-// GetStockLevels retrieves stock levels for a given item ID.
-func (repo *ItemRepositoryDB) GetStockLevels(itemID string) ([]models.InventoryLevel, error) {
-	query := `SELECT variant_id, store_id, in_stock, updated_at FROM loyinventorylevels WHERE variant_id = $1`
-	rows, err := repo.db.Query(query, itemID)
+// GetStockLevels retrieves stock levels of every variant of a given item ID of a merchant.
+func (repo *ItemRepositoryDB) GetStockLevels(merchantID, itemID string) ([]models.InventoryLevel, error) {
+	query := `
+		SELECT variant_id, store_id, in_stock, updated_at
+		FROM loyinventorylevels
+		WHERE variant_id IN (SELECT variant_id FROM loyvariants WHERE item_id = $1 AND merchant_id = $2)
+		AND merchant_id = $2
+		ORDER BY variant_id, store_id`
+	rows, err := repo.db.Query(query, itemID, merchantID)
 	if err != nil {
 		log.Println("Error executing GetStockLevels query:", err)
 		return nil, err
@@ -168,11 +181,11 @@ func (repo *ItemRepositoryDB) GetStockLevels(itemID string) ([]models.InventoryL
 	return levels, nil
 }
 
-// GetItemByID retrieves an item by its ID.
-func (repo *ItemRepositoryDB) GetItemByID(itemID string) (models.Item, error) {
+// GetItemByID retrieves an item of a merchant by its ID.
+func (repo *ItemRepositoryDB) GetItemByID(merchantID, itemID string) (models.Item, error) {
 	var item models.Item
-	query := `SELECT item_id, item_name, description, category_id, primary_supplier, image_url, default_price, purchase_cost, created_at, updated_at FROM loyitems WHERE item_id = $1`
-	err := repo.db.QueryRow(query, itemID).Scan(
+	query := `SELECT item_id, item_name, description, category_id, primary_supplier, image_url, default_price, purchase_cost, created_at, updated_at FROM loyitems WHERE item_id = $1 AND merchant_id = $2`
+	err := repo.db.QueryRow(query, itemID, merchantID).Scan(
 		&item.ItemID, &item.ItemName, &item.Description, &item.CategoryID, &item.PrimarySupplier,
 		&item.ImageURL, &item.DefaultPrice, &item.PurchaseCost, &item.CreatedAt, &item.UpdatedAt,
 	)
@@ -184,10 +197,10 @@ func (repo *ItemRepositoryDB) GetItemByID(itemID string) (models.Item, error) {
 	return item, nil
 }
 
-// UpdateItemStatus updates the status of an item by item ID.
-func (repo *ItemRepositoryDB) UpdateItemStatus(itemID, status string) error {
-	query := `UPDATE loyitems SET status = $2 WHERE item_id = $1`
-	_, err := repo.db.Exec(query, itemID, status)
+// UpdateItemStatus updates the status of an item of a merchant by item ID.
+func (repo *ItemRepositoryDB) UpdateItemStatus(merchantID, itemID, status string) error {
+	query := `UPDATE loyitems SET status = $2 WHERE item_id = $1 AND merchant_id = $3`
+	_, err := repo.db.Exec(query, itemID, status, merchantID)
 	if err != nil {
 		log.Println("Error executing UpdateItemStatus query:", err)
 		return err
@@ -195,3 +208,76 @@ func (repo *ItemRepositoryDB) UpdateItemStatus(itemID, status string) error {
 
 	return nil
 }
+
+// FetchVariants retrieves the variants of an item with their per-store price, availability and stock.
+// Variants and stores deleted in Loyverse are left out unless includeDeleted is set.
+func (repo *ItemRepositoryDB) FetchVariants(merchantID, itemID string, includeDeleted bool) ([]models.Variant, error) {
+	query := `
+		SELECT variant_id, item_id, sku, barcode, reference_variant_id, option1_value, option2_value, option3_value,
+			cost, purchase_cost, default_pricing_type, default_price, deleted_at IS NOT NULL
+		FROM loyvariants
+		WHERE item_id = $1 AND merchant_id = $2 AND ($3 OR deleted_at IS NULL)
+		ORDER BY option1_value NULLS FIRST, option2_value NULLS FIRST, option3_value NULLS FIRST, variant_id`
+	rows, err := repo.db.Query(query, itemID, merchantID, includeDeleted)
+	if err != nil {
+		log.Println("Error executing FetchVariants query:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	variants := []models.Variant{}
+	index := make(map[string]int)
+	for rows.Next() {
+		var variant models.Variant
+		if err := rows.Scan(
+			&variant.VariantID, &variant.ItemID, &variant.SKU, &variant.Barcode, &variant.ReferenceVariantID,
+			&variant.Option1Value, &variant.Option2Value, &variant.Option3Value,
+			&variant.Cost, &variant.PurchaseCost, &variant.DefaultPricingType, &variant.DefaultPrice, &variant.Deleted,
+		); err != nil {
+			log.Println("Error scanning row in FetchVariants:", err)
+			return nil, err
+		}
+		variant.Stores = []models.VariantStore{}
+		index[variant.VariantID] = len(variants)
+		variants = append(variants, variant)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// price comes from loyvariant_stores and stock from loyinventorylevels; a store may only have one of them
+	storeQuery := `
+		SELECT
+			COALESCE(vs.variant_id, il.variant_id),
+			COALESCE(vs.store_id, il.store_id),
+			COALESCE(st.store_name, ''),
+			vs.price,
+			COALESCE(vs.available_for_sale, FALSE),
+			COALESCE(il.in_stock, 0)
+		FROM loyvariant_stores vs
+		FULL OUTER JOIN loyinventorylevels il ON il.variant_id = vs.variant_id AND il.store_id = vs.store_id
+		LEFT JOIN loystores st ON st.store_id = COALESCE(vs.store_id, il.store_id)
+		WHERE COALESCE(vs.variant_id, il.variant_id) IN (SELECT variant_id FROM loyvariants WHERE item_id = $1 AND merchant_id = $2)
+		AND ($3 OR st.deleted_at IS NULL)
+		ORDER BY COALESCE(st.store_name, '')`
+	storeRows, err := repo.db.Query(storeQuery, itemID, merchantID, includeDeleted)
+	if err != nil {
+		log.Println("Error executing FetchVariants store query:", err)
+		return nil, err
+	}
+	defer storeRows.Close()
+
+	for storeRows.Next() {
+		var variantID string
+		var store models.VariantStore
+		if err := storeRows.Scan(&variantID, &store.StoreID, &store.StoreName, &store.Price, &store.AvailableForSale, &store.InStock); err != nil {
+			log.Println("Error scanning row in FetchVariants:", err)
+			return nil, err
+		}
+		if i, ok := index[variantID]; ok {
+			variants[i].Stores = append(variants[i].Stores, store)
+		}
+	}
+
+	return variants, storeRows.Err()
+}
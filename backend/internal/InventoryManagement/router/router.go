@@ -13,6 +13,7 @@ import (
 // RegisterRoutes sets up all the routes for the application
 func RegisterRoutes(mux *http.ServeMux, db *sql.DB, sheetsClient *external.GoogleSheetsClient) {
 	RegisterItemRoutes(mux, db)
+	RegisterInventoryHistoryRoutes(mux, db)
 	RegisterExportRoutes(mux, db, sheetsClient)
 }
 
@@ -27,6 +28,19 @@ func RegisterItemRoutes(mux *http.ServeMux, db *sql.DB) {
 
 	// Route to get store-specific stock data for a given item ID
 	mux.HandleFunc("/api/item-stock/store", itemHandler.GetItemStockByStoreHandler)
+
+	// Route to get the variants of an item with per-store price and stock
+	mux.HandleFunc("/api/item-stock/variants", itemHandler.GetItemVariantsHandler)
+}
+
+// RegisterInventoryHistoryRoutes registers the route for stock history of a variant
+func RegisterInventoryHistoryRoutes(mux *http.ServeMux, db *sql.DB) {
+	historyRepo := data.NewInventoryHistoryRepository(db)
+	historyService := services.NewInventoryHistoryService(historyRepo)
+	historyHandler := handlers.NewInventoryHistoryHandler(historyService)
+
+	// Route to get stock snapshots of a variant over time (?variant_id=&store_id=&from=&to=)
+	mux.HandleFunc("/api/inventory/history", historyHandler.GetInventoryHistoryHandler)
 }
 
 // RegisterExportRoutes registers the route for exporting data to Google Sheets
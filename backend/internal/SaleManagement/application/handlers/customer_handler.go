@@ -0,0 +1,55 @@
+// SaleManagement/application/handlers/customer_handler.go
+package handlers
+
+import (
+	"backend/internal/SaleManagement/application/services"
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+type CustomerHandler struct {
+	customerService *services.CustomerService
+}
+
+func NewCustomerHandler(customerService *services.CustomerService) *CustomerHandler {
+	return &CustomerHandler{customerService: customerService}
+}
+
+func (h *CustomerHandler) ListCustomers(w http.ResponseWriter, r *http.Request) {
+	customers, err := h.customerService.GetCustomers(merchantIDParam(r), includeDeletedParam(r))
+	if err != nil {
+		log.Println("Error fetching customers:", err)
+		http.Error(w, "Failed to fetch customers", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(customers); err != nil {
+		log.Println("Error encoding customers to JSON:", err)
+		http.Error(w, "Failed to encode customers", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *CustomerHandler) ListCustomerReceipts(w http.ResponseWriter, r *http.Request) {
+	customerID := r.URL.Query().Get("customer_id")
+	if customerID == "" {
+		http.Error(w, "Missing customer_id parameter", http.StatusBadRequest)
+		return
+	}
+
+	receipts, err := h.customerService.GetReceiptsByCustomer(merchantIDParam(r), customerID, includeDeletedParam(r))
+	if err != nil {
+		log.Println("Error fetching customer receipts:", err)
+		http.Error(w, "Failed to fetch customer receipts", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(receipts); err != nil {
+		log.Println("Error encoding customer receipts to JSON:", err)
+		http.Error(w, "Failed to encode customer receipts", http.StatusInternalServerError)
+		return
+	}
+}
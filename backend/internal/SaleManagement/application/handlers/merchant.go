@@ -0,0 +1,22 @@
+// SaleManagement/application/handlers/merchant.go
+package handlers
+
+import (
+	"backend/internal/SaleManagement/domain/models"
+	"net/http"
+	"strconv"
+)
+
+// merchantIDParam อ่าน merchant จาก ?merchant_id (ไม่ระบุ = models.DefaultMerchantID)
+func merchantIDParam(r *http.Request) string {
+	if merchantID := r.URL.Query().Get("merchant_id"); merchantID != "" {
+		return merchantID
+	}
+	return models.DefaultMerchantID
+}
+
+// includeDeletedParam อ่าน ?include_deleted=true สำหรับรายงานย้อนหลังที่ต้องเห็นสินค้า สาขา หรือลูกค้าที่ถูกลบแล้ว
+func includeDeletedParam(r *http.Request) bool {
+	includeDeleted, _ := strconv.ParseBool(r.URL.Query().Get("include_deleted"))
+	return includeDeleted
+}
@@ -17,7 +17,7 @@ func NewReceiptHandler(receiptService *services.ReceiptService) *ReceiptHandler
 }
 
 func (h *ReceiptHandler) ListReceipts(w http.ResponseWriter, r *http.Request) {
-	receipts, err := h.receiptService.GetReceiptsWithDetails()
+	receipts, err := h.receiptService.GetReceiptsWithDetails(merchantIDParam(r), includeDeletedParam(r))
 	if err != nil {
 		log.Println("Error fetching receipts:", err) // เพิ่มการ log เมื่อเกิด error
 		http.Error(w, "Failed to fetch receipts", http.StatusInternalServerError)
@@ -34,7 +34,7 @@ func (h *ReceiptHandler) ListReceipts(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *ReceiptHandler) ListSalesByItem(w http.ResponseWriter, r *http.Request) {
-	sales, err := h.receiptService.GetSalesByItem()
+	sales, err := h.receiptService.GetSalesByItem(merchantIDParam(r), includeDeletedParam(r))
 	if err != nil {
 		log.Println("Error fetching sales by item:", err) // เพิ่มการ log เมื่อเกิด error
 		http.Error(w, "Failed to fetch sales by item", http.StatusInternalServerError)
@@ -50,7 +50,7 @@ func (h *ReceiptHandler) ListSalesByItem(w http.ResponseWriter, r *http.Request)
 }
 
 func (h *ReceiptHandler) ListSalesByDay(w http.ResponseWriter, r *http.Request) {
-	salesByDay, err := h.receiptService.GetSalesByDay()
+	salesByDay, err := h.receiptService.GetSalesByDay(merchantIDParam(r), includeDeletedParam(r))
 	if err != nil {
 		log.Println("Error fetching sales by day:", err) // เพิ่มการ log เมื่อเกิด error
 		http.Error(w, "Failed to fetch sales by day", http.StatusInternalServerError)
@@ -64,3 +64,54 @@ func (h *ReceiptHandler) ListSalesByDay(w http.ResponseWriter, r *http.Request)
 		return
 	}
 }
+
+// ListRefunds แสดงยอดคืนเงินตามสาขา สินค้า และเหตุผล
+func (h *ReceiptHandler) ListRefunds(w http.ResponseWriter, r *http.Request) {
+	refunds, err := h.receiptService.GetRefunds(merchantIDParam(r), includeDeletedParam(r))
+	if err != nil {
+		log.Println("Error fetching refunds:", err)
+		http.Error(w, "Failed to fetch refunds", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(refunds); err != nil {
+		log.Println("Error encoding refunds to JSON:", err)
+		http.Error(w, "Failed to encode refunds", http.StatusInternalServerError)
+		return
+	}
+}
+
+// ListDiscountUsage แสดงการใช้ส่วนลดแต่ละรายการตามสาขา
+func (h *ReceiptHandler) ListDiscountUsage(w http.ResponseWriter, r *http.Request) {
+	usage, err := h.receiptService.GetDiscountUsage(merchantIDParam(r), includeDeletedParam(r))
+	if err != nil {
+		log.Println("Error fetching discount usage:", err)
+		http.Error(w, "Failed to fetch discount usage", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(usage); err != nil {
+		log.Println("Error encoding discount usage to JSON:", err)
+		http.Error(w, "Failed to encode discount usage", http.StatusInternalServerError)
+		return
+	}
+}
+
+// ListTaxSummary แสดงยอดภาษีรายเดือนตามภาษีและสาขา
+func (h *ReceiptHandler) ListTaxSummary(w http.ResponseWriter, r *http.Request) {
+	summary, err := h.receiptService.GetTaxSummary(merchantIDParam(r), includeDeletedParam(r))
+	if err != nil {
+		log.Println("Error fetching tax summary:", err)
+		http.Error(w, "Failed to fetch tax summary", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(summary); err != nil {
+		log.Println("Error encoding tax summary to JSON:", err)
+		http.Error(w, "Failed to encode tax summary", http.StatusInternalServerError)
+		return
+	}
+}
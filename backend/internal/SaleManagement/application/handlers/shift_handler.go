@@ -0,0 +1,68 @@
+// SaleManagement/application/handlers/shift_handler.go
+package handlers
+
+import (
+	"backend/internal/SaleManagement/application/services"
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+type ShiftHandler struct {
+	shiftService *services.ShiftService
+}
+
+func NewShiftHandler(shiftService *services.ShiftService) *ShiftHandler {
+	return &ShiftHandler{shiftService: shiftService}
+}
+
+// ListSalesByEmployee แสดงยอดขายตามพนักงานที่ทำรายการ
+func (h *ShiftHandler) ListSalesByEmployee(w http.ResponseWriter, r *http.Request) {
+	sales, err := h.shiftService.GetSalesByEmployee(merchantIDParam(r), includeDeletedParam(r))
+	if err != nil {
+		log.Println("Error fetching sales by employee:", err)
+		http.Error(w, "Failed to fetch sales by employee", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(sales); err != nil {
+		log.Println("Error encoding sales by employee to JSON:", err)
+		http.Error(w, "Failed to encode sales by employee", http.StatusInternalServerError)
+		return
+	}
+}
+
+// ListSalesByDevice แสดงยอดขายตามเครื่อง POS
+func (h *ShiftHandler) ListSalesByDevice(w http.ResponseWriter, r *http.Request) {
+	sales, err := h.shiftService.GetSalesByDevice(merchantIDParam(r), includeDeletedParam(r))
+	if err != nil {
+		log.Println("Error fetching sales by device:", err)
+		http.Error(w, "Failed to fetch sales by device", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(sales); err != nil {
+		log.Println("Error encoding sales by device to JSON:", err)
+		http.Error(w, "Failed to encode sales by device", http.StatusInternalServerError)
+		return
+	}
+}
+
+// ListShiftCashVariance แสดงส่วนต่างเงินสดของแต่ละกะ
+func (h *ShiftHandler) ListShiftCashVariance(w http.ResponseWriter, r *http.Request) {
+	shifts, err := h.shiftService.GetShiftCashVariance(merchantIDParam(r), includeDeletedParam(r))
+	if err != nil {
+		log.Println("Error fetching shift cash variance:", err)
+		http.Error(w, "Failed to fetch shift cash variance", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(shifts); err != nil {
+		log.Println("Error encoding shift cash variance to JSON:", err)
+		http.Error(w, "Failed to encode shift cash variance", http.StatusInternalServerError)
+		return
+	}
+}
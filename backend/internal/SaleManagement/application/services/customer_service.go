@@ -0,0 +1,23 @@
+// SaleManagement/application/services/customer_service.go
+package services
+
+import (
+	"backend/internal/SaleManagement/domain/interfaces"
+	"backend/internal/SaleManagement/domain/models"
+)
+
+type CustomerService struct {
+	customerRepo interfaces.CustomerRepository
+}
+
+func NewCustomerService(repo interfaces.CustomerRepository) *CustomerService {
+	return &CustomerService{customerRepo: repo}
+}
+
+func (s *CustomerService) GetCustomers(merchantID string, includeDeleted bool) ([]models.Customer, error) {
+	return s.customerRepo.FetchCustomers(merchantID, includeDeleted)
+}
+
+func (s *CustomerService) GetReceiptsByCustomer(merchantID, customerID string, includeDeleted bool) ([]models.Receipt, error) {
+	return s.customerRepo.FetchReceiptsByCustomer(merchantID, customerID, includeDeleted)
+}
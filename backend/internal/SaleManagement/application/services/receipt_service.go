@@ -14,18 +14,30 @@ func NewReceiptService(repo interfaces.ReceiptRepository) *ReceiptService {
 	return &ReceiptService{receiptRepo: repo}
 }
 
-func (s *ReceiptService) GetReceiptsWithDetails() ([]models.Receipt, error) {
-	return s.receiptRepo.FetchReceiptsWithDetails()
+func (s *ReceiptService) GetReceiptsWithDetails(merchantID string, includeDeleted bool) ([]models.Receipt, error) {
+	return s.receiptRepo.FetchReceiptsWithDetails(merchantID, includeDeleted)
 }
 
 // SaleManagement/application/services/receipt_service.go
 
-func (s *ReceiptService) GetSalesByItem() ([]models.SaleItem, error) {
-	return s.receiptRepo.FetchSalesByItem()
+func (s *ReceiptService) GetSalesByItem(merchantID string, includeDeleted bool) ([]models.SaleItem, error) {
+	return s.receiptRepo.FetchSalesByItem(merchantID, includeDeleted)
 }
 
 // SaleManagement/application/services/receipt_service.go
 
-func (s *ReceiptService) GetSalesByDay() ([]models.SalesByDay, error) {
-	return s.receiptRepo.FetchSalesByDay()
+func (s *ReceiptService) GetSalesByDay(merchantID string, includeDeleted bool) ([]models.SalesByDay, error) {
+	return s.receiptRepo.FetchSalesByDay(merchantID, includeDeleted)
+}
+
+func (s *ReceiptService) GetRefunds(merchantID string, includeDeleted bool) ([]models.RefundSummary, error) {
+	return s.receiptRepo.FetchRefunds(merchantID, includeDeleted)
+}
+
+func (s *ReceiptService) GetDiscountUsage(merchantID string, includeDeleted bool) ([]models.DiscountUsage, error) {
+	return s.receiptRepo.FetchDiscountUsage(merchantID, includeDeleted)
+}
+
+func (s *ReceiptService) GetTaxSummary(merchantID string, includeDeleted bool) ([]models.TaxSummary, error) {
+	return s.receiptRepo.FetchTaxSummary(merchantID, includeDeleted)
 }
@@ -0,0 +1,27 @@
+// SaleManagement/application/services/shift_service.go
+package services
+
+import (
+	"backend/internal/SaleManagement/domain/interfaces"
+	"backend/internal/SaleManagement/domain/models"
+)
+
+type ShiftService struct {
+	shiftRepo interfaces.ShiftRepository
+}
+
+func NewShiftService(repo interfaces.ShiftRepository) *ShiftService {
+	return &ShiftService{shiftRepo: repo}
+}
+
+func (s *ShiftService) GetSalesByEmployee(merchantID string, includeDeleted bool) ([]models.EmployeeSales, error) {
+	return s.shiftRepo.FetchSalesByEmployee(merchantID, includeDeleted)
+}
+
+func (s *ShiftService) GetSalesByDevice(merchantID string, includeDeleted bool) ([]models.DeviceSales, error) {
+	return s.shiftRepo.FetchSalesByDevice(merchantID, includeDeleted)
+}
+
+func (s *ShiftService) GetShiftCashVariance(merchantID string, includeDeleted bool) ([]models.ShiftCashVariance, error) {
+	return s.shiftRepo.FetchShiftCashVariance(merchantID, includeDeleted)
+}
@@ -0,0 +1,9 @@
+// SaleManagement/domain/interfaces/customer_interface.go
+package interfaces
+
+import "backend/internal/SaleManagement/domain/models"
+
+type CustomerRepository interface {
+	FetchCustomers(merchantID string, includeDeleted bool) ([]models.Customer, error)
+	FetchReceiptsByCustomer(merchantID, customerID string, includeDeleted bool) ([]models.Receipt, error)
+}
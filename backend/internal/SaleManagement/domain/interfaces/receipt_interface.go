@@ -4,7 +4,10 @@ package interfaces
 import "backend/internal/SaleManagement/domain/models"
 
 type ReceiptRepository interface {
-	FetchReceiptsWithDetails() ([]models.Receipt, error)
-	FetchSalesByItem() ([]models.SaleItem, error)
-	FetchSalesByDay() ([]models.SalesByDay, error)
+	FetchReceiptsWithDetails(merchantID string, includeDeleted bool) ([]models.Receipt, error)
+	FetchSalesByItem(merchantID string, includeDeleted bool) ([]models.SaleItem, error)
+	FetchSalesByDay(merchantID string, includeDeleted bool) ([]models.SalesByDay, error)
+	FetchRefunds(merchantID string, includeDeleted bool) ([]models.RefundSummary, error)
+	FetchDiscountUsage(merchantID string, includeDeleted bool) ([]models.DiscountUsage, error)
+	FetchTaxSummary(merchantID string, includeDeleted bool) ([]models.TaxSummary, error)
 }
@@ -0,0 +1,10 @@
+// SaleManagement/domain/interfaces/shift_interface.go
+package interfaces
+
+import "backend/internal/SaleManagement/domain/models"
+
+type ShiftRepository interface {
+	FetchSalesByEmployee(merchantID string, includeDeleted bool) ([]models.EmployeeSales, error)
+	FetchSalesByDevice(merchantID string, includeDeleted bool) ([]models.DeviceSales, error)
+	FetchShiftCashVariance(merchantID string, includeDeleted bool) ([]models.ShiftCashVariance, error)
+}
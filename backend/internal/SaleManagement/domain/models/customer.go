@@ -0,0 +1,25 @@
+// backend/internal/SaleManagement/domain/models/customer.go
+package models
+
+import (
+	"backend/pkg/money"
+	"time"
+)
+
+// Customer คือลูกค้าจาก loycustomers พร้อมยอดซื้อที่คำนวณจาก loyreceipts
+type Customer struct {
+	CustomerID    string      `json:"customer_id"`
+	CustomerCode  *string     `json:"customer_code"`
+	Name          string      `json:"name"`
+	Email         *string     `json:"email"`
+	PhoneNumber   *string     `json:"phone_number"`
+	Address       *string     `json:"address"`
+	Note          *string     `json:"note"`
+	TotalPoints   float64     `json:"total_points"`
+	TotalVisits   int         `json:"total_visits"`
+	TotalSpent    money.Money `json:"total_spent"`
+	FirstVisit    *time.Time  `json:"first_visit"`
+	LastVisit     *time.Time  `json:"last_visit"`
+	ReceiptCount  int         `json:"receipt_count"`  // จำนวนใบขายในระบบของเรา (ไม่นับใบคืนเงิน)
+	ReceiptsTotal money.Money `json:"receipts_total"` // ยอดซื้อสุทธิจากใบเสร็จในระบบของเรา (หักใบคืนเงินแล้ว)
+}
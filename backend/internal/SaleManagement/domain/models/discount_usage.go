@@ -0,0 +1,20 @@
+// backend/internal/SaleManagement/domain/models/discount_usage.go
+package models
+
+import (
+	"backend/pkg/money"
+	"time"
+)
+
+// DiscountUsage คือการใช้ส่วนลดหนึ่งในสาขาหนึ่ง (ยอดของใบคืนเงินถูกหักออกแล้ว)
+type DiscountUsage struct {
+	DiscountID    string      `json:"discount_id"`
+	DiscountName  string      `json:"discount_name"`
+	DiscountType  string      `json:"discount_type"`
+	StoreName     string      `json:"store_name"`
+	ReceiptCount  int         `json:"receipt_count"`  // จำนวนใบขายที่ใช้ส่วนลดนี้
+	LineCount     int         `json:"line_count"`     // จำนวนรายการสินค้าที่ได้ส่วนลด (สุทธิหลังคืนเงิน)
+	TotalDiscount money.Money `json:"total_discount"` // มูลค่าส่วนลดสุทธิ
+	GrossSales    money.Money `json:"gross_sales"`    // ยอดขายก่อนหักส่วนลดของรายการที่ได้ส่วนลด
+	LastUsed      time.Time   `json:"last_used"`
+}
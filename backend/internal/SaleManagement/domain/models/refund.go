@@ -0,0 +1,20 @@
+// backend/internal/SaleManagement/domain/models/refund.go
+package models
+
+import (
+	"backend/pkg/money"
+	"time"
+)
+
+// RefundSummary คือยอดคืนเงินของสินค้าหนึ่งรายการในสาขาหนึ่งตามเหตุผลที่บันทึกไว้ในใบคืนเงิน
+type RefundSummary struct {
+	StoreName        string      `json:"store_name"`
+	ItemName         string      `json:"item_name"`
+	Reason           string      `json:"reason"`            // หมายเหตุของใบคืนเงิน ("ไม่ระบุ" ถ้าว่าง)
+	RefundCount      int         `json:"refund_count"`      // จำนวนใบคืนเงิน
+	Quantity         float64     `json:"quantity"`          // จำนวนชิ้นที่คืน
+	TotalRefunded    money.Money `json:"total_refunded"`    // ยอดเงินที่คืน
+	TotalCost        money.Money `json:"total_cost"`        // ต้นทุนของสินค้าที่คืน
+	OriginalReceipts []string    `json:"original_receipts"` // เลขที่ใบขายเดิมที่ถูกคืน
+	LastRefundDate   time.Time   `json:"last_refund_date"`
+}
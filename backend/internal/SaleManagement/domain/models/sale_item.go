@@ -1,15 +1,20 @@
 package models
 
+import "backend/pkg/money"
+
 type SaleItem struct {
-	ReceiptDate   string  `json:"receipt_date"`
-	ItemName      string  `json:"item_name"`
-	Quantity      float64 `json:"quantity"`
-	TotalSales    float64 `json:"total_sales"`
-	TotalCost     float64 `json:"total_cost"` // เพิ่มฟิลด์นี้
-	TotalDiscount float64 `json:"total_discount"`
-	PaymentName   string  `json:"payment_name"`
-	Status        string  `json:"status"`
-	CategoryName  string  `json:"category_name"`
-	StoreName     string  `json:"store_name"`
-	ReceiptNumber string  `json:"receipt_number"`
+	ReceiptDate   string      `json:"receipt_date"`
+	ItemName      string      `json:"item_name"`
+	VariantID     string      `json:"variant_id"`   // variant ที่ขาย (เช่นขนาดแพ็ค)
+	VariantName   string      `json:"variant_name"` // ชื่อ variant ตามใบเสร็จ
+	SKU           string      `json:"sku"`
+	Quantity      float64     `json:"quantity"`
+	TotalSales    money.Money `json:"total_sales"`
+	TotalCost     money.Money `json:"total_cost"` // เพิ่มฟิลด์นี้
+	TotalDiscount money.Money `json:"total_discount"`
+	PaymentName   string      `json:"payment_name"`
+	Status        string      `json:"status"`
+	CategoryName  string      `json:"category_name"`
+	StoreName     string      `json:"store_name"`
+	ReceiptNumber string      `json:"receipt_number"`
 }
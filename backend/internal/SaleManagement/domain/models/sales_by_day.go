@@ -1,12 +1,18 @@
 // backend/internal/SaleManagement/domain/models/sales_by_day.go
 package models
 
-import "time"
+import (
+	"backend/pkg/money"
+	"time"
+)
 
 type SalesByDay struct {
-	SaleDate      time.Time `json:"sale_date"`
-	ItemName      string    `json:"item_name"` // เพิ่มฟิลด์นี้
-	TotalQuantity float64   `json:"total_quantity"`
-	TotalSales    float64   `json:"total_sales"`
-	TotalProfit   float64   `json:"total_profit"`
+	SaleDate      time.Time   `json:"sale_date"`
+	ItemName      string      `json:"item_name"` // เพิ่มฟิลด์นี้
+	VariantID     string      `json:"variant_id"`
+	VariantName   string      `json:"variant_name"`
+	SKU           string      `json:"sku"`
+	TotalQuantity float64     `json:"total_quantity"`
+	TotalSales    money.Money `json:"total_sales"`
+	TotalProfit   money.Money `json:"total_profit"`
 }
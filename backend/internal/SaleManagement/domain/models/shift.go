@@ -0,0 +1,38 @@
+// backend/internal/SaleManagement/domain/models/shift.go
+package models
+
+import (
+	"backend/pkg/money"
+	"time"
+)
+
+// ShiftCashVariance คือเงินสดที่ควรมีเทียบกับเงินสดที่นับได้ตอนปิดกะ
+// Variance ติดลบแปลว่าเงินขาด และเป็น nil ถ้ากะยังไม่ปิด
+type ShiftCashVariance struct {
+	ShiftID       string         `json:"shift_id"`
+	StoreName     string         `json:"store_name"`
+	DeviceName    string         `json:"device_name"`
+	OpenedAt      time.Time      `json:"opened_at"`
+	ClosedAt      *time.Time     `json:"closed_at"`
+	OpenedBy      *string        `json:"opened_by"`
+	ClosedBy      *string        `json:"closed_by"`
+	StartingCash  money.Money    `json:"starting_cash"`
+	CashPayments  money.Money    `json:"cash_payments"`
+	CashRefunds   money.Money    `json:"cash_refunds"`
+	PaidIn        money.Money    `json:"paid_in"`
+	PaidOut       money.Money    `json:"paid_out"`
+	ExpectedCash  money.Money    `json:"expected_cash"`
+	ActualCash    *money.Money   `json:"actual_cash"`
+	Variance      *money.Money   `json:"variance"`
+	NetSales      money.Money    `json:"net_sales"`
+	CashMovements []CashMovement `json:"cash_movements"`
+}
+
+// CashMovement คือการนำเงินเข้า (PAY_IN) หรือออก (PAY_OUT) จากลิ้นชักระหว่างกะ
+type CashMovement struct {
+	Type         string      `json:"type"`
+	MoneyAmount  money.Money `json:"money_amount"`
+	Comment      *string     `json:"comment"`
+	EmployeeName *string     `json:"employee_name"`
+	CreatedAt    *time.Time  `json:"created_at"`
+}
@@ -0,0 +1,31 @@
+// backend/internal/SaleManagement/domain/models/staff_sales.go
+package models
+
+import "backend/pkg/money"
+
+// EmployeeSales คือยอดขายของพนักงานหนึ่งคน (ยอดใบคืนเงินถูกหักออกใน NetSales)
+type EmployeeSales struct {
+	EmployeeID    string      `json:"employee_id"`
+	EmployeeName  string      `json:"employee_name"`
+	ReceiptCount  int         `json:"receipt_count"` // จำนวนใบขาย
+	RefundCount   int         `json:"refund_count"`  // จำนวนใบคืนเงิน
+	GrossSales    money.Money `json:"gross_sales"`
+	Refunds       money.Money `json:"refunds"`
+	NetSales      money.Money `json:"net_sales"`
+	TotalDiscount money.Money `json:"total_discount"` // ส่วนลดสุทธิ
+	AverageSale   money.Money `json:"average_sale"`   // ยอดเฉลี่ยต่อใบขาย
+}
+
+// DeviceSales คือยอดขายของเครื่อง POS หนึ่งเครื่อง
+type DeviceSales struct {
+	PosDeviceID   string      `json:"pos_device_id"`
+	DeviceName    string      `json:"device_name"`
+	StoreName     string      `json:"store_name"`
+	ReceiptCount  int         `json:"receipt_count"`
+	RefundCount   int         `json:"refund_count"`
+	GrossSales    money.Money `json:"gross_sales"`
+	Refunds       money.Money `json:"refunds"`
+	NetSales      money.Money `json:"net_sales"`
+	TotalDiscount money.Money `json:"total_discount"`
+	AverageSale   money.Money `json:"average_sale"`
+}
@@ -0,0 +1,17 @@
+// backend/internal/SaleManagement/domain/models/tax_summary.go
+package models
+
+import "backend/pkg/money"
+
+// TaxSummary คือยอดภาษีของภาษีหนึ่งในสาขาหนึ่งต่อเดือน (ยอดของใบคืนเงินถูกหักออกแล้ว)
+type TaxSummary struct {
+	Month        string      `json:"month"` // YYYY-MM
+	TaxID        string      `json:"tax_id"`
+	TaxName      string      `json:"tax_name"`
+	TaxType      string      `json:"tax_type"` // INCLUDED หรือ ADDED
+	Rate         float64     `json:"rate"`
+	StoreName    string      `json:"store_name"`
+	ReceiptCount int         `json:"receipt_count"`
+	TaxableSales money.Money `json:"taxable_sales"` // ยอดขายสุทธิของรายการที่คิดภาษีนี้
+	TotalTax     money.Money `json:"total_tax"`
+}
@@ -2,4 +2,10 @@ module backend/internal/SaleManagement
 
 go 1.23
 
-require github.com/lib/pq v1.10.9 // indirect
+require (
+	backend/pkg/money v0.0.0-00010101000000-000000000000
+	github.com/lib/pq v1.10.9
+	github.com/shopspring/decimal v1.4.0 // indirect
+)
+
+replace backend/pkg/money => ../../pkg/money
@@ -0,0 +1,140 @@
+// SaleManagement/infrastructure/data/customer_data.go
+package data
+
+import (
+	"backend/internal/SaleManagement/domain/models"
+	"database/sql"
+)
+
+type CustomerRepository struct {
+	db *sql.DB
+}
+
+func NewCustomerRepository(db *sql.DB) *CustomerRepository {
+	return &CustomerRepository{db: db}
+}
+
+// FetchCustomers ดึงลูกค้าทั้งหมดของ merchant พร้อมจำนวนใบขายและยอดซื้อสุทธิ (หักใบคืนเงิน) ที่ join ด้วย customer_id
+// ลูกค้าที่ถูกลบใน Loyverse จะถูกรวมมาด้วยเฉพาะเมื่อ includeDeleted เป็น true
+func (repo *CustomerRepository) FetchCustomers(merchantID string, includeDeleted bool) ([]models.Customer, error) {
+	query := `
+        SELECT 
+            c.customer_id,
+            c.customer_code,
+            COALESCE(c.name, '') AS name,
+            c.email,
+            c.phone_number,
+            c.address,
+            c.note,
+            c.total_points,
+            c.total_visits,
+            c.total_spent,
+            c.first_visit,
+            c.last_visit,
+            COUNT(r.receipt_number) FILTER (WHERE r.receipt_type <> 'REFUND') AS receipt_count,
+            COALESCE(SUM(CASE WHEN r.receipt_type = 'REFUND' THEN -r.total_money ELSE r.total_money END), 0) AS receipts_total
+        FROM 
+            loycustomers c
+        LEFT JOIN 
+            loyreceipts r ON r.customer_id = c.customer_id AND r.merchant_id = c.merchant_id AND r.cancelled_at IS NULL
+        WHERE 
+            c.merchant_id = $1 AND ($2 OR c.deleted_at IS NULL)
+        GROUP BY 
+            c.customer_id
+        ORDER BY 
+            c.last_visit DESC NULLS LAST, c.name;
+    `
+
+	rows, err := repo.db.Query(query, merchantID, includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	customers := []models.Customer{}
+	for rows.Next() {
+		var customer models.Customer
+		err := rows.Scan(
+			&customer.CustomerID,
+			&customer.CustomerCode,
+			&customer.Name,
+			&customer.Email,
+			&customer.PhoneNumber,
+			&customer.Address,
+			&customer.Note,
+			&customer.TotalPoints,
+			&customer.TotalVisits,
+			&customer.TotalSpent,
+			&customer.FirstVisit,
+			&customer.LastVisit,
+			&customer.ReceiptCount,
+			&customer.ReceiptsTotal,
+		)
+		if err != nil {
+			return nil, err
+		}
+		customers = append(customers, customer)
+	}
+	return customers, rows.Err()
+}
+
+// FetchReceiptsByCustomer ดึงใบเสร็จทั้งหมดของลูกค้าหนึ่งคนใน merchant เรียงจากล่าสุด
+// ใบเสร็จของสาขาที่ถูกลบจะถูกรวมมาด้วยเฉพาะเมื่อ includeDeleted เป็น true
+func (repo *CustomerRepository) FetchReceiptsByCustomer(merchantID, customerID string, includeDeleted bool) ([]models.Receipt, error) {
+	query := `
+        SELECT 
+            r.receipt_number,
+            r.receipt_type,
+            r.refund_for,
+            r.receipt_date,
+            r.total_money,
+            r.total_discount,
+            r.customer_id,
+            cu.name,
+            s.store_name,
+            CASE 
+                WHEN r.cancelled_at IS NOT NULL THEN 'ยกเลิก' 
+                WHEN r.receipt_type = 'REFUND' THEN 'คืนเงิน' 
+                ELSE 'ขาย' 
+            END AS Status
+        FROM 
+            loyreceipts r
+        JOIN 
+            loystores s ON r.store_id = s.store_id
+        LEFT JOIN 
+            loycustomers cu ON r.customer_id = cu.customer_id
+        WHERE 
+            r.merchant_id = $1 AND r.customer_id = $2
+            AND ($3 OR s.deleted_at IS NULL)
+        ORDER BY 
+            r.receipt_date DESC;
+    `
+
+	rows, err := repo.db.Query(query, merchantID, customerID, includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	receipts := []models.Receipt{}
+	for rows.Next() {
+		var receipt models.Receipt
+		err := rows.Scan(
+			&receipt.ReceiptNumber,
+			&receipt.ReceiptType,
+			&receipt.RefundFor,
+			&receipt.ReceiptDate,
+			&receipt.TotalMoney,
+			&receipt.TotalDiscount,
+			&receipt.CustomerID,
+			&receipt.CustomerName,
+			&receipt.StoreName,
+			&receipt.Status,
+		)
+		if err != nil {
+			return nil, err
+		}
+		receipts = append(receipts, receipt)
+	}
+	return receipts, rows.Err()
+}
@@ -18,38 +18,95 @@ func NewReceiptRepository(db *sql.DB) *ReceiptRepository {
 	return &ReceiptRepository{db: db}
 }
 
-func (repo *ReceiptRepository) FetchReceiptsWithDetails() ([]models.Receipt, error) {
+// FetchReceiptsWithDetails ดึงใบเสร็จของ merchant พร้อมรายการสินค้าและวิธีชำระเงิน
+// ใบเสร็จของสาขาที่ถูกลบใน Loyverse จะถูกรวมมาด้วยเฉพาะเมื่อ includeDeleted เป็น true
+func (repo *ReceiptRepository) FetchReceiptsWithDetails(merchantID string, includeDeleted bool) ([]models.Receipt, error) {
 	var receipts []models.Receipt
 	query := `
         SELECT 
             r.receipt_date AS ReceiptDate,
             r.receipt_number AS ReceiptNumber,
+            r.receipt_type AS ReceiptType,
+            r.refund_for AS RefundFor,
             r.total_money AS TotalMoney,
             r.total_discount AS TotalDiscount,
             s.store_name AS StoreName,
-            array_agg(DISTINCT pt.name) AS PaymentNames,  -- ใช้ DISTINCT เพื่อหลีกเลี่ยงการซ้ำกัน
+            r.customer_id AS CustomerID,
+            cu.name AS CustomerName,
+            COALESCE(r.pos_device_id, '') AS PosDeviceId,
+            COALESCE(e.name, r.employee_id) AS EmployeeName,
+            COALESCE(d.name, r.pos_device_id) AS DeviceName,
+            (
+                SELECT array_agg(DISTINCT COALESCE(pt.name, p.name))
+                FROM receipt_payments p
+                LEFT JOIN loypaymenttypes pt ON p.payment_type_id = pt.payment_type_id
+                WHERE p.merchant_id = r.merchant_id AND p.receipt_number = r.receipt_number
+            ) AS PaymentNames,
             CASE 
                 WHEN r.cancelled_at IS NOT NULL THEN 'ยกเลิก' 
+                WHEN r.receipt_type = 'REFUND' THEN 'คืนเงิน' 
                 ELSE 'ขาย' 
             END AS Status,
-            jsonb_agg(li) AS LineItems
+            COALESCE((
+                SELECT jsonb_agg(jsonb_build_object(
+                    'id', l.line_item_id,
+                    'item_id', l.item_id,
+                    'variant_id', l.variant_id,
+                    'item_name', l.item_name,
+                    'variant_name', l.variant_name,
+                    'sku', l.sku,
+                    'quantity', l.quantity,
+                    'price', l.price,
+                    'gross_total_money', l.gross_total_money,
+                    'total_money', l.total_money,
+                    'cost', l.cost,
+                    'cost_total', l.cost_total,
+                    'line_note', l.line_note,
+                    'line_taxes', COALESCE((
+                        SELECT jsonb_agg(jsonb_build_object(
+                            'id', t.tax_id, 'type', t.type, 'name', t.name, 'rate', t.rate, 'money_amount', t.money_amount
+                        ) ORDER BY t.position)
+                        FROM receipt_line_taxes t
+                        WHERE t.merchant_id = l.merchant_id AND t.receipt_number = l.receipt_number AND t.line_no = l.line_no
+                    ), '[]'),
+                    'total_discount', l.total_discount,
+                    'line_discounts', COALESCE((
+                        SELECT jsonb_agg(jsonb_build_object(
+                            'id', d.discount_id, 'type', d.type, 'name', d.name, 'percentage', d.percentage, 'money_amount', d.money_amount
+                        ) ORDER BY d.position)
+                        FROM receipt_line_discounts d
+                        WHERE d.merchant_id = l.merchant_id AND d.receipt_number = l.receipt_number AND d.line_no = l.line_no
+                    ), '[]'),
+                    'line_modifiers', COALESCE((
+                        SELECT jsonb_agg(jsonb_build_object(
+                            'id', m.modifier_id, 'modifier_option_id', m.modifier_option_id, 'name', m.name,
+                            'option', m.option, 'price', m.price, 'money_amount', m.money_amount
+                        ) ORDER BY m.position)
+                        FROM receipt_line_modifiers m
+                        WHERE m.merchant_id = l.merchant_id AND m.receipt_number = l.receipt_number AND m.line_no = l.line_no
+                    ), '[]')
+                ) ORDER BY l.line_no)
+                FROM receipt_line_items l
+                WHERE l.merchant_id = r.merchant_id AND l.receipt_number = r.receipt_number
+            ), '[]') AS LineItems
         FROM 
             loyreceipts r
         JOIN 
             loystores s ON r.store_id = s.store_id
         LEFT JOIN 
-            jsonb_array_elements(r.line_items) AS li ON TRUE
+            loycustomers cu ON r.customer_id = cu.customer_id
         LEFT JOIN 
-            jsonb_array_elements(r.payments) AS p ON TRUE
+            loyemployees e ON r.employee_id = e.employee_id
         LEFT JOIN 
-            loypaymenttypes pt ON (p->>'payment_type_id') = pt.payment_type_id
-        GROUP BY 
-            r.receipt_date, r.receipt_number, r.total_money, r.total_discount, s.store_name, r.cancelled_at
+            loyposdevices d ON r.pos_device_id = d.pos_device_id
+        WHERE 
+            r.merchant_id = $1
+            AND ($2 OR s.deleted_at IS NULL)
         ORDER BY 
             r.receipt_date DESC, r.receipt_number;
     `
 
-	rows, err := repo.db.Query(query)
+	rows, err := repo.db.Query(query, merchantID, includeDeleted)
 	if err != nil {
 		return nil, err
 	}
@@ -57,17 +114,25 @@ func (repo *ReceiptRepository) FetchReceiptsWithDetails() ([]models.Receipt, err
 
 	for rows.Next() {
 		var receipt models.Receipt
-		var paymentNames []string // ใช้สำหรับ array ของ payment names
+		var paymentNames []string // ใช้สำหรับ array ของ payment names (NULL เมื่อไม่มีการชำระเงิน)
 		var lineItemsData []byte  // ใช้สำหรับ JSON ของ line items
 		var status string         // สถานะการขาย เช่น "ขาย" หรือ "ยกเลิก"
+		var customerID sql.NullString
 
 		// ใช้ `pq.Array(&paymentNames)` เพื่ออ่าน array ของ payment names
 		err := rows.Scan(
 			&receipt.ReceiptDate,
 			&receipt.ReceiptNumber,
+			&receipt.ReceiptType,
+			&receipt.RefundFor,
 			&receipt.TotalMoney,
 			&receipt.TotalDiscount,
 			&receipt.StoreName,
+			&customerID,
+			&receipt.CustomerName,
+			&receipt.PosDeviceId,
+			&receipt.EmployeeName,
+			&receipt.DeviceName,
 			pq.Array(&paymentNames), // ใช้ `pq.Array` เพื่ออ่าน array ของ payment names
 			&status,                 // สถานะการขาย
 			&lineItemsData,          // JSON ของ LineItems
@@ -94,6 +159,7 @@ func (repo *ReceiptRepository) FetchReceiptsWithDetails() ([]models.Receipt, err
 		receipt.LineItemsSummary = lineItemsSummary
 
 		// ตั้งค่าฟิลด์ PaymentNames และ Status
+		receipt.CustomerID = customerID.String
 		receipt.PaymentNames = paymentNames
 		receipt.Status = status
 
@@ -104,44 +170,55 @@ func (repo *ReceiptRepository) FetchReceiptsWithDetails() ([]models.Receipt, err
 	return receipts, nil
 }
 
-func (repo *ReceiptRepository) FetchSalesByItem() ([]models.SaleItem, error) {
+// FetchSalesByItem สรุปยอดขายรายสินค้าแยกตาม variant (ไม่รวมสินค้าและสาขาที่ถูกลบ เว้นแต่ includeDeleted เป็น true)
+// ใบคืนเงินแสดงเป็นแถวของตัวเองโดยมีจำนวนและยอดเงินติดลบ เพื่อให้ผลรวมเป็นยอดขายสุทธิ
+func (repo *ReceiptRepository) FetchSalesByItem(merchantID string, includeDeleted bool) ([]models.SaleItem, error) {
 	var salesByItem []models.SaleItem
 	query := `SELECT 
-        r.receipt_date AS ReceiptDate,
-        li->>'item_name' AS ItemName,
-        SUM((li->>'quantity')::numeric) AS Quantity,
-        SUM((li->>'price')::numeric * (li->>'quantity')::numeric) AS TotalSales,
-        SUM((li->>'cost')::numeric * (li->>'quantity')::numeric) AS TotalCost,
-        r.total_discount AS TotalDiscount,
-        pt.name AS PaymentName,
+        l.receipt_date AS ReceiptDate,
+        l.item_name AS ItemName,
+        COALESCE(l.variant_id, '') AS VariantID,
+        COALESCE(MAX(l.variant_name), '') AS VariantName,
+        COALESCE(MAX(l.sku), '') AS SKU,
+        SUM(l.quantity) * (CASE WHEN r.receipt_type = 'REFUND' THEN -1 ELSE 1 END) AS Quantity,
+        SUM(l.price * l.quantity) * (CASE WHEN r.receipt_type = 'REFUND' THEN -1 ELSE 1 END) AS TotalSales,
+        SUM(l.cost * l.quantity) * (CASE WHEN r.receipt_type = 'REFUND' THEN -1 ELSE 1 END) AS TotalCost,
+        r.total_discount * (CASE WHEN r.receipt_type = 'REFUND' THEN -1 ELSE 1 END) AS TotalDiscount,
+        COALESCE((
+            SELECT string_agg(DISTINCT COALESCE(pt.name, p.name), ', ')
+            FROM receipt_payments p
+            LEFT JOIN loypaymenttypes pt ON p.payment_type_id = pt.payment_type_id
+            WHERE p.merchant_id = r.merchant_id AND p.receipt_number = r.receipt_number
+        ), '') AS PaymentName,
         CASE 
             WHEN r.cancelled_at IS NOT NULL THEN 'ยกเลิก' 
+            WHEN r.receipt_type = 'REFUND' THEN 'คืนเงิน' 
             ELSE 'ขาย' 
         END AS Status,
-        c.category_name AS CategoryName,
+        COALESCE(c.name, '') AS CategoryName,
         s.store_name AS StoreName,
-        r.receipt_number AS ReceiptNumber
+        l.receipt_number AS ReceiptNumber
     FROM 
-        loyreceipts r
+        receipt_line_items l
     JOIN 
-        loystores s ON r.store_id = s.store_id
-    LEFT JOIN 
-        jsonb_array_elements(r.line_items) AS li ON TRUE
-    LEFT JOIN 
-        categories c ON (li->>'category_id') = c.category_id
+        loyreceipts r ON r.merchant_id = l.merchant_id AND r.receipt_number = l.receipt_number
+    JOIN 
+        loystores s ON l.store_id = s.store_id
     LEFT JOIN 
-        jsonb_array_elements(r.payments) AS p ON TRUE
+        loyitems i ON l.item_id = i.item_id
     LEFT JOIN 
-        loypaymenttypes pt ON (p->>'payment_type_id') = pt.payment_type_id
+        loycategories c ON i.category_id = c.category_id
     WHERE 
-        r.cancelled_at IS NULL
+        l.merchant_id = $1 AND r.cancelled_at IS NULL
+        AND ($2 OR s.deleted_at IS NULL)
+        AND ($2 OR i.deleted_at IS NULL)
     GROUP BY 
-        ReceiptDate, ItemName, PaymentName, CategoryName, StoreName, ReceiptNumber, Status
+        l.receipt_date, l.item_name, l.variant_id, r.merchant_id, r.receipt_number, r.receipt_type, r.total_discount, r.cancelled_at, c.name, s.store_name, l.receipt_number
     ORDER BY 
         ReceiptDate DESC, ItemName;
     `
 
-	rows, err := repo.db.Query(query)
+	rows, err := repo.db.Query(query, merchantID, includeDeleted)
 	if err != nil {
 		return nil, err
 	}
@@ -149,7 +226,7 @@ func (repo *ReceiptRepository) FetchSalesByItem() ([]models.SaleItem, error) {
 
 	for rows.Next() {
 		var saleItem models.SaleItem
-		err := rows.Scan(&saleItem.ReceiptDate, &saleItem.ItemName, &saleItem.Quantity, &saleItem.TotalSales, &saleItem.TotalCost, &saleItem.TotalDiscount, &saleItem.PaymentName, &saleItem.Status, &saleItem.CategoryName, &saleItem.StoreName, &saleItem.ReceiptNumber)
+		err := rows.Scan(&saleItem.ReceiptDate, &saleItem.ItemName, &saleItem.VariantID, &saleItem.VariantName, &saleItem.SKU, &saleItem.Quantity, &saleItem.TotalSales, &saleItem.TotalCost, &saleItem.TotalDiscount, &saleItem.PaymentName, &saleItem.Status, &saleItem.CategoryName, &saleItem.StoreName, &saleItem.ReceiptNumber)
 		if err != nil {
 			return nil, err
 		}
@@ -158,27 +235,37 @@ func (repo *ReceiptRepository) FetchSalesByItem() ([]models.SaleItem, error) {
 	return salesByItem, nil
 }
 
-func (repo *ReceiptRepository) FetchSalesByDay() ([]models.SalesByDay, error) {
+// FetchSalesByDay สรุปยอดขายสุทธิรายวันแยกตาม variant (หักรายการในใบคืนเงินแล้ว ไม่รวมสินค้าและสาขาที่ถูกลบ เว้นแต่ includeDeleted เป็น true)
+func (repo *ReceiptRepository) FetchSalesByDay(merchantID string, includeDeleted bool) ([]models.SalesByDay, error) {
 	var salesByDay []models.SalesByDay
 	query := `SELECT 
-        DATE(r.receipt_date) AS SaleDate,
-        li->>'item_name' AS ItemName,
-        SUM((li->>'quantity')::numeric) AS TotalQuantity,
-        SUM((li->>'price')::numeric * (li->>'quantity')::numeric) AS TotalSales,
-        SUM((li->>'quantity')::numeric * ((li->>'price')::numeric - (li->>'cost')::numeric)) AS TotalProfit
+        DATE(l.receipt_date) AS SaleDate,
+        l.item_name AS ItemName,
+        COALESCE(l.variant_id, '') AS VariantID,
+        COALESCE(MAX(l.variant_name), '') AS VariantName,
+        COALESCE(MAX(l.sku), '') AS SKU,
+        SUM(CASE WHEN r.receipt_type = 'REFUND' THEN -l.quantity ELSE l.quantity END) AS TotalQuantity,
+        SUM(CASE WHEN r.receipt_type = 'REFUND' THEN -1 ELSE 1 END * l.price * l.quantity) AS TotalSales,
+        SUM(CASE WHEN r.receipt_type = 'REFUND' THEN -1 ELSE 1 END * l.quantity * (l.price - l.cost)) AS TotalProfit
     FROM 
-        loyreceipts r
+        receipt_line_items l
+    JOIN 
+        loyreceipts r ON r.merchant_id = l.merchant_id AND r.receipt_number = l.receipt_number
+    JOIN 
+        loystores s ON l.store_id = s.store_id
     LEFT JOIN 
-        jsonb_array_elements(r.line_items) AS li ON TRUE
+        loyitems i ON l.item_id = i.item_id
     WHERE 
-        r.cancelled_at IS NULL
+        l.merchant_id = $1 AND r.cancelled_at IS NULL
+        AND ($2 OR s.deleted_at IS NULL)
+        AND ($2 OR i.deleted_at IS NULL)
     GROUP BY 
-        SaleDate, ItemName
+        SaleDate, ItemName, l.variant_id
     ORDER BY 
-        SaleDate, ItemName;
+        SaleDate, ItemName, VariantName;
     `
 
-	rows, err := repo.db.Query(query)
+	rows, err := repo.db.Query(query, merchantID, includeDeleted)
 	if err != nil {
 		return nil, err
 	}
@@ -186,7 +273,7 @@ func (repo *ReceiptRepository) FetchSalesByDay() ([]models.SalesByDay, error) {
 
 	for rows.Next() {
 		var saleByDay models.SalesByDay
-		err := rows.Scan(&saleByDay.SaleDate, &saleByDay.ItemName, &saleByDay.TotalQuantity, &saleByDay.TotalSales, &saleByDay.TotalProfit)
+		err := rows.Scan(&saleByDay.SaleDate, &saleByDay.ItemName, &saleByDay.VariantID, &saleByDay.VariantName, &saleByDay.SKU, &saleByDay.TotalQuantity, &saleByDay.TotalSales, &saleByDay.TotalProfit)
 		if err != nil {
 			return nil, err
 		}
@@ -194,3 +281,160 @@ func (repo *ReceiptRepository) FetchSalesByDay() ([]models.SalesByDay, error) {
 	}
 	return salesByDay, nil
 }
+
+// FetchRefunds สรุปรายการคืนเงินตามสาขา สินค้า และเหตุผล (หมายเหตุของใบคืนเงิน)
+// พร้อมเลขที่ใบขายเดิมที่ถูกคืน ไม่รวมใบคืนเงินที่ถูกยกเลิก
+func (repo *ReceiptRepository) FetchRefunds(merchantID string, includeDeleted bool) ([]models.RefundSummary, error) {
+	refunds := []models.RefundSummary{}
+	query := `SELECT 
+        s.store_name AS StoreName,
+        l.item_name AS ItemName,
+        COALESCE(NULLIF(r.note, ''), 'ไม่ระบุ') AS Reason,
+        COUNT(DISTINCT r.receipt_number) AS RefundCount,
+        SUM(l.quantity) AS Quantity,
+        SUM(l.price * l.quantity) AS TotalRefunded,
+        SUM(l.cost * l.quantity) AS TotalCost,
+        array_agg(DISTINCT r.refund_for) FILTER (WHERE r.refund_for IS NOT NULL) AS OriginalReceipts,
+        MAX(r.receipt_date) AS LastRefundDate
+    FROM 
+        receipt_line_items l
+    JOIN 
+        loyreceipts r ON r.merchant_id = l.merchant_id AND r.receipt_number = l.receipt_number
+    JOIN 
+        loystores s ON l.store_id = s.store_id
+    LEFT JOIN 
+        loyitems i ON l.item_id = i.item_id
+    WHERE 
+        l.merchant_id = $1 AND r.receipt_type = 'REFUND' AND r.cancelled_at IS NULL
+        AND ($2 OR s.deleted_at IS NULL)
+        AND ($2 OR i.deleted_at IS NULL)
+    GROUP BY 
+        StoreName, ItemName, Reason
+    ORDER BY 
+        TotalRefunded DESC, StoreName, ItemName;
+    `
+
+	rows, err := repo.db.Query(query, merchantID, includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var refund models.RefundSummary
+		err := rows.Scan(&refund.StoreName, &refund.ItemName, &refund.Reason, &refund.RefundCount, &refund.Quantity, &refund.TotalRefunded, &refund.TotalCost, pq.Array(&refund.OriginalReceipts), &refund.LastRefundDate)
+		if err != nil {
+			return nil, err
+		}
+		refunds = append(refunds, refund)
+	}
+	return refunds, rows.Err()
+}
+
+// FetchDiscountUsage สรุปการใช้ส่วนลดแต่ละรายการตามสาขา จาก receipt_line_discounts
+// ส่วนลดในใบคืนเงินถูกหักออก ชื่อและประเภทใช้ค่าล่าสุดจาก loydiscounts ถ้ามี
+func (repo *ReceiptRepository) FetchDiscountUsage(merchantID string, includeDeleted bool) ([]models.DiscountUsage, error) {
+	usage := []models.DiscountUsage{}
+	query := `SELECT 
+        COALESCE(d.discount_id, '') AS DiscountID,
+        COALESCE(dc.name, d.name) AS DiscountName,
+        COALESCE(dc.type, d.type) AS DiscountType,
+        s.store_name AS StoreName,
+        COUNT(DISTINCT r.receipt_number) FILTER (WHERE r.receipt_type <> 'REFUND') AS ReceiptCount,
+        SUM(CASE WHEN r.receipt_type = 'REFUND' THEN -1 ELSE 1 END) AS LineCount,
+        SUM(CASE WHEN r.receipt_type = 'REFUND' THEN -1 ELSE 1 END * d.money_amount) AS TotalDiscount,
+        SUM(CASE WHEN r.receipt_type = 'REFUND' THEN -1 ELSE 1 END * l.gross_total_money) AS GrossSales,
+        MAX(r.receipt_date) AS LastUsed
+    FROM 
+        receipt_line_discounts d
+    JOIN 
+        receipt_line_items l ON l.merchant_id = d.merchant_id AND l.receipt_number = d.receipt_number AND l.line_no = d.line_no
+    JOIN 
+        loyreceipts r ON r.merchant_id = l.merchant_id AND r.receipt_number = l.receipt_number
+    JOIN 
+        loystores s ON l.store_id = s.store_id
+    LEFT JOIN 
+        loyitems i ON l.item_id = i.item_id
+    LEFT JOIN 
+        loydiscounts dc ON d.discount_id = dc.discount_id
+    WHERE 
+        d.merchant_id = $1 AND r.cancelled_at IS NULL
+        AND ($2 OR s.deleted_at IS NULL)
+        AND ($2 OR i.deleted_at IS NULL)
+        AND ($2 OR dc.deleted_at IS NULL)
+    GROUP BY 
+        DiscountID, DiscountName, DiscountType, StoreName
+    ORDER BY 
+        TotalDiscount DESC, DiscountName, StoreName;
+    `
+
+	rows, err := repo.db.Query(query, merchantID, includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var u models.DiscountUsage
+		err := rows.Scan(&u.DiscountID, &u.DiscountName, &u.DiscountType, &u.StoreName, &u.ReceiptCount, &u.LineCount, &u.TotalDiscount, &u.GrossSales, &u.LastUsed)
+		if err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
+
+// FetchTaxSummary สรุปภาษีรายเดือนตามภาษีและสาขา จาก receipt_line_taxes
+// ภาษีในใบคืนเงินถูกหักออก อัตราเป็นค่า ณ เวลาที่ขาย จึงแยกแถวถ้าอัตราเปลี่ยนกลางเดือน
+func (repo *ReceiptRepository) FetchTaxSummary(merchantID string, includeDeleted bool) ([]models.TaxSummary, error) {
+	summary := []models.TaxSummary{}
+	query := `SELECT 
+        to_char(l.receipt_date, 'YYYY-MM') AS Month,
+        COALESCE(t.tax_id, '') AS TaxID,
+        COALESCE(tc.name, t.name) AS TaxName,
+        t.type AS TaxType,
+        t.rate AS Rate,
+        s.store_name AS StoreName,
+        COUNT(DISTINCT r.receipt_number) FILTER (WHERE r.receipt_type <> 'REFUND') AS ReceiptCount,
+        SUM(CASE WHEN r.receipt_type = 'REFUND' THEN -1 ELSE 1 END * l.total_money) AS TaxableSales,
+        SUM(CASE WHEN r.receipt_type = 'REFUND' THEN -1 ELSE 1 END * t.money_amount) AS TotalTax
+    FROM 
+        receipt_line_taxes t
+    JOIN 
+        receipt_line_items l ON l.merchant_id = t.merchant_id AND l.receipt_number = t.receipt_number AND l.line_no = t.line_no
+    JOIN 
+        loyreceipts r ON r.merchant_id = l.merchant_id AND r.receipt_number = l.receipt_number
+    JOIN 
+        loystores s ON l.store_id = s.store_id
+    LEFT JOIN 
+        loyitems i ON l.item_id = i.item_id
+    LEFT JOIN 
+        loytaxes tc ON t.tax_id = tc.tax_id
+    WHERE 
+        t.merchant_id = $1 AND r.cancelled_at IS NULL
+        AND ($2 OR s.deleted_at IS NULL)
+        AND ($2 OR i.deleted_at IS NULL)
+        AND ($2 OR tc.deleted_at IS NULL)
+    GROUP BY 
+        to_char(l.receipt_date, 'YYYY-MM'), COALESCE(t.tax_id, ''), COALESCE(tc.name, t.name), t.type, t.rate, s.store_name
+    ORDER BY 
+        Month DESC, TaxName, StoreName;
+    `
+
+	rows, err := repo.db.Query(query, merchantID, includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tax models.TaxSummary
+		err := rows.Scan(&tax.Month, &tax.TaxID, &tax.TaxName, &tax.TaxType, &tax.Rate, &tax.StoreName, &tax.ReceiptCount, &tax.TaxableSales, &tax.TotalTax)
+		if err != nil {
+			return nil, err
+		}
+		summary = append(summary, tax)
+	}
+	return summary, rows.Err()
+}
@@ -0,0 +1,190 @@
+// SaleManagement/infrastructure/data/shift_data.go
+package data
+
+import (
+	"backend/internal/SaleManagement/domain/models"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+type ShiftRepository struct {
+	db *sql.DB
+}
+
+func NewShiftRepository(db *sql.DB) *ShiftRepository {
+	return &ShiftRepository{db: db}
+}
+
+// FetchSalesByEmployee สรุปยอดขายตามพนักงานที่ทำรายการ (employee_id ในใบเสร็จ)
+// ใบเสร็จที่ไม่มีพนักงานรวมอยู่ในแถว "ไม่ระบุ" พนักงานและสาขาที่ถูกลบจะไม่แสดง เว้นแต่ includeDeleted เป็น true
+func (repo *ShiftRepository) FetchSalesByEmployee(merchantID string, includeDeleted bool) ([]models.EmployeeSales, error) {
+	sales := []models.EmployeeSales{}
+	query := `SELECT 
+        COALESCE(r.employee_id, '') AS EmployeeID,
+        COALESCE(MAX(e.name), 'ไม่ระบุ') AS EmployeeName,
+        COUNT(*) FILTER (WHERE r.receipt_type <> 'REFUND') AS ReceiptCount,
+        COUNT(*) FILTER (WHERE r.receipt_type = 'REFUND') AS RefundCount,
+        COALESCE(SUM(r.total_money) FILTER (WHERE r.receipt_type <> 'REFUND'), 0) AS GrossSales,
+        COALESCE(SUM(r.total_money) FILTER (WHERE r.receipt_type = 'REFUND'), 0) AS Refunds,
+        SUM(CASE WHEN r.receipt_type = 'REFUND' THEN -1 ELSE 1 END * r.total_money) AS NetSales,
+        SUM(CASE WHEN r.receipt_type = 'REFUND' THEN -1 ELSE 1 END * r.total_discount) AS TotalDiscount
+    FROM 
+        loyreceipts r
+    JOIN 
+        loystores s ON r.store_id = s.store_id
+    LEFT JOIN 
+        loyemployees e ON r.employee_id = e.employee_id
+    WHERE 
+        r.merchant_id = $1 AND r.cancelled_at IS NULL
+        AND ($2 OR s.deleted_at IS NULL)
+        AND ($2 OR e.deleted_at IS NULL)
+    GROUP BY 
+        EmployeeID
+    ORDER BY 
+        NetSales DESC, EmployeeName;
+    `
+
+	rows, err := repo.db.Query(query, merchantID, includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s models.EmployeeSales
+		err := rows.Scan(&s.EmployeeID, &s.EmployeeName, &s.ReceiptCount, &s.RefundCount, &s.GrossSales, &s.Refunds, &s.NetSales, &s.TotalDiscount)
+		if err != nil {
+			return nil, err
+		}
+		if s.ReceiptCount > 0 {
+			s.AverageSale = s.GrossSales.Div(int64(s.ReceiptCount)) // ปัดเป็นสตางค์ตาม money.Money.Div
+		}
+		sales = append(sales, s)
+	}
+	return sales, rows.Err()
+}
+
+// FetchSalesByDevice สรุปยอดขายตามเครื่อง POS (pos_device_id ในใบเสร็จ)
+func (repo *ShiftRepository) FetchSalesByDevice(merchantID string, includeDeleted bool) ([]models.DeviceSales, error) {
+	sales := []models.DeviceSales{}
+	query := `SELECT 
+        COALESCE(r.pos_device_id, '') AS PosDeviceID,
+        COALESCE(MAX(d.name), 'ไม่ระบุ') AS DeviceName,
+        s.store_name AS StoreName,
+        COUNT(*) FILTER (WHERE r.receipt_type <> 'REFUND') AS ReceiptCount,
+        COUNT(*) FILTER (WHERE r.receipt_type = 'REFUND') AS RefundCount,
+        COALESCE(SUM(r.total_money) FILTER (WHERE r.receipt_type <> 'REFUND'), 0) AS GrossSales,
+        COALESCE(SUM(r.total_money) FILTER (WHERE r.receipt_type = 'REFUND'), 0) AS Refunds,
+        SUM(CASE WHEN r.receipt_type = 'REFUND' THEN -1 ELSE 1 END * r.total_money) AS NetSales,
+        SUM(CASE WHEN r.receipt_type = 'REFUND' THEN -1 ELSE 1 END * r.total_discount) AS TotalDiscount
+    FROM 
+        loyreceipts r
+    JOIN 
+        loystores s ON r.store_id = s.store_id
+    LEFT JOIN 
+        loyposdevices d ON r.pos_device_id = d.pos_device_id
+    WHERE 
+        r.merchant_id = $1 AND r.cancelled_at IS NULL
+        AND ($2 OR s.deleted_at IS NULL)
+        AND ($2 OR d.deleted_at IS NULL)
+    GROUP BY 
+        PosDeviceID, StoreName
+    ORDER BY 
+        StoreName, DeviceName;
+    `
+
+	rows, err := repo.db.Query(query, merchantID, includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s models.DeviceSales
+		err := rows.Scan(&s.PosDeviceID, &s.DeviceName, &s.StoreName, &s.ReceiptCount, &s.RefundCount, &s.GrossSales, &s.Refunds, &s.NetSales, &s.TotalDiscount)
+		if err != nil {
+			return nil, err
+		}
+		if s.ReceiptCount > 0 {
+			s.AverageSale = s.GrossSales.Div(int64(s.ReceiptCount)) // ปัดเป็นสตางค์ตาม money.Money.Div
+		}
+		sales = append(sales, s)
+	}
+	return sales, rows.Err()
+}
+
+// FetchShiftCashVariance แสดงเงินสดที่ควรมีเทียบกับที่นับได้ของทุกกะ (กะล่าสุดก่อน)
+// พร้อมรายการนำเงินเข้า/ออกระหว่างกะ
+func (repo *ShiftRepository) FetchShiftCashVariance(merchantID string, includeDeleted bool) ([]models.ShiftCashVariance, error) {
+	shifts := []models.ShiftCashVariance{}
+	query := `SELECT 
+        sh.shift_id AS ShiftID,
+        COALESCE(s.store_name, '') AS StoreName,
+        COALESCE(d.name, sh.pos_device_id, '') AS DeviceName,
+        sh.opened_at AS OpenedAt,
+        sh.closed_at AS ClosedAt,
+        COALESCE(eo.name, sh.opened_by_employee) AS OpenedBy,
+        COALESCE(ec.name, sh.closed_by_employee) AS ClosedBy,
+        sh.starting_cash AS StartingCash,
+        sh.cash_payments AS CashPayments,
+        sh.cash_refunds AS CashRefunds,
+        sh.paid_in AS PaidIn,
+        sh.paid_out AS PaidOut,
+        sh.expected_cash AS ExpectedCash,
+        sh.actual_cash AS ActualCash,
+        sh.actual_cash - sh.expected_cash AS Variance,
+        sh.net_sales AS NetSales,
+        COALESCE((
+            SELECT jsonb_agg(jsonb_build_object(
+                'type', m.type,
+                'money_amount', m.money_amount,
+                'comment', m.comment,
+                'employee_name', COALESCE(me.name, m.employee_id),
+                'created_at', m.created_at
+            ) ORDER BY m.position)
+            FROM loyshift_cash_movements m
+            LEFT JOIN loyemployees me ON m.employee_id = me.employee_id
+            WHERE m.shift_id = sh.shift_id
+        ), '[]') AS CashMovements
+    FROM 
+        loyshifts sh
+    LEFT JOIN 
+        loystores s ON sh.store_id = s.store_id
+    LEFT JOIN 
+        loyposdevices d ON sh.pos_device_id = d.pos_device_id
+    LEFT JOIN 
+        loyemployees eo ON sh.opened_by_employee = eo.employee_id
+    LEFT JOIN 
+        loyemployees ec ON sh.closed_by_employee = ec.employee_id
+    WHERE 
+        sh.merchant_id = $1
+        AND ($2 OR s.deleted_at IS NULL)
+    ORDER BY 
+        sh.opened_at DESC;
+    `
+
+	rows, err := repo.db.Query(query, merchantID, includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var shift models.ShiftCashVariance
+		var movements []byte
+		err := rows.Scan(
+			&shift.ShiftID, &shift.StoreName, &shift.DeviceName, &shift.OpenedAt, &shift.ClosedAt, &shift.OpenedBy, &shift.ClosedBy,
+			&shift.StartingCash, &shift.CashPayments, &shift.CashRefunds, &shift.PaidIn, &shift.PaidOut,
+			&shift.ExpectedCash, &shift.ActualCash, &shift.Variance, &shift.NetSales, &movements,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(movements, &shift.CashMovements); err != nil {
+			return nil, fmt.Errorf("error unmarshalling cash movements: %w", err)
+		}
+		shifts = append(shifts, shift)
+	}
+	return shifts, rows.Err()
+}
@@ -15,7 +15,25 @@ func RegisterSaleRoutes(mux *http.ServeMux, db *sql.DB) {
 	receiptService := services.NewReceiptService(receiptRepo)
 	receiptHandler := handlers.NewReceiptHandler(receiptService)
 
-	mux.HandleFunc("/api/receipts", receiptHandler.ListReceipts)       // ลิสใบเสร็จ
-	mux.HandleFunc("/api/sales/items", receiptHandler.ListSalesByItem) // รายการขายตามสินค้า
-	mux.HandleFunc("/api/sales/days", receiptHandler.ListSalesByDay)   // จำนวนขายตามวัน
+	mux.HandleFunc("/api/receipts", receiptHandler.ListReceipts)             // ลิสใบเสร็จ
+	mux.HandleFunc("/api/sales/items", receiptHandler.ListSalesByItem)       // รายการขายตามสินค้า
+	mux.HandleFunc("/api/sales/days", receiptHandler.ListSalesByDay)         // จำนวนขายตามวัน
+	mux.HandleFunc("/api/sales/refunds", receiptHandler.ListRefunds)         // ยอดคืนเงินตามสาขา สินค้า และเหตุผล
+	mux.HandleFunc("/api/sales/discounts", receiptHandler.ListDiscountUsage) // การใช้ส่วนลดตามสาขา
+	mux.HandleFunc("/api/sales/taxes", receiptHandler.ListTaxSummary)        // ยอดภาษีรายเดือน
+
+	shiftRepo := data.NewShiftRepository(db)
+	shiftService := services.NewShiftService(shiftRepo)
+	shiftHandler := handlers.NewShiftHandler(shiftService)
+
+	mux.HandleFunc("/api/sales/employees", shiftHandler.ListSalesByEmployee)        // ยอดขายตามพนักงาน
+	mux.HandleFunc("/api/sales/devices", shiftHandler.ListSalesByDevice)            // ยอดขายตามเครื่อง POS
+	mux.HandleFunc("/api/shifts/cash-variance", shiftHandler.ListShiftCashVariance) // ส่วนต่างเงินสดรายกะ
+
+	customerRepo := data.NewCustomerRepository(db)
+	customerService := services.NewCustomerService(customerRepo)
+	customerHandler := handlers.NewCustomerHandler(customerService)
+
+	mux.HandleFunc("/api/customers", customerHandler.ListCustomers)                 // ลิสลูกค้าสำหรับหน้า CRM
+	mux.HandleFunc("/api/customers/receipts", customerHandler.ListCustomerReceipts) // ใบเสร็จของลูกค้า
 }
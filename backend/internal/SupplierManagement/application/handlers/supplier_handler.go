@@ -8,6 +8,7 @@ import (
 	"backend/internal/SupplierManagement/application/services"
 	"backend/internal/SupplierManagement/domain/models"
 	"log"
+	"strconv"
 )
 
 type SupplierHandler struct {
@@ -18,8 +19,22 @@ func NewSupplierHandler(service *services.SupplierService) *SupplierHandler {
 	return &SupplierHandler{service: service}
 }
 
+// merchantIDParam อ่าน merchant จาก ?merchant_id (ไม่ระบุ = models.DefaultMerchantID)
+func merchantIDParam(r *http.Request) string {
+	if merchantID := r.URL.Query().Get("merchant_id"); merchantID != "" {
+		return merchantID
+	}
+	return models.DefaultMerchantID
+}
+
+// includeDeletedParam อ่าน ?include_deleted=true สำหรับรายงานย้อนหลังที่ต้องเห็นซัพพลายเออร์ที่ถูกลบแล้ว
+func includeDeletedParam(r *http.Request) bool {
+	includeDeleted, _ := strconv.ParseBool(r.URL.Query().Get("include_deleted"))
+	return includeDeleted
+}
+
 func (h *SupplierHandler) GetSuppliers(w http.ResponseWriter, r *http.Request) {
-	suppliers, err := h.service.GetAllSuppliers()
+	suppliers, err := h.service.GetAllSuppliers(merchantIDParam(r), includeDeletedParam(r))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -35,7 +50,7 @@ func (h *SupplierHandler) SaveSupplierSettings(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	if err := h.service.SaveSupplierSettings(suppliersInput); err != nil {
+	if err := h.service.SaveSupplierSettings(merchantIDParam(r), suppliersInput); err != nil {
 		log.Println("Error saving supplier settings:", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -16,11 +16,11 @@ func NewSupplierService(repo interfaces.SupplierRepository) *SupplierService {
 	return &SupplierService{repo: repo}
 }
 
-func (s *SupplierService) GetAllSuppliers() ([]models.Supplier, error) {
-	return s.repo.GetSuppliers()
+func (s *SupplierService) GetAllSuppliers(merchantID string, includeDeleted bool) ([]models.Supplier, error) {
+	return s.repo.GetSuppliers(merchantID, includeDeleted)
 }
 
-func (s *SupplierService) SaveSupplierSettings(suppliers []models.SupplierInput) error {
+func (s *SupplierService) SaveSupplierSettings(merchantID string, suppliers []models.SupplierInput) error {
 	var updatedSuppliers []models.Supplier
 	for _, input := range suppliers {
 		orderCycle := sql.NullString{String: input.OrderCycle, Valid: input.OrderCycle != ""}
@@ -35,5 +35,5 @@ func (s *SupplierService) SaveSupplierSettings(suppliers []models.SupplierInput)
 		}
 		updatedSuppliers = append(updatedSuppliers, supplier)
 	}
-	return s.repo.SaveSupplierSettings(updatedSuppliers)
+	return s.repo.SaveSupplierSettings(merchantID, updatedSuppliers)
 }
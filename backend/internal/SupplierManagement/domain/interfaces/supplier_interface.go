@@ -4,7 +4,7 @@ import "backend/internal/SupplierManagement/domain/models"
 
 // SupplierRepository interface
 type SupplierRepository interface {
-	GetSuppliers() ([]models.Supplier, error)
-	SaveSupplierSettings(suppliers []models.Supplier) error
-	FetchSupplierCycles() ([]models.Supplier, error) // ตรวจสอบให้แน่ใจว่ามี method นี้
+	GetSuppliers(merchantID string, includeDeleted bool) ([]models.Supplier, error)
+	SaveSupplierSettings(merchantID string, suppliers []models.Supplier) error
+	FetchSupplierCycles(merchantID string) ([]models.Supplier, error) // ตรวจสอบให้แน่ใจว่ามี method นี้
 }
@@ -0,0 +1,5 @@
+package models
+
+// DefaultMerchantID คือ merchant ที่ใช้เมื่อ request ไม่ได้ระบุ ?merchant_id
+// (ตรงกับ merchant default ของ loyverse connector)
+const DefaultMerchantID = "default"
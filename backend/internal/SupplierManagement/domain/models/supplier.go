@@ -1,6 +1,9 @@
 package models
 
-import "database/sql"
+import (
+	"database/sql"
+	"time"
+)
 
 // Supplier represents the main structure for storing supplier information.
 type Supplier struct {
@@ -9,6 +12,7 @@ type Supplier struct {
 	OrderCycle   sql.NullString `json:"order_cycle"`
 	SelectedDays sql.NullString `json:"selected_days"`
 	SortOrder    int            `json:"sort_order"`
+	DeletedAt    *time.Time     `json:"deleted_at,omitempty"` // set when the supplier was deleted in Loyverse
 }
 
 // SupplierInput represents the structure for receiving supplier input data.
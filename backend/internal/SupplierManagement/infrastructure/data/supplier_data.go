@@ -15,10 +15,11 @@ func NewSupplierRepository(db *sql.DB) *SupplierRepository {
 	return &SupplierRepository{db: db}
 }
 
-func (repo *SupplierRepository) GetSuppliers() ([]models.Supplier, error) {
+// GetSuppliers returns the suppliers of a merchant; suppliers deleted in Loyverse are only included when includeDeleted is set.
+func (repo *SupplierRepository) GetSuppliers(merchantID string, includeDeleted bool) ([]models.Supplier, error) {
 	var suppliers []models.Supplier
 
-	rows, err := repo.db.Query(`SELECT supplier_id, supplier_name, order_cycle, selected_days, sort_order FROM loysuppliers`)
+	rows, err := repo.db.Query(`SELECT supplier_id, supplier_name, order_cycle, selected_days, sort_order, deleted_at FROM loysuppliers WHERE merchant_id = $1 AND ($2 OR deleted_at IS NULL)`, merchantID, includeDeleted)
 	if err != nil {
 		return nil, fmt.Errorf("error querying suppliers: %v", err)
 	}
@@ -26,7 +27,7 @@ func (repo *SupplierRepository) GetSuppliers() ([]models.Supplier, error) {
 
 	for rows.Next() {
 		var supplier models.Supplier
-		if err := rows.Scan(&supplier.SupplierID, &supplier.SupplierName, &supplier.OrderCycle, &supplier.SelectedDays, &supplier.SortOrder); err != nil {
+		if err := rows.Scan(&supplier.SupplierID, &supplier.SupplierName, &supplier.OrderCycle, &supplier.SelectedDays, &supplier.SortOrder, &supplier.DeletedAt); err != nil {
 			return nil, fmt.Errorf("error scanning supplier row: %v", err)
 		}
 		suppliers = append(suppliers, supplier)
@@ -35,7 +36,7 @@ func (repo *SupplierRepository) GetSuppliers() ([]models.Supplier, error) {
 	return suppliers, nil
 }
 
-func (repo *SupplierRepository) SaveSupplierSettings(suppliers []models.Supplier) error {
+func (repo *SupplierRepository) SaveSupplierSettings(merchantID string, suppliers []models.Supplier) error {
 	tx, err := repo.db.Begin()
 	if err != nil {
 		return fmt.Errorf("error starting transaction: %v", err)
@@ -45,7 +46,7 @@ func (repo *SupplierRepository) SaveSupplierSettings(suppliers []models.Supplier
 	stmt, err := tx.Prepare(`
         UPDATE loysuppliers
         SET supplier_name = $1, order_cycle = $2, selected_days = $3, sort_order = $4
-        WHERE supplier_id = $5
+        WHERE supplier_id = $5 AND merchant_id = $6
     `)
 	if err != nil {
 		return fmt.Errorf("error preparing statement: %v", err)
@@ -53,7 +54,7 @@ func (repo *SupplierRepository) SaveSupplierSettings(suppliers []models.Supplier
 	defer stmt.Close()
 
 	for _, supplier := range suppliers {
-		_, err := stmt.Exec(supplier.SupplierName, supplier.OrderCycle.String, supplier.SelectedDays.String, supplier.SortOrder, supplier.SupplierID)
+		_, err := stmt.Exec(supplier.SupplierName, supplier.OrderCycle.String, supplier.SelectedDays.String, supplier.SortOrder, supplier.SupplierID, merchantID)
 		if err != nil {
 			return fmt.Errorf("error executing statement: %v", err)
 		}
@@ -65,9 +66,9 @@ func (repo *SupplierRepository) SaveSupplierSettings(suppliers []models.Supplier
 	return nil
 }
 
-// FetchSupplierCycles fetches supplier cycles from the database
-func (repo *SupplierRepository) FetchSupplierCycles() ([]models.Supplier, error) {
-	rows, err := repo.db.Query("SELECT supplier_id, supplier_name, order_cycle, selected_days FROM loysuppliers")
+// FetchSupplierCycles fetches supplier cycles from the database (suppliers deleted in Loyverse are skipped)
+func (repo *SupplierRepository) FetchSupplierCycles(merchantID string) ([]models.Supplier, error) {
+	rows, err := repo.db.Query("SELECT supplier_id, supplier_name, order_cycle, selected_days FROM loysuppliers WHERE merchant_id = $1 AND deleted_at IS NULL", merchantID)
 	if err != nil {
 		return nil, err
 	}
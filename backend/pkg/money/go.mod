@@ -0,0 +1,5 @@
+module backend/pkg/money
+
+go 1.21
+
+require github.com/shopspring/decimal v1.4.0
@@ -0,0 +1,133 @@
+// Package money คือชนิดจำนวนเงินที่ใช้ร่วมกันระหว่าง backend/external/loyverse และบริการใน backend/internal
+// ทุก module อ้างถึง package นี้ผ่าน replace directive ใน go.mod จึงมี Money เพียงชุดเดียว
+package money
+
+import (
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// Places คือจำนวนตำแหน่งทศนิยมของเงินบาท (สตางค์) ที่ใช้เมื่อต้องปัดเศษ
+const Places = 2
+
+// Money คือจำนวนเงินแบบทศนิยมแน่นอน ใช้แทน float64 ตั้งแต่การ decode JSON ของ Loyverse
+// การบันทึก/อ่านคอลัมน์ NUMERIC และ JSONB ของ Postgres การคำนวณรายงาน จนถึง JSON ที่ API ส่งออก
+//
+// กฎการปัดเศษ:
+//   - ค่าที่ได้จาก Loyverse และฐานข้อมูลถูกเก็บตามที่ได้รับโดยไม่ปัด ผลบวก ลบ และผลรวมจึงตรงทุกสตางค์
+//   - ปัดเศษเฉพาะเมื่อผลลัพธ์มีทศนิยมไม่รู้จบ คือการหาร (Div) ซึ่งปัดเป็น Places ตำแหน่งเสมอ
+//   - Round ปัดเป็น Places ตำแหน่งแบบครึ่งหนึ่งปัดออกจากศูนย์ (0.005 → 0.01, -0.005 → -0.01)
+//
+// JSON ของ Money เป็นตัวเลข (ไม่มีเครื่องหมายคำพูด) ที่เขียนทศนิยมครบตามค่าจริง เช่น 1234.50 → 1234.5
+// และรับได้ทั้งตัวเลขและ string ตอน decode
+type Money struct {
+	d decimal.Decimal
+}
+
+// FromInt สร้าง Money จากจำนวนบาทเต็ม
+func FromInt(baht int64) Money {
+	return Money{d: decimal.NewFromInt(baht)}
+}
+
+// FromFloat สร้าง Money จาก float64 โดยใช้ทศนิยมที่สั้นที่สุดที่แทน float นั้นได้ (0.1 → 0.1)
+// ใช้กับค่าที่เป็น float อยู่แล้วเท่านั้น ค่าจาก API หรือฐานข้อมูลควรผ่าน JSON หรือ Scan โดยตรง
+func FromFloat(f float64) Money {
+	return Money{d: decimal.NewFromFloat(f)}
+}
+
+// Parse แปลง string ทศนิยม เช่น "1234.50" เป็น Money
+func Parse(s string) (Money, error) {
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid money amount %q: %w", s, err)
+	}
+	return Money{d: d}, nil
+}
+
+// Add คืนค่า m + other
+func (m Money) Add(other Money) Money {
+	return Money{d: m.d.Add(other.d)}
+}
+
+// Sub คืนค่า m - other
+func (m Money) Sub(other Money) Money {
+	return Money{d: m.d.Sub(other.d)}
+}
+
+// Neg คืนค่า -m
+func (m Money) Neg() Money {
+	return Money{d: m.d.Neg()}
+}
+
+// MulQuantity คูณด้วยจำนวนชิ้น (ซึ่งอาจมีทศนิยม เช่นน้ำหนัก) โดยไม่ปัดเศษ
+func (m Money) MulQuantity(quantity float64) Money {
+	return Money{d: m.d.Mul(decimal.NewFromFloat(quantity))}
+}
+
+// Div หารด้วย n และปัดเป็น Places ตำแหน่งแบบครึ่งหนึ่งปัดออกจากศูนย์ หาร 0 คืนค่า 0
+func (m Money) Div(n int64) Money {
+	if n == 0 {
+		return Money{}
+	}
+	return Money{d: m.d.DivRound(decimal.NewFromInt(n), Places)}
+}
+
+// Round ปัดเป็น Places ตำแหน่งแบบครึ่งหนึ่งปัดออกจากศูนย์
+func (m Money) Round() Money {
+	return Money{d: m.d.Round(Places)}
+}
+
+// IsZero คืนค่า true เมื่อ m เท่ากับ 0
+func (m Money) IsZero() bool {
+	return m.d.IsZero()
+}
+
+// Cmp เปรียบเทียบ m กับ other คืนค่า -1, 0 หรือ 1
+func (m Money) Cmp(other Money) int {
+	return m.d.Cmp(other.d)
+}
+
+// Equal คืนค่า true เมื่อ m และ other มีค่าเท่ากัน (1.5 เท่ากับ 1.50)
+func (m Money) Equal(other Money) bool {
+	return m.d.Equal(other.d)
+}
+
+// Float64 แปลงเป็น float64 สำหรับค่าที่ไม่ใช่ยอดเงิน เช่นอัตราส่วนหรือเปอร์เซ็นต์ (อาจคลาดเคลื่อนตาม float)
+func (m Money) Float64() float64 {
+	f, _ := m.d.Float64()
+	return f
+}
+
+// String คืนค่าทศนิยมครบตามค่าจริง เช่น "1234.5"
+func (m Money) String() string {
+	return m.d.String()
+}
+
+// MarshalJSON เขียน Money เป็นตัวเลข JSON โดยไม่ผ่าน float
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.d.String()), nil
+}
+
+// UnmarshalJSON อ่านตัวเลขหรือ string ทศนิยม (null ให้ค่า 0)
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*m = Money{}
+		return nil
+	}
+	return m.d.UnmarshalJSON(data)
+}
+
+// Scan อ่านค่าจากคอลัมน์ NUMERIC (คอลัมน์ที่เป็น NULL ได้ให้ scan ลง *Money)
+func (m *Money) Scan(value interface{}) error {
+	if value == nil {
+		return fmt.Errorf("converting NULL to Money is unsupported")
+	}
+	return m.d.Scan(value)
+}
+
+// Value ส่งค่าเป็นข้อความทศนิยมเพื่อให้ Postgres แปลงเป็น NUMERIC ได้ตรงทุกหลัก
+func (m Money) Value() (driver.Value, error) {
+	return m.d.String(), nil
+}
@@ -70,17 +70,42 @@ const SyncDataPage = () => {
         setSettings((prev) => ({ ...prev, [name]: value }));
     };
 
-    // ฟังก์ชันสำหรับซิงค์แต่ละประเภท
-    const handleSync = async (endpoint) => {
+    // ฟังก์ชันสำหรับซิงค์แต่ละประเภท: สั่งเป็น job เบื้องหลังแล้วติดตามความคืบหน้าผ่าน SSE
+    const handleSync = async (entity) => {
         try {
-            const response = await fetch(endpoint, { method: "POST" });
-            if (response.ok) {
-                setStatus(`Data synced successfully from ${endpoint}`);
-            } else {
-                setStatus(`Failed to sync data from ${endpoint}`);
+            const response = await fetch(`http://localhost:8080/api/sync/jobs?entity=${entity}`, { method: "POST" });
+            if (!response.ok) {
+                setStatus(`Failed to start ${entity} sync`);
+                return;
             }
+            const job = await response.json();
+            setStatus(job.merged ? `${entity} sync is already running, following it...` : `${entity} sync started`);
+
+            const events = new EventSource(`http://localhost:8080/api/sync/jobs/${job.id}/events`);
+            let pages = 0;
+            let rows = 0;
+            events.addEventListener("page", (e) => {
+                const event = JSON.parse(e.data);
+                pages += 1;
+                setStatus(`${entity}: fetched ${event.resource} page ${event.page} (${pages} pages, ${rows} rows saved)`);
+            });
+            events.addEventListener("rows", (e) => {
+                rows += JSON.parse(e.data).rows;
+                setStatus(`${entity}: ${pages} pages fetched, ${rows} rows saved`);
+            });
+            events.addEventListener("error", (e) => {
+                // event error จาก server มี data ส่วน error ของ EventSource เอง (เช่นการเชื่อมต่อหลุด) ไม่มี
+                if (e.data) setStatus(`${entity}: ${JSON.parse(e.data).message}`);
+            });
+            events.addEventListener("completed", (e) => {
+                const event = JSON.parse(e.data);
+                events.close();
+                setStatus(event.status === "succeeded"
+                    ? `${entity} synced successfully (${pages} pages, ${rows} rows saved)`
+                    : `Failed to sync ${entity}: ${event.message}`);
+            });
         } catch (error) {
-            setStatus("Error: Could not complete sync");
+            setStatus("Error: Could not start sync");
         }
     };
 
@@ -122,19 +147,19 @@ const SyncDataPage = () => {
             <div className="bg-white p-6 rounded-xl shadow-md space-y-4 w-full max-w-lg">
                 <h3 className="text-xl font-semibold mb-4">Manual Data Sync</h3>
                 <button 
-                    onClick={() => handleSync("http://localhost:8080/api/sync-master-data")}
+                    onClick={() => handleSync("master_data")}
                     className="bg-blue-500 text-white px-4 py-2 rounded-md w-full hover:bg-blue-600 transition duration-200"
                 >
                     Sync Master Data
                 </button>
                 <button 
-                    onClick={() => handleSync("http://localhost:8080/api/sync-receipts")}
+                    onClick={() => handleSync("receipts")}
                     className="bg-green-500 text-white px-4 py-2 rounded-md w-full hover:bg-green-600 transition duration-200"
                 >
                     Sync Receipts
                 </button>
                 <button 
-                    onClick={() => handleSync("http://localhost:8080/api/sync-inventory-levels")}
+                    onClick={() => handleSync("inventory_levels")}
                     className="bg-purple-500 text-white px-4 py-2 rounded-md w-full hover:bg-purple-600 transition duration-200"
                 >
                     Sync Inventory Levels